@@ -0,0 +1,43 @@
+// Command fakebluesky serves a fake Bluesky PDS for local runs:
+//
+//	go run ./cmd/fakebluesky --addr :9092
+//	PLATFORMS=instagram,bluesky BLUESKY_PDS_URL=http://localhost:9092 \
+//	  BLUESKY_HANDLE=test.bsky.social BLUESKY_APP_PASSWORD=test go run ./cmd/publisher publish
+package main
+
+import (
+	"flag"
+	"net/http"
+	"os"
+
+	"github.com/nitin737/GoAutoPosts/internal/bluesky"
+	"github.com/nitin737/GoAutoPosts/internal/fakebluesky"
+	"github.com/nitin737/GoAutoPosts/internal/logger"
+)
+
+func main() {
+	addr := flag.String("addr", ":9092", "listen address")
+	appPassword := flag.String("app-password", "", "the only app password to accept (any when empty)")
+	flag.Parse()
+
+	logger := logger.NewDevelopmentLogger()
+
+	server := fakebluesky.NewServer(fakebluesky.Options{AppPassword: *appPassword})
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.Debug("Request", "path", r.URL.Path)
+		server.ServeHTTP(w, r)
+		if r.URL.Path == "/xrpc/com.atproto.repo.createRecord" {
+			if posts := server.Posts(); len(posts) > 0 {
+				last := posts[len(posts)-1]
+				logger.Info("Post", "uri", last.URI, "graphemes", bluesky.Graphemes(last.Text), "facets", last.Facets, "altText", last.AltText)
+			}
+		}
+	})
+
+	logger.Info("Fake Bluesky listening", "addr", *addr)
+	if err := http.ListenAndServe(*addr, handler); err != nil {
+		logger.Error("Fake Bluesky failed", "error", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,52 @@
+// Command fakegraph serves a fake Instagram Graph API for local runs:
+//
+//	go run ./cmd/fakegraph --addr :9090 --polls 2
+//	GRAPH_API_URL=http://localhost:9090 go run ./cmd/publisher publish
+package main
+
+import (
+	"flag"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/fakegraph"
+	"github.com/nitin737/GoAutoPosts/internal/logger"
+)
+
+func main() {
+	addr := flag.String("addr", ":9090", "listen address")
+	polls := flag.Int("polls", 2, "status polls each container stays IN_PROGRESS")
+	failURL := flag.String("fail-url", "", "containers whose image_url or video_url contains this end in ERROR")
+	expireURL := flag.String("expire-url", "", "containers whose image_url contains this end in EXPIRED")
+	transient := flag.Int("transient-failures", 0, "POSTs that answer a transient 500 before any succeeds")
+	lostPublishes := flag.Int("lost-publishes", 0, "media_publish calls that publish but answer a 500")
+	fetchMedia := flag.Bool("fetch", true, "download media URLs like Instagram does; a failed download ends in ERROR")
+	tokenDays := flag.Int("token-days", 60, "lifetime of exchanged tokens in days")
+	missingScope := flag.String("missing-scope", "", "permission left out of every token")
+	flag.Parse()
+
+	logger := logger.NewDevelopmentLogger()
+
+	server := fakegraph.NewServer(fakegraph.Options{
+		ProcessingPolls:   *polls,
+		FailImageURL:      *failURL,
+		ExpireImageURL:    *expireURL,
+		TransientFailures: *transient,
+		LostPublishes:     *lostPublishes,
+		TokenLifetime:     time.Duration(*tokenDays) * 24 * time.Hour,
+		MissingScope:      *missingScope,
+		FetchMedia:        *fetchMedia,
+	})
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.Debug("Request", "method", r.Method, "path", r.URL.Path)
+		server.ServeHTTP(w, r)
+	})
+
+	logger.Info("Fake Graph API listening", "addr", *addr)
+	if err := http.ListenAndServe(*addr, handler); err != nil {
+		logger.Error("Fake Graph API failed", "error", err)
+		os.Exit(1)
+	}
+}
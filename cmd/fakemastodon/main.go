@@ -0,0 +1,46 @@
+// Command fakemastodon serves a fake Mastodon instance for local runs:
+//
+//	go run ./cmd/fakemastodon --addr :9091
+//	PLATFORMS=instagram,mastodon MASTODON_URL=http://localhost:9091 \
+//	  MASTODON_ACCESS_TOKEN=test go run ./cmd/publisher publish
+package main
+
+import (
+	"flag"
+	"net/http"
+	"os"
+
+	"github.com/nitin737/GoAutoPosts/internal/fakemastodon"
+	"github.com/nitin737/GoAutoPosts/internal/logger"
+)
+
+func main() {
+	addr := flag.String("addr", ":9091", "listen address")
+	maxChars := flag.Int("max-chars", 500, "status character limit")
+	polls := flag.Int("polls", 1, "media polls each upload stays unprocessed")
+	flag.Parse()
+
+	logger := logger.NewDevelopmentLogger()
+
+	server := fakemastodon.NewServer(fakemastodon.Options{
+		MaxCharacters:   *maxChars,
+		ProcessingPolls: *polls,
+	})
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.Debug("Request", "method", r.Method, "path", r.URL.Path)
+		server.ServeHTTP(w, r)
+		if r.URL.Path == "/api/v1/statuses" {
+			if statuses := server.Statuses(); len(statuses) > 0 {
+				last := statuses[len(statuses)-1]
+				logger.Info("Status", "id", last.ID, "chars", len([]rune(last.Text)), "media", len(last.MediaIDs), "altText", last.AltText)
+			}
+		}
+	})
+
+	logger.Info("Fake Mastodon listening", "addr", *addr)
+	if err := http.ListenAndServe(*addr, handler); err != nil {
+		logger.Error("Fake Mastodon failed", "error", err)
+		os.Exit(1)
+	}
+}
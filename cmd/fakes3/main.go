@@ -0,0 +1,37 @@
+// Command fakes3 serves an in-memory S3-compatible store for local runs:
+//
+//	go run ./cmd/fakes3 --addr :9000 --access-key fake --secret-key fake
+//	MEDIA_HOST=s3 S3_ENDPOINT=http://localhost:9000 S3_BUCKET=media \
+//	  S3_ACCESS_KEY_ID=fake S3_SECRET_ACCESS_KEY=fake go run ./cmd/publisher publish
+package main
+
+import (
+	"flag"
+	"net/http"
+	"os"
+
+	"github.com/nitin737/GoAutoPosts/internal/fakes3"
+	"github.com/nitin737/GoAutoPosts/internal/logger"
+)
+
+func main() {
+	addr := flag.String("addr", ":9000", "listen address")
+	accessKey := flag.String("access-key", "", "access key ID requests must be signed with; empty accepts any")
+	secretKey := flag.String("secret-key", "", "secret access key to check signatures with; empty only checks the access key")
+	flag.Parse()
+
+	logger := logger.NewDevelopmentLogger()
+
+	server := fakes3.NewServer(*accessKey, *secretKey)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.Debug("Request", "method", r.Method, "path", r.URL.Path)
+		server.ServeHTTP(w, r)
+	})
+
+	logger.Info("Fake S3 listening", "addr", *addr)
+	if err := http.ListenAndServe(*addr, handler); err != nil {
+		logger.Error("Fake S3 failed", "error", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/config"
+	"github.com/nitin737/GoAutoPosts/internal/hashtag"
+	"github.com/nitin737/GoAutoPosts/internal/hosting"
+	"github.com/nitin737/GoAutoPosts/internal/image"
+	"github.com/nitin737/GoAutoPosts/internal/logger"
+	"github.com/nitin737/GoAutoPosts/internal/model"
+	"github.com/nitin737/GoAutoPosts/internal/preview"
+	"github.com/nitin737/GoAutoPosts/internal/selector"
+	"github.com/nitin737/GoAutoPosts/internal/store"
+	"github.com/nitin737/GoAutoPosts/internal/template"
+	"github.com/nitin737/GoAutoPosts/internal/video"
+)
+
+// reelFile is the name of the rendered reel inside a post's output directory
+const reelFile = "reel.mp4"
+
+// app holds the components shared by every subcommand
+type app struct {
+	cfg        *config.Config
+	logger     *logger.Logger
+	selector   *selector.LibrarySelector
+	hashtagGen *hashtag.Generator
+	renderer   *template.Renderer
+	imageGen   *image.Generator
+	videoGen   *video.Renderer
+	store      store.Repository
+	catalog    store.LibraryCatalog
+	queue      *store.JSONQueueStore
+	tokens     store.TokenStore
+}
+
+// post is a fully rendered post that is ready to be published
+type post struct {
+	library    *model.Library
+	hashtags   []string
+	caption    string
+	imagePaths []string
+	// videoPath is the rendered reel, empty for carousels
+	videoPath string
+	// storyPath is the Story teaser, empty when no Story is posted
+	storyPath string
+}
+
+func newApp(cfg *config.Config, logger *logger.Logger) (*app, error) {
+	renderer, err := template.NewRenderer()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize template renderer: %w", err)
+	}
+
+	imageGen, err := image.NewGenerator(cfg.ImageBasePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize image generator: %w", err)
+	}
+
+	strategy, err := selector.StrategyByName(cfg.SelectionStrategy)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := store.Open(cfg.StoreBackend, cfg.PostedPath, cfg.SQLitePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open store: %w", err)
+	}
+
+	catalog, err := store.OpenCatalog(cfg.CatalogBackend, cfg.LibrariesPath, cfg.SQLitePath)
+	if err != nil {
+		history.Close()
+		return nil, fmt.Errorf("failed to open catalog: %w", err)
+	}
+
+	tokens, err := store.OpenTokenStore(cfg.TokenStoreBackend, cfg.TokenPath, cfg.SQLitePath)
+	if err != nil {
+		history.Close()
+		catalog.Close()
+		return nil, fmt.Errorf("failed to open token store: %w", err)
+	}
+
+	librarySelector := selector.NewLibrarySelector(catalog, history, selector.Options{
+		Strategy: strategy,
+		Rules: selector.Rules{
+			CooldownDays:       cfg.CooldownDays,
+			CategoryGapDays:    cfg.CategoryGapDays,
+			AuthorGapDays:      cfg.AuthorGapDays,
+			CategoryMaxPosts:   cfg.CategoryMaxPosts,
+			CategoryWindowDays: cfg.CategoryWindowDays,
+		},
+		Seed:         int64(cfg.SelectionSeed),
+		SeedFromDate: cfg.SeedFromDate,
+	})
+
+	return &app{
+		cfg:        cfg,
+		logger:     logger,
+		selector:   librarySelector,
+		hashtagGen: hashtag.NewGenerator(),
+		renderer:   renderer,
+		imageGen:   imageGen,
+		videoGen:   video.NewRenderer(cfg.FFmpegPath),
+		store:      history,
+		catalog:    catalog,
+		queue:      store.NewJSONQueueStore(cfg.QueuePath),
+		tokens:     tokens,
+	}, nil
+}
+
+// close releases the resources held by the app
+func (a *app) close() error {
+	return errors.Join(a.store.Close(), a.catalog.Close(), a.tokens.Close())
+}
+
+// buildPost generates hashtags, caption and carousel images for a library,
+// and for reels the slideshow video made from the images
+func (a *app) buildPost(ctx context.Context, library *model.Library, outputDir string, format model.PostFormat) (*post, error) {
+	a.logger.Info("Generating hashtags...")
+	hashtags := a.hashtagGen.Generate(library)
+	a.logger.Info("Generated hashtags", "count", len(hashtags))
+
+	a.logger.Info("Rendering caption...")
+	caption, err := a.renderer.RenderCaption(library, hashtags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render caption: %w", err)
+	}
+
+	a.logger.Info("Generating carousel images...")
+	imagePaths, err := a.imageGen.GenerateCarousel(ctx, library, outputDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate carousel: %w", err)
+	}
+	a.logger.Info("Carousel generated", "count", len(imagePaths), "dir", outputDir, "paths", imagePaths)
+
+	p := &post{
+		library:    library,
+		hashtags:   hashtags,
+		caption:    caption,
+		imagePaths: imagePaths,
+	}
+
+	if format == model.PostFormatReel {
+		a.logger.Info("Rendering reel...")
+		p.videoPath = filepath.Join(outputDir, reelFile)
+		if err := a.videoGen.Slideshow(ctx, imagePaths, p.videoPath, a.reelOptions()); err != nil {
+			return nil, fmt.Errorf("failed to render reel: %w", err)
+		}
+		a.logger.Info("Reel rendered", "path", p.videoPath)
+	}
+
+	return p, nil
+}
+
+// postFormat resolves a --format flag, falling back to POST_FORMAT
+func (a *app) postFormat(name string) (model.PostFormat, error) {
+	if name == "" {
+		name = a.cfg.PostFormat
+	}
+	return model.ParsePostFormat(name)
+}
+
+// reelOptions returns the slideshow settings from the configuration
+func (a *app) reelOptions() video.Options {
+	return video.Options{
+		SlideDuration:      time.Duration(a.cfg.ReelSlideSeconds) * time.Second,
+		Transition:         a.cfg.ReelTransition,
+		TransitionDuration: video.DefaultTransitionDuration,
+		AudioPath:          a.cfg.ReelAudioPath,
+	}
+}
+
+// mediaHost creates the MEDIA_HOST backend Instagram downloads media from
+func (a *app) mediaHost(cfg *config.Config) (hosting.MediaHost, error) {
+	switch cfg.MediaHost {
+	case hosting.BackendLocal:
+		a.logger.Info("Starting local media server", "port", cfg.ServerPort, "publicURL", cfg.PublicURL)
+		return hosting.NewLocalServer(hosting.LocalConfig{
+			Addr:            ":" + cfg.ServerPort,
+			BaseURL:         cfg.PublicURL,
+			URLExpiry:       time.Duration(cfg.ServerURLExpiry) * time.Second,
+			ShutdownTimeout: time.Duration(cfg.ServerShutdownTimeout) * time.Second,
+		})
+	case hosting.BackendS3:
+		return hosting.NewS3Host(hosting.S3Config{
+			Endpoint:        cfg.S3Endpoint,
+			Region:          cfg.S3Region,
+			Bucket:          cfg.S3Bucket,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretAccessKey,
+			Prefix:          cfg.S3Prefix,
+			PublicURL:       cfg.S3PublicURL,
+			URLExpiry:       time.Duration(cfg.S3URLExpiry) * time.Second,
+		})
+	case hosting.BackendPages:
+		return hosting.NewPagesHost(hosting.PagesConfig{
+			Dir:         cfg.PagesDir,
+			BaseURL:     cfg.PagesBaseURL,
+			Push:        cfg.PagesPush,
+			WaitTimeout: time.Duration(cfg.PagesWaitTimeout) * time.Second,
+		})
+	default:
+		return nil, fmt.Errorf("unknown media host: %s", cfg.MediaHost)
+	}
+}
+
+// clearPreview removes the previous preview from dir, so slides of an
+// earlier library do not end up next to the new manifest
+func (a *app) clearPreview(dir string) error {
+	if err := preview.NewWriter(dir).Clear(); err != nil {
+		return fmt.Errorf("failed to clear preview: %w", err)
+	}
+	return nil
+}
+
+// writePreview stores a rendered post in dir for review
+func (a *app) writePreview(p *post, dir string) error {
+	manifestPath, err := preview.NewWriter(dir).Write(p.library, p.caption, p.hashtags, preview.Media{
+		Slides: p.imagePaths,
+		Video:  p.videoPath,
+		Story:  p.storyPath,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write preview: %w", err)
+	}
+	a.logger.Info("Preview written", "library", p.library.Name, "manifest", manifestPath)
+	return nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/model"
+	"github.com/nitin737/GoAutoPosts/internal/store"
+)
+
+func runHistory(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: history list | history remove <name> | history import [--from file]")
+	}
+
+	switch args[0] {
+	case "list":
+		return historyList(ctx, a, args[1:])
+	case "remove":
+		return historyRemove(ctx, a, args[1:])
+	case "import":
+		return historyImport(ctx, a, args[1:])
+	default:
+		return fmt.Errorf("unknown history command %q", args[0])
+	}
+}
+
+func historyList(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("history list", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	records, err := a.store.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load posted history: %w", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "POSTED AT\tLIBRARY\tCATEGORY\tSTATUS\tREMOTE IDS")
+	for _, record := range records {
+		status := string(record.Status)
+		if status == "" {
+			status = string(model.PostStatusPublished)
+		}
+		if record.Status == model.PostStatusFailed {
+			status = fmt.Sprintf("failed after %s", record.LastStep)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			record.PostedAt.Format(time.RFC3339),
+			record.Library.Name,
+			record.Library.Category,
+			status,
+			remoteIDs(&record),
+		)
+	}
+	return w.Flush()
+}
+
+// remoteIDs lists where a post went out as platform:id pairs
+func remoteIDs(record *model.PostedLibrary) string {
+	ids := make(map[string]string, len(record.RemoteIDs)+1)
+	for name, id := range record.RemoteIDs {
+		ids[name] = id
+	}
+	if id := record.RemoteID(model.PlatformInstagram); id != "" {
+		ids[model.PlatformInstagram] = id
+	}
+
+	names := make([]string, 0, len(ids))
+	for name := range ids {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + ":" + ids[name]
+	}
+	return strings.Join(pairs, " ")
+}
+
+func historyRemove(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("history remove", flag.ContinueOnError)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: history remove <name>")
+	}
+
+	removed, err := a.store.DeleteByName(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Removed %d record(s) for %s\n", removed, positional[0])
+	return nil
+}
+
+// historyImport copies a JSON posted history into the configured store,
+// skipping records that are already there so it can be re-run safely
+func historyImport(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("history import", flag.ContinueOnError)
+	from := fs.String("from", a.cfg.PostedPath, "JSON posted history to import")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if a.cfg.StoreBackend != store.BackendSQLite {
+		return fmt.Errorf("history import needs STORE_BACKEND=%s", store.BackendSQLite)
+	}
+
+	records, err := store.NewJSONStore(*from).GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", *from, err)
+	}
+
+	existing, err := a.store.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load posted history: %w", err)
+	}
+
+	imported := 0
+	for i := range records {
+		if containsPost(existing, &records[i]) {
+			continue
+		}
+		if err := a.store.Save(ctx, &records[i]); err != nil {
+			return fmt.Errorf("failed to import %s: %w", records[i].Library.Name, err)
+		}
+		imported++
+	}
+
+	fmt.Printf("Imported %d of %d records from %s\n", imported, len(records), *from)
+	return nil
+}
+
+func containsPost(records []model.PostedLibrary, posted *model.PostedLibrary) bool {
+	for _, record := range records {
+		if record.Library.Name == posted.Library.Name && record.PostedAt.Equal(posted.PostedAt) {
+			return true
+		}
+	}
+	return false
+}
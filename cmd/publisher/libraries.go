@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/nitin737/GoAutoPosts/internal/model"
+	"github.com/nitin737/GoAutoPosts/internal/store"
+)
+
+const librariesUsage = "usage: libraries list [--category c] [--tag t] [--all] | validate | add [flags] | update <name> [flags] | enable <name> | disable <name> | remove <name> | import [--from file]"
+
+func runLibraries(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 {
+		return errors.New(librariesUsage)
+	}
+
+	switch args[0] {
+	case "list":
+		return librariesList(ctx, a, args[1:])
+	case "validate":
+		return librariesValidate(ctx, a, args[1:])
+	case "add":
+		return librariesAdd(ctx, a, args[1:])
+	case "update":
+		return librariesUpdate(ctx, a, args[1:])
+	case "enable":
+		return librariesSetEnabled(ctx, a, args[1:], true)
+	case "disable":
+		return librariesSetEnabled(ctx, a, args[1:], false)
+	case "remove":
+		return librariesRemove(ctx, a, args[1:])
+	case "import":
+		return librariesImport(ctx, a, args[1:])
+	default:
+		return fmt.Errorf("unknown libraries command %q", args[0])
+	}
+}
+
+func librariesList(ctx context.Context, a *app, args []string) error {
+	var q store.LibraryQuery
+
+	fs := flag.NewFlagSet("libraries list", flag.ContinueOnError)
+	fs.StringVar(&q.Category, "category", "", "only libraries in this category")
+	fs.StringVar(&q.Tag, "tag", "", "only libraries with this tag")
+	fs.BoolVar(&q.IncludeDisabled, "all", false, "include disabled libraries")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	libraries, err := a.catalog.Query(ctx, q)
+	if err != nil {
+		return fmt.Errorf("failed to query catalog: %w", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tCATEGORY\tSTARS\tENABLED\tTAGS")
+	for _, lib := range libraries {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%t\t%s\n", lib.Name, lib.Category, lib.Stars, lib.Enabled, strings.Join(lib.Tags, ","))
+	}
+	return w.Flush()
+}
+
+func librariesValidate(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("libraries validate", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	libraries, err := a.catalog.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load catalog: %w", err)
+	}
+
+	problems := 0
+	seen := make(map[string]bool)
+	for i, lib := range libraries {
+		if err := lib.Validate(); err != nil {
+			for _, line := range strings.Split(err.Error(), "\n") {
+				fmt.Printf("Library %d (%s): %s\n", i, lib.Name, line)
+				problems++
+			}
+		}
+		if lib.Name != "" && seen[lib.Name] {
+			fmt.Printf("Library %d (%s): duplicate name\n", i, lib.Name)
+			problems++
+		}
+		seen[lib.Name] = true
+	}
+
+	if problems > 0 {
+		return fmt.Errorf("validation failed with %d errors", problems)
+	}
+
+	fmt.Printf("Validation successful! %d libraries validated.\n", len(libraries))
+	return nil
+}
+
+// libraryFlags registers the editable library fields on fs
+func libraryFlags(fs *flag.FlagSet, lib *model.Library, tags *string) {
+	fs.StringVar(&lib.Description, "description", lib.Description, "short description")
+	fs.StringVar(&lib.URL, "url", lib.URL, "repository URL")
+	fs.StringVar(&lib.Category, "category", lib.Category, "category")
+	fs.StringVar(tags, "tags", *tags, "comma separated tags")
+	fs.IntVar(&lib.Stars, "stars", lib.Stars, "GitHub stars")
+	fs.StringVar(&lib.Author, "author", lib.Author, "author or organisation")
+}
+
+func splitTags(tags string) []string {
+	var out []string
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			out = append(out, tag)
+		}
+	}
+	return out
+}
+
+func librariesAdd(ctx context.Context, a *app, args []string) error {
+	lib := model.Library{Enabled: true}
+	var tags string
+
+	fs := flag.NewFlagSet("libraries add", flag.ContinueOnError)
+	fs.StringVar(&lib.Name, "name", "", "library name")
+	libraryFlags(fs, &lib, &tags)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	lib.Tags = splitTags(tags)
+
+	if err := a.catalog.Add(ctx, &lib); err != nil {
+		return err
+	}
+
+	fmt.Printf("Added %s\n", lib.Name)
+	return nil
+}
+
+func librariesUpdate(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return fmt.Errorf("usage: libraries update <name> [flags]")
+	}
+
+	lib, err := a.catalog.GetByName(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	tags := strings.Join(lib.Tags, ",")
+
+	fs := flag.NewFlagSet("libraries update", flag.ContinueOnError)
+	libraryFlags(fs, lib, &tags)
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	lib.Tags = splitTags(tags)
+
+	if err := a.catalog.Update(ctx, lib); err != nil {
+		return err
+	}
+
+	fmt.Printf("Updated %s\n", lib.Name)
+	return nil
+}
+
+func librariesSetEnabled(ctx context.Context, a *app, args []string, enabled bool) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: libraries enable|disable <name>")
+	}
+
+	if err := a.catalog.SetEnabled(ctx, args[0], enabled); err != nil {
+		return err
+	}
+
+	state := "Disabled"
+	if enabled {
+		state = "Enabled"
+	}
+	fmt.Printf("%s %s\n", state, args[0])
+	return nil
+}
+
+func librariesRemove(ctx context.Context, a *app, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: libraries remove <name>")
+	}
+
+	if err := a.catalog.Remove(ctx, args[0]); err != nil {
+		return err
+	}
+
+	fmt.Printf("Removed %s\n", args[0])
+	return nil
+}
+
+func librariesImport(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("libraries import", flag.ContinueOnError)
+	from := fs.String("from", a.cfg.LibrariesPath, "JSON catalog to import")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	libraries, err := store.NewJSONCatalog(*from).GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", *from, err)
+	}
+
+	imported := 0
+	for i := range libraries {
+		if _, err := a.catalog.GetByName(ctx, libraries[i].Name); err == nil {
+			continue
+		}
+		if err := a.catalog.Add(ctx, &libraries[i]); err != nil {
+			return err
+		}
+		imported++
+	}
+
+	fmt.Printf("Imported %d of %d libraries\n", imported, len(libraries))
+	return nil
+}
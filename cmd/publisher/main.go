@@ -1,27 +1,65 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/nitin737/GoAutoPosts/internal/config"
-	"github.com/nitin737/GoAutoPosts/internal/hashtag"
-	"github.com/nitin737/GoAutoPosts/internal/image"
-	"github.com/nitin737/GoAutoPosts/internal/instagram"
 	"github.com/nitin737/GoAutoPosts/internal/logger"
-	"github.com/nitin737/GoAutoPosts/internal/model"
-	"github.com/nitin737/GoAutoPosts/internal/selector"
-	"github.com/nitin737/GoAutoPosts/internal/store"
-	"github.com/nitin737/GoAutoPosts/internal/template"
 )
 
+// exitAlreadyPosted is the exit status when publish finds a post for today,
+// so schedulers can tell a skipped run from a failed one
+const exitAlreadyPosted = 3
+
+// command is a publisher subcommand
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(ctx context.Context, a *app, args []string) error
+}
+
+var commands = []command{
+	{"publish", "publish [--library name [--force]] [--dry-run]", "select today's library and publish it", runPublish},
+	{"preview", "preview <library> [--out dir]", "render a library's post into a preview directory", runPreview},
+	{"render", "render <library> --out dir", "render a library's slides only", runRender},
+	{"plan", "plan [--days 30] [--start YYYY-MM-DD]", "simulate upcoming picks without writing anything", runPlan},
+	{"queue", "queue list | add | remove | move", "manage the editorial queue", runQueue},
+	{"history", "history list | remove <name> | import", "inspect or edit the posted history", runHistory},
+	{"libraries", "libraries list | add | update | enable | ...", "manage the library catalog", runLibraries},
+	{"token", "token check | exchange [--token value]", "check, refresh or replace the access token", runToken},
+}
+
 func main() {
 	// Initialize logger
 	logger := logger.NewLogger()
-	logger.Info("Starting daily publisher...")
+
+	// Without a subcommand (or with only flags) the publisher keeps its
+	// original behaviour and runs the daily publish.
+	name, args := "publish", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	if name == "help" {
+		usage()
+		return
+	}
+
+	cmd, ok := findCommand(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		usage()
+		os.Exit(2)
+	}
 
 	// Load configuration
 	cfg, err := config.Load()
@@ -30,109 +68,87 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Initialize components
-	selector := selector.NewLibrarySelector(cfg.LibrariesPath, cfg.PostedPath)
-	hashtagGen := hashtag.NewGenerator()
-	renderer, err := template.NewRenderer()
+	a, err := newApp(cfg, logger)
 	if err != nil {
-		logger.Error("Failed to initialize template renderer", "error", err)
+		logger.Error("Failed to initialize", "error", err)
 		os.Exit(1)
 	}
 
-	imageGen, err := image.NewGenerator(cfg.ImageBasePath)
-	if err != nil {
-		logger.Error("Failed to initialize image generator", "error", err)
-		os.Exit(1)
+	// SIGINT or SIGTERM cancels the run; in-flight HTTP calls are aborted and
+	// an unfinished post is recorded as failed. The first signal restores the
+	// default handling, so a second one kills it.
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	context.AfterFunc(sigCtx, stop)
+	ctx, cancel := withTimeout(sigCtx, cfg.RunTimeout)
+
+	err = cmd.run(ctx, a, args)
+	cancel()
+	if closeErr := a.close(); closeErr != nil {
+		logger.Error("Failed to close storage", "error", closeErr)
 	}
-
-	instagramClient := instagram.NewClient(cfg.InstagramAccessToken, cfg.InstagramAccountID, cfg.GraphAPIURL)
-	publisher := instagram.NewPublisher(instagramClient)
-	store := store.NewJSONStore(cfg.PostedPath)
-
-	// Start local file server to serve images
-	if cfg.PublicURL != "" {
-		go func() {
-			logger.Info("Starting local file server", "port", cfg.ServerPort, "dir", "/tmp")
-			if err := http.ListenAndServe(":"+cfg.ServerPort, http.FileServer(http.Dir("/tmp"))); err != nil {
-				logger.Error("Local file server failed", "error", err)
-			}
-		}()
-	} else {
-		logger.Warn("PUBLIC_URL is not set. Instagram publishing will fail for carousel items.")
+	switch status := exitStatus(err); status {
+	case 0:
+	case exitAlreadyPosted:
+		logger.Info("Nothing to do", "reason", err)
+		os.Exit(status)
+	default:
+		logger.Error("Command failed", "command", cmd.name, "error", err)
+		os.Exit(status)
 	}
+}
 
-	// Step 1: Select a random library
-	logger.Info("Selecting random library...")
-	library, err := selector.SelectRandom()
-	if err != nil {
-		logger.Error("Failed to select library", "error", err)
-		os.Exit(1)
+// exitStatus returns the exit status for the error a command returned
+func exitStatus(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errAlreadyPosted):
+		return exitAlreadyPosted
+	default:
+		return 1
 	}
-	logger.Info("Selected library", "name", library.Name, "category", library.Category)
-
-	// Step 2: Generate hashtags
-	logger.Info("Generating hashtags...")
-	hashtags := hashtagGen.Generate(library)
-	logger.Info("Generated hashtags", "count", len(hashtags))
+}
 
-	// Step 3: Render caption
-	logger.Info("Rendering caption...")
-	caption, err := renderer.RenderCaption(library, hashtags)
-	if err != nil {
-		logger.Error("Failed to render caption", "error", err)
-		os.Exit(1)
+// withTimeout bounds ctx by the given seconds; 0 leaves it unbounded, as it
+// does for a single request
+func withTimeout(ctx context.Context, seconds int) (context.Context, context.CancelFunc) {
+	if seconds <= 0 {
+		return context.WithCancel(ctx)
 	}
+	return context.WithTimeout(ctx, time.Duration(seconds)*time.Second)
+}
 
-	// Step 4: Generate images (Carousel)
-	logger.Info("Generating carousel images...")
-	// Use a clean directory
-	outputDir := fmt.Sprintf("/tmp/go-daily-%s-%d", library.Name, time.Now().Unix())
-	imagePaths, err := imageGen.GenerateCarousel(library, outputDir)
-	if err != nil {
-		logger.Error("Failed to generate carousel", "error", err)
-		os.Exit(1)
-	}
-	logger.Info("Carousel generated", "count", len(imagePaths), "dir", outputDir, "paths", imagePaths)
-
-	// Step 5: Publish to Instagram
-	logger.Info("Publishing to Instagram...")
-
-	// Convert local paths to public URLs
-	var imageURLs []string
-	for _, path := range imagePaths {
-		if cfg.PublicURL != "" {
-			// Assuming path starts with /tmp/
-			relPath := strings.TrimPrefix(path, "/tmp/")
-			// Ensure we don't need double slashes
-			relPath = strings.TrimPrefix(relPath, "/")
-			url := fmt.Sprintf("%s/%s", strings.TrimRight(cfg.PublicURL, "/"), relPath)
-			imageURLs = append(imageURLs, url)
-		} else {
-			imageURLs = append(imageURLs, path)
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
 		}
 	}
+	return command{}, false
+}
 
-	postID, err := publisher.PublishCarousel(imageURLs, caption)
-	if err != nil {
-		logger.Error("Failed to publish to Instagram", "error", err)
-		os.Exit(1)
-	}
-	logger.Info("Successfully published to Instagram", "postID", postID)
-
-	// Step 6: Update posted history
-	logger.Info("Updating posted history...")
-	postedLibrary := &model.PostedLibrary{
-		Library:  *library,
-		PostedAt: time.Now(),
-		PostID:   postID,
-		// Store the first image path as reference or comma separated
-		ImagePath: imagePaths[0],
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: publisher <command> [arguments]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-45s %s\n", cmd.usage, cmd.summary)
 	}
+}
 
-	if err := store.Save(postedLibrary); err != nil {
-		logger.Error("Failed to save posted history", "error", err)
-		// Don't exit here - the post was successful
+// parseArgs parses flags that may appear before or after positional
+// arguments and returns the positional arguments in order.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
 	}
-
-	logger.Info("Daily publisher completed successfully", "library", library.Name, "postID", postID)
 }
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWithTimeout(t *testing.T) {
+	ctx, cancel := withTimeout(context.Background(), 0)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok || ctx.Err() != nil {
+		t.Errorf("withTimeout(0) has a deadline or is done (%v), want unbounded", ctx.Err())
+	}
+
+	ctx, cancel = withTimeout(context.Background(), 60)
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	if !ok || time.Until(deadline) < 59*time.Second || time.Until(deadline) > 60*time.Second {
+		t.Errorf("withTimeout(60) deadline = %v, %v, want a minute from now", deadline, ok)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+func runPlan(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("plan", flag.ContinueOnError)
+	days := fs.Int("days", 30, "number of days to simulate")
+	startStr := fs.String("start", "", "first simulated day as YYYY-MM-DD (defaults to today)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	if *startStr != "" {
+		date, err := time.Parse("2006-01-02", *startStr)
+		if err != nil {
+			return fmt.Errorf("invalid --start: %w", err)
+		}
+		// Keep the current time of day so cooldowns line up with a real run
+		start = date.Add(start.Sub(start.Truncate(24 * time.Hour)))
+	}
+
+	queue, err := a.queue.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load queue: %w", err)
+	}
+
+	entries, err := a.selector.Plan(ctx, start, *days, queue)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATE\tLIBRARY\tCATEGORY\tAUTHOR\tSOURCE")
+	for _, entry := range entries {
+		date := entry.Date.Format("2006-01-02 Mon")
+		if entry.Err != nil {
+			fmt.Fprintf(w, "%s\t-\t-\t-\t%v\n", date, entry.Err)
+			continue
+		}
+		source := "selector"
+		if entry.Queued {
+			source = "queue"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", date, entry.Library.Name, entry.Library.Category, entry.Library.Author, source)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+)
+
+func runPreview(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("preview", flag.ContinueOnError)
+	out := fs.String("out", "", "preview directory (defaults to PREVIEW_DIR)")
+	formatName := fs.String("format", "", "carousel or reel (defaults to POST_FORMAT)")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: preview <library> [--out dir] [--format carousel|reel]")
+	}
+
+	format, err := a.postFormat(*formatName)
+	if err != nil {
+		return err
+	}
+
+	dir := a.cfg.PreviewDir
+	if *out != "" {
+		dir = *out
+	}
+
+	library, err := a.selector.FindByName(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+
+	if err := a.clearPreview(dir); err != nil {
+		return err
+	}
+	p, err := a.buildPost(ctx, library, dir, format)
+	if err != nil {
+		return err
+	}
+
+	return a.writePreview(p, dir)
+}
+
+func runRender(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("render", flag.ContinueOnError)
+	out := fs.String("out", "", "output directory for the slides")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 || *out == "" {
+		return fmt.Errorf("usage: render <library> --out dir")
+	}
+
+	library, err := a.selector.FindByName(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+
+	paths, err := a.imageGen.GenerateCarousel(ctx, library, *out)
+	if err != nil {
+		return fmt.Errorf("failed to generate carousel: %w", err)
+	}
+
+	for _, path := range paths {
+		fmt.Println(path)
+	}
+	return nil
+}
@@ -0,0 +1,538 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/bluesky"
+	"github.com/nitin737/GoAutoPosts/internal/config"
+	"github.com/nitin737/GoAutoPosts/internal/hosting"
+	"github.com/nitin737/GoAutoPosts/internal/image"
+	"github.com/nitin737/GoAutoPosts/internal/instagram"
+	"github.com/nitin737/GoAutoPosts/internal/mastodon"
+	"github.com/nitin737/GoAutoPosts/internal/model"
+	"github.com/nitin737/GoAutoPosts/internal/platform"
+	"github.com/nitin737/GoAutoPosts/internal/selector"
+)
+
+// errAlreadyPosted means today's post exists, so the run stops without
+// publishing and exits with exitAlreadyPosted
+var errAlreadyPosted = errors.New("already posted today")
+
+func runPublish(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("publish", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "render the post into the preview directory without publishing")
+	previewDir := fs.String("preview-dir", "", "directory for dry-run output (overrides PREVIEW_DIR)")
+	libraryName := fs.String("library", "", "publish this library instead of a random pick")
+	force := fs.Bool("force", false, "with --library, publish even if the library is inside its cooldown")
+	resume := fs.Bool("resume", false, "continue the last unfinished post from its last completed step")
+	allowMultiple := fs.Bool("allow-multiple", false, "publish even if a post already exists for today")
+	formatName := fs.String("format", "", "carousel or reel (defaults to POST_FORMAT)")
+	story := fs.Bool("story", a.cfg.StoryEnabled, "also publish a Story teaser once the post is live (defaults to STORY_ENABLED)")
+	platforms := fs.String("platforms", "", "comma-separated platforms to publish to (overrides PLATFORMS)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// Flags apply to this run only, so they go on a copy of the configuration
+	runCfg := *a.cfg
+	cfg := &runCfg
+	if *dryRun {
+		cfg.DryRun = true
+	}
+	if *previewDir != "" {
+		cfg.PreviewDir = *previewDir
+	}
+	cfg.StoryEnabled = *story
+	if *platforms != "" {
+		cfg.Platforms = *platforms
+	}
+	if *resume && (cfg.DryRun || *libraryName != "" || *formatName != "") {
+		return fmt.Errorf("--resume cannot be combined with --dry-run, --library or --format")
+	}
+	format, err := a.postFormat(*formatName)
+	if err != nil {
+		return err
+	}
+	if !cfg.DryRun {
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("invalid configuration: %w", err)
+		}
+	}
+
+	a.logger.Info("Starting daily publisher...")
+
+	// Guard against a second run on the same day, e.g. the cron and a manual
+	// dispatch. Dry runs never publish and resumed runs continue the post
+	// that would trip it, so both skip it.
+	if !cfg.DryRun && !*resume && !*allowMultiple {
+		if err := a.checkNotPostedToday(ctx, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	// Check the Instagram access token before doing any work, refreshing it
+	// when it is close to expiry, and set up the host Instagram downloads
+	// media from
+	var host hosting.MediaHost
+	if cfg.DryRun {
+		a.logger.Info("Dry run enabled, nothing will be published", "previewDir", cfg.PreviewDir)
+	} else if publishesTo(cfg, platform.Instagram) {
+		if _, err := a.ensureToken(ctx, cfg); err != nil {
+			return err
+		}
+
+		host, err = a.mediaHost(cfg)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := host.Close(); err != nil {
+				a.logger.Warn("Failed to close media host", "error", err)
+			}
+		}()
+	}
+
+	if *resume {
+		rec, err := a.lastUnfinishedPost(ctx)
+		if err != nil {
+			return err
+		}
+		a.logger.Info("Resuming post", "id", rec.ID, "library", rec.Library.Name, "completed", rec.CompletedStep())
+		queued, err := a.queuedEntryFor(ctx, rec)
+		if err != nil {
+			return err
+		}
+		return a.runPost(ctx, cfg, host, rec, queued)
+	}
+
+	// Step 1: Select a library
+	library, queued, err := a.selectLibrary(ctx, *libraryName, *force)
+	if err != nil {
+		return fmt.Errorf("failed to select library: %w", err)
+	}
+	a.logger.Info("Selected library", "name", library.Name, "category", library.Category)
+
+	// Dry run renders into the preview directory and leaves the API and
+	// history untouched
+	if cfg.DryRun {
+		if err := a.clearPreview(cfg.PreviewDir); err != nil {
+			return err
+		}
+		p, err := a.buildPost(ctx, library, cfg.PreviewDir, format)
+		if err != nil {
+			return err
+		}
+		if cfg.StoryEnabled {
+			if p.storyPath, err = a.imageGen.GenerateStory(ctx, library, cfg.PreviewDir); err != nil {
+				return fmt.Errorf("failed to generate story: %w", err)
+			}
+		}
+		if err := a.writePreview(p, cfg.PreviewDir); err != nil {
+			return err
+		}
+		a.logger.Info("Dry run completed", "library", library.Name, "format", format)
+		return nil
+	}
+
+	// Record the selection so a failed run can be resumed
+	rec := model.NewPost(library, time.Now())
+	rec.Format = format
+	if err := a.savePost(ctx, rec, *allowMultiple); err != nil {
+		return err
+	}
+
+	return a.runPost(ctx, cfg, host, rec, queued)
+}
+
+// checkNotPostedToday returns errAlreadyPosted when a post for the UTC date
+// of now is published or still in flight, so a second run stops before
+// doing any work. savePost checks again when the new post is saved.
+func (a *app) checkNotPostedToday(ctx context.Context, now time.Time) error {
+	records, err := a.store.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load posted history: %w", err)
+	}
+
+	return notPostedToday(records, now)
+}
+
+// notPostedToday returns errAlreadyPosted when one of records is a post for
+// the UTC date of now. Failed posts do not count unless they reached a
+// platform; they are continued with publish --resume.
+func notPostedToday(records []model.PostedLibrary, now time.Time) error {
+	today := now.UTC().Format(model.QueueDateFormat)
+	for _, record := range records {
+		if record.Status == model.PostStatusFailed && !record.ReachedPlatform() {
+			continue
+		}
+		if record.PostedAt.UTC().Format(model.QueueDateFormat) == today {
+			return fmt.Errorf("%w: %s (%s) on %s (use --allow-multiple to post again)",
+				errAlreadyPosted, record.Library.Name, record.CompletedStep(), today)
+		}
+	}
+
+	return nil
+}
+
+// savePost saves the record of a newly selected post. Unless allowMultiple
+// is set, the store repeats the same-day check while it holds its lock, so
+// of two runs that both passed checkNotPostedToday only the first saves.
+func (a *app) savePost(ctx context.Context, rec *model.PostedLibrary, allowMultiple bool) error {
+	check := func(records []model.PostedLibrary) error {
+		return notPostedToday(records, rec.PostedAt)
+	}
+	if allowMultiple {
+		check = func([]model.PostedLibrary) error { return nil }
+	}
+
+	err := a.store.SaveIf(ctx, rec, check)
+	if err != nil && !errors.Is(err, errAlreadyPosted) {
+		return fmt.Errorf("failed to save post record: %w", err)
+	}
+	return err
+}
+
+// runPost takes a post record through the steps it has not completed yet,
+// saving the record after each one. On failure the record is marked failed
+// with the last completed step so publish --resume can pick it up. Media
+// uploaded to host is released as soon as Instagram is done with it, before
+// any cross-posting, and otherwise deleted once the run ends, whether it
+// succeeded or not; Instagram has copied it by the time a container is ready.
+func (a *app) runPost(ctx context.Context, cfg *config.Config, host hosting.MediaHost, rec *model.PostedLibrary, queued *model.QueueEntry) error {
+	publishCtx, cancel := withTimeout(ctx, cfg.PublishTimeout)
+	defer cancel()
+
+	uploads := hosting.NewUploads(host)
+	defer func() {
+		if err := uploads.Cleanup(context.WithoutCancel(ctx)); err != nil {
+			a.logger.Warn("Failed to clean up uploaded media", "error", err)
+		}
+	}()
+
+	if err := a.advancePost(publishCtx, cfg, uploads, rec); err != nil {
+		if ctx.Err() != nil {
+			err = fmt.Errorf("publish interrupted: %w", err)
+		}
+		rec.Fail(err)
+		if updateErr := a.store.Update(context.WithoutCancel(ctx), rec); updateErr != nil {
+			a.logger.Error("Failed to save post record", "error", updateErr)
+		}
+		return fmt.Errorf("%w (run publish --resume to retry from %s)", err, rec.LastStep)
+	}
+
+	if queued != nil {
+		if err := a.queue.RemoveEntry(context.WithoutCancel(ctx), queued); err != nil {
+			a.logger.Error("Failed to remove published entry from queue", "error", err)
+		}
+	}
+
+	a.logger.Info("Daily publisher completed successfully", "library", rec.Library.Name, "remoteIDs", rec.RemoteIDs)
+	return nil
+}
+
+func (a *app) advancePost(ctx context.Context, cfg *config.Config, uploads *hosting.Uploads, rec *model.PostedLibrary) error {
+	// Steps 2-4: Hashtags, caption, carousel images and for reels the video.
+	// Media from an earlier run is re-rendered if it is gone and a platform
+	// still needs it.
+	if !rec.Completed(model.PostStatusRendered) || (needsMedia(cfg, rec) && !filesExist(rec.MediaPaths())) {
+		// Use a clean directory
+		outputDir := fmt.Sprintf("/tmp/go-daily-%s-%d", rec.Library.Name, time.Now().Unix())
+		p, err := a.buildPost(ctx, &rec.Library, outputDir, rec.Format)
+		if err != nil {
+			return err
+		}
+
+		rec.Caption = p.caption
+		rec.ImagePaths = p.imagePaths
+		rec.ImagePath = p.imagePaths[0]
+		rec.VideoPath = p.videoPath
+		if err := a.advance(ctx, rec, model.PostStatusRendered); err != nil {
+			return err
+		}
+	}
+
+	platforms, err := platform.ParseNames(cfg.Platforms)
+	if err != nil {
+		return err
+	}
+
+	post := platform.Post{
+		ID:       rec.ID,
+		Library:  &rec.Library,
+		Caption:  rec.Caption,
+		Hashtags: a.hashtagGen.Generate(&rec.Library),
+		Slides:   rec.ImagePaths,
+		AltText:  image.AltTexts(&rec.Library),
+		Video:    rec.VideoPath,
+	}
+
+	// Steps 5-6: Publish on every platform the post is not on yet, Instagram
+	// first. A resumed post skips the platforms it already reached.
+	for _, name := range platforms {
+		// Instagram, always first, has fetched every file by the time its
+		// containers are FINISHED, so the media is released before the
+		// platforms that upload their own copies
+		if name != platform.Instagram {
+			if err := uploads.Release(context.WithoutCancel(ctx)); err != nil {
+				a.logger.Warn("Failed to release uploaded media", "error", err)
+			}
+		}
+
+		if id := rec.RemoteID(name); id != "" {
+			a.logger.Info("Already published", "platform", name, "id", id)
+			continue
+		}
+
+		publisher, err := a.platformPublisher(cfg, name, uploads, rec)
+		if err != nil {
+			return err
+		}
+
+		a.logger.Info("Publishing...", "platform", name)
+		id, err := publisher.Publish(ctx, post)
+		if err != nil {
+			return fmt.Errorf("failed to publish to %s: %w", name, err)
+		}
+		a.logger.Info("Successfully published", "platform", name, "id", id)
+
+		rec.SetRemoteID(name, string(id))
+		if err := a.store.Update(context.WithoutCancel(ctx), rec); err != nil {
+			a.logger.Error("Failed to save posted history", "error", err)
+			// Don't fail here - the post was successful
+		}
+
+		// Step 7: Story teaser. The post is already live, so a failed Story
+		// is only logged.
+		if ig, ok := publisher.(*instagram.Platform); ok && cfg.StoryEnabled {
+			if err := a.publishStory(ctx, ig, rec); err != nil {
+				a.logger.Warn("Failed to publish story", "error", err)
+			}
+		}
+	}
+
+	rec.PostedAt = time.Now()
+	if err := a.advance(ctx, rec, model.PostStatusPublished); err != nil {
+		a.logger.Error("Failed to save posted history", "error", err)
+	}
+
+	return nil
+}
+
+// platformPublisher creates the publisher for the named platform. Instagram
+// resumes from the containers of an earlier attempt and records new ones on
+// rec as soon as they are ready. Containers that expired or failed are
+// cleared and rec goes back to rendered until they are created again; if the
+// rendered media is gone by then, the next resume renders it again.
+func (a *app) platformPublisher(cfg *config.Config, name string, uploads *hosting.Uploads, rec *model.PostedLibrary) (platform.Publisher, error) {
+	switch name {
+	case platform.Instagram:
+		publisher := instagram.NewPublisher(a.graphClient(cfg))
+		publisher.SetContainerPolling(time.Duration(cfg.ContainerPollTimeout)*time.Second, instagram.DefaultPollInterval)
+		publisher.SetConcurrency(cfg.GraphConcurrency)
+		publisher.SetReelPollTimeout(time.Duration(cfg.ReelPollTimeout) * time.Second)
+
+		var containers instagram.Containers
+		if rec.Completed(model.PostStatusContainersCreated) {
+			containers = instagram.Containers{ContainerID: rec.ContainerID, ChildIDs: rec.ChildIDs}
+		}
+
+		ig := instagram.NewPlatform(publisher, uploads)
+		ig.SetContainers(containers, func(ctx context.Context, containers instagram.Containers) error {
+			rec.ContainerID = containers.ContainerID
+			rec.ChildIDs = containers.ChildIDs
+			if containers.ContainerID == "" {
+				a.logger.Warn("Instagram containers expired or failed, creating them again", "id", rec.ID)
+				return a.advance(ctx, rec, model.PostStatusRendered)
+			}
+			return a.advance(ctx, rec, model.PostStatusContainersCreated)
+		})
+		return ig, nil
+	case platform.Mastodon:
+		client := mastodon.NewClient(cfg.MastodonURL, cfg.MastodonAccessToken)
+		client.SetTimeout(time.Duration(cfg.GraphTimeout) * time.Second)
+		client.SetRetryPolicy(retryPolicy(cfg))
+		return mastodon.NewPublisher(client, cfg.MastodonVisibility), nil
+	case platform.Bluesky:
+		client := bluesky.NewClient(cfg.BlueskyPDSURL)
+		client.SetTimeout(time.Duration(cfg.GraphTimeout) * time.Second)
+		client.SetRetryPolicy(retryPolicy(cfg))
+		return bluesky.NewPublisher(client, a.renderer, cfg.BlueskyHandle, cfg.BlueskyAppPassword), nil
+	default:
+		return nil, fmt.Errorf("unknown platform: %s", name)
+	}
+}
+
+// publishStory renders the Story teaser next to the slides and publishes it
+func (a *app) publishStory(ctx context.Context, ig *instagram.Platform, rec *model.PostedLibrary) error {
+	storyPath, err := a.imageGen.GenerateStory(ctx, &rec.Library, filepath.Dir(rec.ImagePath))
+	if err != nil {
+		return fmt.Errorf("failed to generate story: %w", err)
+	}
+
+	a.logger.Info("Publishing Instagram story...")
+	storyID, err := ig.PublishStory(ctx, storyPath)
+	if err != nil {
+		return err
+	}
+	a.logger.Info("Successfully published story", "storyID", storyID)
+
+	rec.StoryID = storyID
+	if err := a.store.Update(context.WithoutCancel(ctx), rec); err != nil {
+		return fmt.Errorf("failed to save post record: %w", err)
+	}
+	return nil
+}
+
+// advance records a completed step and saves the post record. The save
+// ignores cancellation so an interrupted run still records what it finished.
+func (a *app) advance(ctx context.Context, rec *model.PostedLibrary, step model.PostStatus) error {
+	rec.Advance(step)
+	if err := a.store.Update(context.WithoutCancel(ctx), rec); err != nil {
+		return fmt.Errorf("failed to save post record: %w", err)
+	}
+	a.logger.Info("Post step completed", "id", rec.ID, "step", step)
+	return nil
+}
+
+// lastUnfinishedPost returns the most recently selected post that has not
+// been published
+func (a *app) lastUnfinishedPost(ctx context.Context) (*model.PostedLibrary, error) {
+	records, err := a.store.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load posted history: %w", err)
+	}
+
+	var last *model.PostedLibrary
+	for i := range records {
+		if records[i].IsPublished() || records[i].ID == "" {
+			continue
+		}
+		if last == nil || records[i].PostedAt.After(last.PostedAt) {
+			last = &records[i]
+		}
+	}
+
+	if last == nil {
+		return nil, fmt.Errorf("no unfinished post to resume")
+	}
+	return last, nil
+}
+
+// queuedEntryFor returns the queue entry a post was most likely selected
+// from, so a resumed post removes it once published: an entry for the same
+// library pinned to the day the post was selected, or else an undated one.
+// It returns nil when the library is not queued.
+func (a *app) queuedEntryFor(ctx context.Context, rec *model.PostedLibrary) (*model.QueueEntry, error) {
+	queue, err := a.queue.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load queue: %w", err)
+	}
+
+	for _, pinned := range []bool{true, false} {
+		for i, entry := range queue {
+			if entry.Library == rec.Library.Name && entry.DueOn(rec.PostedAt) && (entry.Date != "") == pinned {
+				return &queue[i], nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// publishesTo reports whether name is one of the configured platforms
+func publishesTo(cfg *config.Config, name string) bool {
+	platforms, err := platform.ParseNames(cfg.Platforms)
+	if err != nil {
+		return false
+	}
+	for _, p := range platforms {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// needsMedia reports whether a platform the post has not reached yet still
+// has to upload its media. Instagram containers keep their own copy.
+func needsMedia(cfg *config.Config, rec *model.PostedLibrary) bool {
+	platforms, err := platform.ParseNames(cfg.Platforms)
+	if err != nil {
+		return true
+	}
+
+	for _, name := range platforms {
+		if rec.RemoteID(name) != "" {
+			continue
+		}
+		if name == platform.Instagram && rec.Completed(model.PostStatusContainersCreated) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+func filesExist(paths []string) bool {
+	if len(paths) == 0 {
+		return false
+	}
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// selectLibrary picks the named library when one is given, otherwise the
+// first due queue entry, otherwise a random one. The queue entry is returned
+// so it can be removed once the post is published.
+func (a *app) selectLibrary(ctx context.Context, name string, force bool) (*model.Library, *model.QueueEntry, error) {
+	if name != "" {
+		a.logger.Info("Selecting requested library...", "name", name, "force", force)
+		if force {
+			library, err := a.selector.FindByName(ctx, name)
+			return library, nil, err
+		}
+
+		library, err := a.selector.SelectByName(ctx, name)
+		if errors.Is(err, selector.ErrNotEligible) {
+			return nil, nil, fmt.Errorf("%w (use --force to publish anyway)", err)
+		}
+		return library, nil, err
+	}
+
+	a.logger.Info("Checking editorial queue...")
+	queue, err := a.queue.List(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load queue: %w", err)
+	}
+
+	pick, skipped, err := a.selector.SelectQueued(ctx, queue, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, reason := range skipped {
+		a.logger.Warn("Skipping queued library", "reason", reason)
+	}
+	for _, entry := range queue {
+		if entry.Overdue(time.Now()) {
+			a.logger.Warn("Queued library is past its date and will not be published; move or remove it",
+				"name", entry.Library, "date", entry.Date)
+		}
+	}
+	if pick != nil {
+		a.logger.Info("Using queued library", "name", pick.Library.Name, "date", pick.Entry.Date, "note", pick.Entry.Note)
+		return pick.Library, &pick.Entry, nil
+	}
+
+	a.logger.Info("Selecting random library...")
+	library, err := a.selector.SelectRandom(ctx)
+	return library, nil, err
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"image/png"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/config"
+	"github.com/nitin737/GoAutoPosts/internal/fakegraph"
+	"github.com/nitin737/GoAutoPosts/internal/hosting"
+	"github.com/nitin737/GoAutoPosts/internal/image"
+	"github.com/nitin737/GoAutoPosts/internal/instagram"
+	"github.com/nitin737/GoAutoPosts/internal/logger"
+	"github.com/nitin737/GoAutoPosts/internal/model"
+	"github.com/nitin737/GoAutoPosts/internal/platform"
+	"github.com/nitin737/GoAutoPosts/internal/selector"
+	"github.com/nitin737/GoAutoPosts/internal/store"
+)
+
+// newTestApp returns an app over an empty catalog and the given history,
+// configured to publish to Mastodon only
+func newTestApp(t *testing.T, history ...*model.PostedLibrary) *app {
+	t.Helper()
+
+	dir := t.TempDir()
+	catalogPath := filepath.Join(dir, "libraries.json")
+	if err := os.WriteFile(catalogPath, []byte("[]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	posted := store.NewJSONStore(filepath.Join(dir, "posted.json"))
+	for _, rec := range history {
+		if err := posted.Save(context.Background(), rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	catalog := store.NewJSONCatalog(catalogPath)
+
+	return &app{
+		cfg: &config.Config{
+			Platforms:           "mastodon",
+			MastodonURL:         "http://127.0.0.1:0",
+			MastodonAccessToken: "token",
+			PostFormat:          string(model.PostFormatCarousel),
+		},
+		logger:   logger.NewLogger(),
+		selector: selector.NewLibrarySelector(catalog, posted, selector.Options{}),
+		store:    posted,
+		catalog:  catalog,
+		queue:    store.NewJSONQueueStore(filepath.Join(dir, "queue.json")),
+	}
+}
+
+// postAt returns a post of gin selected at postedAt that got as far as step
+func postAt(postedAt time.Time, step model.PostStatus) *model.PostedLibrary {
+	rec := model.NewPost(&model.Library{Name: "gin"}, postedAt)
+	rec.Advance(step)
+	if step == model.PostStatusPublished {
+		rec.SetRemoteID(platform.Mastodon, "110")
+	}
+	return rec
+}
+
+func TestPublishOncePerDay(t *testing.T) {
+	now := time.Now()
+	failed := postAt(now, model.PostStatusRendered)
+	failed.Fail(errors.New("upload failed"))
+	failedLive := postAt(now, model.PostStatusPublished)
+	failedLive.Fail(errors.New("story failed"))
+
+	tests := []struct {
+		name    string
+		history []*model.PostedLibrary
+		args    []string
+		// skipped is true when the run stops as already posted; otherwise it
+		// goes on to select the library, which is not in the catalog
+		skipped bool
+	}{
+		{"nothing posted", nil, nil, false},
+		{"published yesterday", []*model.PostedLibrary{postAt(now.AddDate(0, 0, -1), model.PostStatusPublished)}, nil, false},
+		{"published today", []*model.PostedLibrary{postAt(now, model.PostStatusPublished)}, nil, true},
+		{"in flight today", []*model.PostedLibrary{postAt(now, model.PostStatusRendered)}, nil, true},
+		{"failed today before any platform", []*model.PostedLibrary{failed}, nil, false},
+		{"failed today after a platform", []*model.PostedLibrary{failedLive}, nil, true},
+		{"allow multiple", []*model.PostedLibrary{postAt(now, model.PostStatusPublished)}, []string{"--allow-multiple"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestApp(t, tt.history...)
+
+			err := runPublish(context.Background(), a, append(tt.args, "--library", "missing"))
+			if got := errors.Is(err, errAlreadyPosted); got != tt.skipped {
+				t.Fatalf("runPublish = %v, want already posted %v", err, tt.skipped)
+			}
+			if tt.skipped {
+				if !strings.Contains(err.Error(), "gin") {
+					t.Errorf("error %q does not name today's post", err)
+				}
+				if got := exitStatus(err); got != exitAlreadyPosted {
+					t.Errorf("exit status = %d, want %d", got, exitAlreadyPosted)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), "failed to select library") {
+				t.Errorf("runPublish = %v, want it to go on to select the library", err)
+			}
+			if got := exitStatus(err); got != 1 {
+				t.Errorf("exit status = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestSavePostRechecksToday(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	a := newTestApp(t)
+	if err := a.checkNotPostedToday(ctx, now); err != nil {
+		t.Fatalf("checkNotPostedToday: %v", err)
+	}
+
+	// Another run saves its post after this one passed the check
+	if err := a.savePost(ctx, postAt(now, model.PostStatusSelected), false); err != nil {
+		t.Fatalf("savePost of the other run: %v", err)
+	}
+
+	echo := model.NewPost(&model.Library{Name: "echo"}, now)
+	if err := a.savePost(ctx, echo, false); !errors.Is(err, errAlreadyPosted) {
+		t.Fatalf("savePost = %v, want already posted", err)
+	}
+	if err := a.savePost(ctx, echo, true); err != nil {
+		t.Fatalf("savePost with allowMultiple: %v", err)
+	}
+
+	records, err := a.store.GetAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Errorf("stored %d records, want 2", len(records))
+	}
+}
+
+func TestExitStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, 0},
+		{errAlreadyPosted, exitAlreadyPosted},
+		{errors.New("failed to publish to mastodon"), 1},
+	}
+
+	for _, tt := range tests {
+		if got := exitStatus(tt.err); got != tt.want {
+			t.Errorf("exitStatus(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+// fileHost serves uploads under https://media.example.com and remembers the
+// local paths it was given
+type fileHost struct {
+	paths []string
+}
+
+func (h *fileHost) Upload(ctx context.Context, path string) (string, error) {
+	h.paths = append(h.paths, path)
+	return "https://media.example.com/" + filepath.Base(path), nil
+}
+
+func (h *fileHost) Delete(ctx context.Context, url string) error { return nil }
+
+func (h *fileHost) Close() error { return nil }
+
+func TestPublishStory(t *testing.T) {
+	ctx := context.Background()
+	fake := fakegraph.NewServer(fakegraph.Options{})
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	a := newTestApp(t)
+	imageGen, err := image.NewGenerator("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.imageGen = imageGen
+
+	rec := postAt(time.Now(), model.PostStatusPublished)
+	rec.ImagePath = filepath.Join(t.TempDir(), "gin_cover.png")
+	if err := a.store.Save(ctx, rec); err != nil {
+		t.Fatal(err)
+	}
+
+	publisher := instagram.NewPublisher(instagram.NewClient("token", "1", server.URL))
+	publisher.SetContainerPolling(time.Second, time.Millisecond)
+	host := &fileHost{}
+	ig := instagram.NewPlatform(publisher, hosting.NewUploads(host))
+
+	if err := a.publishStory(ctx, ig, rec); err != nil {
+		t.Fatalf("publishStory: %v", err)
+	}
+
+	// The teaser is rendered next to the post's images on the Story canvas
+	if len(host.paths) != 1 || filepath.Dir(host.paths[0]) != filepath.Dir(rec.ImagePath) {
+		t.Fatalf("uploaded %v, want one story next to %s", host.paths, rec.ImagePath)
+	}
+	f, err := os.Open(host.paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	cfg, err := png.DecodeConfig(f)
+	if err != nil {
+		t.Fatalf("story is not a PNG: %v", err)
+	}
+	if cfg.Width != image.StoryWidth || cfg.Height != image.StoryHeight {
+		t.Errorf("story is %dx%d, want %dx%d", cfg.Width, cfg.Height, image.StoryWidth, image.StoryHeight)
+	}
+
+	published := fake.Published()
+	if len(published) != 1 || fake.MediaType(published[0]) != "STORIES" {
+		t.Fatalf("published %v, want one STORIES container", published)
+	}
+
+	saved, err := a.store.GetAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 1 || saved[0].StoryID == "" || saved[0].StoryID != rec.StoryID {
+		t.Errorf("saved story ID = %v, want %q", saved, rec.StoryID)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/model"
+)
+
+const queueUsage = "usage: queue list | queue add <library> [--date YYYY-MM-DD] [--note text] | queue remove <position> | queue move <from> <to>"
+
+func runQueue(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 {
+		return errors.New(queueUsage)
+	}
+
+	switch args[0] {
+	case "list":
+		return queueList(ctx, a, args[1:])
+	case "add":
+		return queueAdd(ctx, a, args[1:])
+	case "remove":
+		return queueRemove(ctx, a, args[1:])
+	case "move":
+		return queueMove(ctx, a, args[1:])
+	default:
+		return fmt.Errorf("unknown queue command %q", args[0])
+	}
+}
+
+func queueList(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("queue list", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	entries, err := a.queue.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load queue: %w", err)
+	}
+
+	now := time.Now()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "POS\tDATE\tLIBRARY\tNOTE")
+	for i, entry := range entries {
+		date := entry.Date
+		switch {
+		case date == "":
+			date = "any"
+		case entry.Overdue(now):
+			date += " (overdue)"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", i+1, date, entry.Library, entry.Note)
+	}
+	return w.Flush()
+}
+
+func queueAdd(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("queue add", flag.ContinueOnError)
+	date := fs.String("date", "", "pin the library to this day (YYYY-MM-DD); empty means any day it is eligible")
+	note := fs.String("note", "", "editorial note, e.g. the release being celebrated")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("usage: queue add <library> [--date YYYY-MM-DD] [--note text]")
+	}
+
+	if *date != "" {
+		if _, err := time.Parse(model.QueueDateFormat, *date); err != nil {
+			return fmt.Errorf("invalid --date: %w", err)
+		}
+	}
+
+	// Make sure the library exists before queueing it
+	library, err := a.selector.FindByName(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+
+	entry := &model.QueueEntry{
+		Library: library.Name,
+		Date:    *date,
+		Note:    *note,
+		AddedAt: time.Now(),
+	}
+	if err := a.queue.Add(ctx, entry); err != nil {
+		return fmt.Errorf("failed to add to queue: %w", err)
+	}
+
+	fmt.Printf("Queued %s\n", library.Name)
+	return nil
+}
+
+func queueRemove(ctx context.Context, a *app, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: queue remove <position>")
+	}
+
+	entry, err := queueEntryAt(ctx, a, args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := a.queue.RemoveEntry(ctx, entry); err != nil {
+		return err
+	}
+
+	fmt.Printf("Removed %s from the queue\n", entry.Library)
+	return nil
+}
+
+func queueMove(ctx context.Context, a *app, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: queue move <from> <to>")
+	}
+
+	from, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid position %q", args[0])
+	}
+	to, err := strconv.Atoi(args[1])
+	if err != nil {
+		return fmt.Errorf("invalid position %q", args[1])
+	}
+
+	return a.queue.Move(ctx, from, to)
+}
+
+// queueEntryAt returns the entry at a 1-based position given on the command line
+func queueEntryAt(ctx context.Context, a *app, pos string) (*model.QueueEntry, error) {
+	n, err := strconv.Atoi(pos)
+	if err != nil {
+		return nil, fmt.Errorf("invalid position %q", pos)
+	}
+
+	entries, err := a.queue.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load queue: %w", err)
+	}
+	if n < 1 || n > len(entries) {
+		return nil, fmt.Errorf("position out of range: queue has %d entries", len(entries))
+	}
+
+	return &entries[n-1], nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/config"
+	"github.com/nitin737/GoAutoPosts/internal/instagram"
+	"github.com/nitin737/GoAutoPosts/internal/model"
+	"github.com/nitin737/GoAutoPosts/internal/retry"
+)
+
+const day = 24 * time.Hour
+
+func runToken(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: token check | token exchange [--token value]")
+	}
+
+	switch args[0] {
+	case "check":
+		return tokenCheck(ctx, a, args[1:])
+	case "exchange":
+		return tokenExchange(ctx, a, args[1:])
+	default:
+		return fmt.Errorf("unknown token command %q", args[0])
+	}
+}
+
+func tokenCheck(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("token check", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	token, err := a.ensureToken(ctx, a.cfg)
+	if err != nil {
+		return err
+	}
+
+	printToken(token)
+	return nil
+}
+
+func tokenExchange(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("token exchange", flag.ContinueOnError)
+	value := fs.String("token", "", "short-lived token to exchange (defaults to INSTAGRAM_ACCESS_TOKEN)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *value == "" {
+		*value = a.cfg.InstagramAccessToken
+	}
+	if *value == "" {
+		return fmt.Errorf("usage: token exchange --token value (or set INSTAGRAM_ACCESS_TOKEN)")
+	}
+
+	manager := a.tokenManager(a.cfg, a.graphClient(a.cfg))
+	token, err := manager.Exchange(ctx, *value, time.Now())
+	if err != nil {
+		return err
+	}
+
+	a.logger.Info("Long-lived token stored", "expiresAt", token.ExpiresAt)
+	printToken(token)
+	return nil
+}
+
+// ensureToken settles on the access token for this run, refreshing it when
+// it is close to expiry, and stores it in cfg. It warns when the token
+// expires within TOKEN_WARN_DAYS.
+func (a *app) ensureToken(ctx context.Context, cfg *config.Config) (*model.AccessToken, error) {
+	manager := a.tokenManager(cfg, a.graphClient(cfg))
+	status, err := manager.Ensure(ctx, cfg.InstagramAccessToken, time.Now())
+	if errors.Is(err, instagram.ErrNoToken) {
+		return nil, fmt.Errorf("%w: set INSTAGRAM_ACCESS_TOKEN or run token exchange", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	token := status.Token
+	if status.Refreshed {
+		a.logger.Info("Access token refreshed", "expiresAt", token.ExpiresAt)
+	}
+	if status.RefreshErr != nil {
+		a.logger.Warn("Failed to refresh access token", "error", status.RefreshErr)
+	}
+	if status.ExpiresSoon {
+		a.logger.Warn("Access token expires soon, run token exchange with a new token",
+			"expiresAt", token.ExpiresAt, "daysLeft", int(time.Until(token.ExpiresAt)/day))
+	}
+	if !token.Expires() && !manager.CanRefresh() {
+		a.logger.Debug("Token expiry unknown; set FACEBOOK_APP_ID and FACEBOOK_APP_SECRET to track and refresh it")
+	}
+
+	cfg.InstagramAccessToken = token.Token
+	return token, nil
+}
+
+// tokenManager creates a token manager backed by the app's token store
+func (a *app) tokenManager(cfg *config.Config, client *instagram.Client) *instagram.TokenManager {
+	manager := instagram.NewTokenManager(client, a.tokens, cfg.FacebookAppID, cfg.FacebookAppSecret)
+	manager.SetInstagramLogin(cfg.InstagramLogin())
+	manager.SetWindows(time.Duration(cfg.TokenRefreshDays)*day, time.Duration(cfg.TokenWarnDays)*day)
+	return manager
+}
+
+// graphClient creates a Graph API client from the configuration
+func (a *app) graphClient(cfg *config.Config) *instagram.Client {
+	client := instagram.NewClient(cfg.InstagramAccessToken, cfg.InstagramAccountID, cfg.GraphAPIURL)
+	client.SetTimeout(time.Duration(cfg.GraphTimeout) * time.Second)
+	client.SetRetryPolicy(retryPolicy(cfg))
+	return client
+}
+
+// retryPolicy is the default retry policy with GRAPH_API_MAX_RETRIES, shared
+// by the clients of every platform
+func retryPolicy(cfg *config.Config) retry.Policy {
+	policy := retry.DefaultPolicy
+	policy.MaxRetries = cfg.GraphMaxRetries
+	return policy
+}
+
+func printToken(token *model.AccessToken) {
+	expires := "unknown"
+	if token.Expires() {
+		expires = fmt.Sprintf("%s (in %d days)", token.ExpiresAt.Format(time.RFC3339), int(time.Until(token.ExpiresAt)/day))
+	}
+
+	scopes := "not reported"
+	if len(token.Scopes) > 0 {
+		scopes = strings.Join(token.Scopes, ", ")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "EXPIRES\t%s\n", expires)
+	fmt.Fprintf(w, "REFRESHED\t%s\n", token.RefreshedAt.Format(time.RFC3339))
+	fmt.Fprintf(w, "SCOPES\t%s\n", scopes)
+	w.Flush()
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/config"
+	"github.com/nitin737/GoAutoPosts/internal/fakegraph"
+	"github.com/nitin737/GoAutoPosts/internal/logger"
+	"github.com/nitin737/GoAutoPosts/internal/model"
+	"github.com/nitin737/GoAutoPosts/internal/store"
+)
+
+func TestEnsureTokenWarnsBeforeExpiry(t *testing.T) {
+	server := httptest.NewServer(fakegraph.NewServer(fakegraph.Options{}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		name     string
+		daysLeft int
+		warnDays int
+		warns    bool
+	}{
+		{"inside the window", 5, 7, true},
+		{"outside the window", 5, 3, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			tokens := store.NewJSONTokenStore(filepath.Join(t.TempDir(), "token.json"))
+			stored := &model.AccessToken{
+				Token: "stored-token",
+				// Half a day on top, so the whole days left do not round down
+				ExpiresAt:   time.Now().Add(time.Duration(tt.daysLeft)*day + day/2),
+				RefreshedAt: time.Now().Add(-50 * day),
+				SeedHash:    model.TokenHash("env-token"),
+			}
+			if err := tokens.Save(ctx, stored); err != nil {
+				t.Fatal(err)
+			}
+
+			var logs bytes.Buffer
+			a := &app{
+				logger: &logger.Logger{Logger: slog.New(slog.NewJSONHandler(&logs, nil))},
+				tokens: tokens,
+			}
+			// Without app credentials the token cannot be refreshed, only warned about
+			cfg := &config.Config{
+				GraphAPIURL:          server.URL,
+				InstagramAccessToken: "env-token",
+				TokenRefreshDays:     10,
+				TokenWarnDays:        tt.warnDays,
+			}
+
+			token, err := a.ensureToken(ctx, cfg)
+			if err != nil {
+				t.Fatalf("ensureToken: %v", err)
+			}
+			if token.Token != "stored-token" || cfg.InstagramAccessToken != "stored-token" {
+				t.Errorf("token = %s, configured %s, want the stored token", token.Token, cfg.InstagramAccessToken)
+			}
+
+			warned := strings.Contains(logs.String(), `"msg":"Access token expires soon, run token exchange with a new token"`)
+			if warned != tt.warns {
+				t.Fatalf("warned = %v, want %v; logs:\n%s", warned, tt.warns, logs.String())
+			}
+			if warned && !strings.Contains(logs.String(), `"daysLeft":5`) {
+				t.Errorf("warning does not report 5 days left:\n%s", logs.String())
+			}
+		})
+	}
+}
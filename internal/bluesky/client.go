@@ -0,0 +1,207 @@
+// Package bluesky cross-posts to Bluesky through the AT Protocol XRPC API of
+// the account's PDS.
+package bluesky
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/retry"
+)
+
+// DefaultPDSURL is the PDS of accounts hosted by Bluesky itself
+const DefaultPDSURL = "https://bsky.social"
+
+// DefaultHTTPTimeout bounds a single XRPC request
+const DefaultHTTPTimeout = 30 * time.Second
+
+// Client calls the XRPC API of a PDS. Calls other than Login need a session.
+type Client struct {
+	pdsURL     string
+	httpClient *http.Client
+	retry      retry.Policy
+	session    *Session
+}
+
+// NewClient creates a client for the PDS at pdsURL
+func NewClient(pdsURL string) *Client {
+	return &Client{
+		pdsURL:     strings.TrimRight(pdsURL, "/"),
+		httpClient: &http.Client{Timeout: DefaultHTTPTimeout},
+		retry:      retry.DefaultPolicy,
+	}
+}
+
+// SetTimeout sets the timeout of a single request; retries get their own
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.httpClient.Timeout = timeout
+}
+
+// SetRetryPolicy replaces the retry policy of the calls that are retried
+func (c *Client) SetRetryPolicy(policy retry.Policy) {
+	c.retry = policy
+}
+
+// XRPCError is an error response from the PDS
+type XRPCError struct {
+	// Op names the client call that failed, e.g. "create record"
+	Op         string `json:"-"`
+	StatusCode int    `json:"-"`
+	Name       string `json:"error"`
+	Message    string `json:"message"`
+}
+
+func (e *XRPCError) Error() string {
+	return fmt.Sprintf("%s failed (status %d, %s): %s", e.Op, e.StatusCode, e.Name, e.Message)
+}
+
+// RateLimited reports whether the PDS refused the call for exceeding its
+// rate limit
+func (e *XRPCError) RateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests
+}
+
+// retryable reports whether a failed request is worth repeating
+func retryable(err error) bool {
+	var xrpcErr *XRPCError
+	if errors.As(err, &xrpcErr) {
+		return retry.TemporaryStatus(xrpcErr.StatusCode)
+	}
+	return retry.IsNetworkError(err)
+}
+
+// Session is an authenticated session of an account
+type Session struct {
+	AccessJWT string `json:"accessJwt"`
+	DID       string `json:"did"`
+	Handle    string `json:"handle"`
+}
+
+// Login starts a session with the account's handle or email and an app
+// password, created under Settings > Privacy and security > App passwords
+func (c *Client) Login(ctx context.Context, identifier, appPassword string) (*Session, error) {
+	body, err := json.Marshal(map[string]string{
+		"identifier": identifier,
+		"password":   appPassword,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var session Session
+	if err := c.doRetried(ctx, "login", "com.atproto.server.createSession", "application/json", body, &session); err != nil {
+		return nil, err
+	}
+	c.session = &session
+	return &session, nil
+}
+
+// Blob is a reference to uploaded data, embedded as is in records
+type Blob struct {
+	Type     string `json:"$type"`
+	Ref      Link   `json:"ref"`
+	MimeType string `json:"mimeType"`
+	Size     int    `json:"size"`
+}
+
+// Link is a content identifier in the AT Protocol's JSON encoding
+type Link struct {
+	Link string `json:"$link"`
+}
+
+// UploadBlob uploads data and returns the reference to embed it. Blobs are
+// addressed by their content, so uploading one again is harmless.
+func (c *Client) UploadBlob(ctx context.Context, data []byte, mimeType string) (*Blob, error) {
+	var resp struct {
+		Blob Blob `json:"blob"`
+	}
+	if err := c.doRetried(ctx, "upload blob", "com.atproto.repo.uploadBlob", mimeType, data, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Blob, nil
+}
+
+// RecordRef identifies a created record
+type RecordRef struct {
+	URI string `json:"uri"`
+	CID string `json:"cid"`
+}
+
+// CreateRecord writes record to collection in the session's repository. It
+// is never retried: a record that was created but whose response was lost
+// would be posted twice.
+func (c *Client) CreateRecord(ctx context.Context, collection string, record interface{}) (*RecordRef, error) {
+	if c.session == nil {
+		return nil, fmt.Errorf("create record: not logged in")
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"repo":       c.session.DID,
+		"collection": collection,
+		"record":     record,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var ref RecordRef
+	if err := c.do(ctx, "create record", "com.atproto.repo.createRecord", "application/json", body, &ref); err != nil {
+		return nil, err
+	}
+	return &ref, nil
+}
+
+// doRetried calls do, retrying server errors and rate limits according to
+// the retry policy. Only idempotent procedures go through it.
+func (c *Client) doRetried(ctx context.Context, op, nsid, contentType string, body []byte, out interface{}) error {
+	return retry.Do(ctx, c.retry, retryable, func() error {
+		return c.do(ctx, op, nsid, contentType, body, out)
+	})
+}
+
+// do calls the XRPC procedure nsid once and decodes the JSON response into
+// out
+func (c *Client) do(ctx context.Context, op, nsid, contentType string, body []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.pdsURL+"/xrpc/"+nsid, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if c.session != nil {
+		req.Header.Set("Authorization", "Bearer "+c.session.AccessJWT)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		xrpcErr := &XRPCError{Op: op, StatusCode: resp.StatusCode}
+		if err := json.Unmarshal(data, xrpcErr); err != nil || xrpcErr.Message == "" {
+			xrpcErr.Message = strings.TrimSpace(string(data))
+		}
+		return xrpcErr
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s: failed to decode response: %w", op, err)
+	}
+	return nil
+}
+
+// IsAuthError reports whether err means the handle, app password or session
+// was rejected
+func IsAuthError(err error) bool {
+	var xrpcErr *XRPCError
+	return errors.As(err, &xrpcErr) && xrpcErr.StatusCode == http.StatusUnauthorized
+}
@@ -0,0 +1,187 @@
+package bluesky_test
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/bluesky"
+	"github.com/nitin737/GoAutoPosts/internal/fakebluesky"
+	"github.com/nitin737/GoAutoPosts/internal/model"
+	"github.com/nitin737/GoAutoPosts/internal/platform"
+	"github.com/nitin737/GoAutoPosts/internal/retry"
+	"github.com/nitin737/GoAutoPosts/internal/template"
+)
+
+// flaky answers a 503 to the first failures[path] requests for each path
+// before passing them on, and counts the requests
+type flaky struct {
+	handler http.Handler
+
+	mu       sync.Mutex
+	failures map[string]int
+	requests map[string]int
+}
+
+func (f *flaky) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	nsid := strings.TrimPrefix(r.URL.Path, "/xrpc/")
+
+	f.mu.Lock()
+	f.requests[nsid]++
+	fail := f.failures[nsid] > 0
+	if fail {
+		f.failures[nsid]--
+	}
+	f.mu.Unlock()
+
+	if fail {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"error":"InternalServerError","message":"Internal Server Error"}`))
+		return
+	}
+	f.handler.ServeHTTP(w, r)
+}
+
+func (f *flaky) count(nsid string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[nsid]
+}
+
+// newTestPublisher starts the fake PDS and returns a publisher for it with
+// fast retries
+func newTestPublisher(t *testing.T, appPassword string) (*bluesky.Publisher, *fakebluesky.Server, *flaky) {
+	t.Helper()
+
+	fake := fakebluesky.NewServer(fakebluesky.Options{AppPassword: "app-password"})
+	f := &flaky{handler: fake, failures: make(map[string]int), requests: make(map[string]int)}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+
+	renderer, err := template.NewRenderer()
+	if err != nil {
+		t.Fatalf("NewRenderer: %v", err)
+	}
+	client := bluesky.NewClient(server.URL)
+	client.SetRetryPolicy(retry.Policy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond})
+	return bluesky.NewPublisher(client, renderer, "gopher.test", appPassword), fake, f
+}
+
+// testPost returns a post of gin with n slides
+func testPost(t *testing.T, n int) platform.Post {
+	t.Helper()
+
+	post := platform.Post{
+		ID: "20260315T090000-gin",
+		Library: &model.Library{
+			Name:        "gin",
+			Description: "Gin is a HTTP web framework written in Go (Golang) — très rapide.",
+			URL:         "https://github.com/gin-gonic/gin",
+		},
+		Hashtags: []string{"golang", "webdev"},
+	}
+
+	dir := t.TempDir()
+	for i := 1; i <= n; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("slide-%d.png", i))
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := png.Encode(f, image.NewRGBA(image.Rect(0, 0, 108, 135))); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+		post.Slides = append(post.Slides, path)
+		post.AltText = append(post.AltText, fmt.Sprintf("Slide %d of gin", i))
+	}
+	return post
+}
+
+func TestPublish(t *testing.T) {
+	publisher, fake, _ := newTestPublisher(t, "app-password")
+
+	remoteID, err := publisher.Publish(context.Background(), testPost(t, 5))
+	if err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	posts := fake.Posts()
+	if len(posts) != 1 {
+		t.Fatalf("created %d posts, want 1", len(posts))
+	}
+	post := posts[0]
+	if string(remoteID) != post.URI || !strings.HasPrefix(post.URI, "at://") {
+		t.Errorf("remote ID = %s, want the post URI %s", remoteID, post.URI)
+	}
+	if !strings.Contains(post.Text, "très rapide") {
+		t.Errorf("post text does not hold the description:\n%s", post.Text)
+	}
+	if got, want := strings.Join(post.AltText, ","), "Slide 1 of gin,Slide 2 of gin,Slide 3 of gin,Slide 4 of gin"; got != want {
+		t.Errorf("alt text = %s, want the first %d slides: %s", got, bluesky.MaxImages, want)
+	}
+	if got, want := strings.Join(post.Facets, " "), "https://github.com/gin-gonic/gin #golang #webdev"; got != want {
+		t.Errorf("facets cover %q, want %q", got, want)
+	}
+}
+
+func TestPublishRejectsWrongAppPassword(t *testing.T) {
+	publisher, fake, f := newTestPublisher(t, "wrong-password")
+
+	_, err := publisher.Publish(context.Background(), testPost(t, 1))
+	if !bluesky.IsAuthError(err) || !strings.Contains(err.Error(), "check the handle and app password") {
+		t.Fatalf("Publish error = %v, want an auth error", err)
+	}
+	if got := f.count("com.atproto.server.createSession"); got != 1 {
+		t.Errorf("login attempts = %d, want 1", got)
+	}
+	if len(fake.Posts()) != 0 {
+		t.Error("posted without a session")
+	}
+}
+
+func TestPublishRetriesOnlyIdempotentCalls(t *testing.T) {
+	t.Run("login and uploads", func(t *testing.T) {
+		publisher, fake, f := newTestPublisher(t, "app-password")
+		f.failures["com.atproto.server.createSession"] = 1
+		f.failures["com.atproto.repo.uploadBlob"] = 2
+
+		if _, err := publisher.Publish(context.Background(), testPost(t, 2)); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+		if got := f.count("com.atproto.server.createSession"); got != 2 {
+			t.Errorf("login attempts = %d, want 2", got)
+		}
+		if got := f.count("com.atproto.repo.uploadBlob"); got != 4 {
+			t.Errorf("upload attempts = %d, want 4", got)
+		}
+		if len(fake.Posts()) != 1 {
+			t.Errorf("created %d posts, want 1", len(fake.Posts()))
+		}
+	})
+
+	t.Run("create record", func(t *testing.T) {
+		publisher, fake, f := newTestPublisher(t, "app-password")
+		f.failures["com.atproto.repo.createRecord"] = 1
+
+		if _, err := publisher.Publish(context.Background(), testPost(t, 1)); err == nil {
+			t.Fatal("Publish succeeded, want the createRecord error")
+		}
+		if got := f.count("com.atproto.repo.createRecord"); got != 1 {
+			t.Errorf("create record attempts = %d, want 1", got)
+		}
+		if len(fake.Posts()) != 0 {
+			t.Errorf("created %d posts, want none", len(fake.Posts()))
+		}
+	})
+}
@@ -0,0 +1,202 @@
+package bluesky
+
+import (
+	"context"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/model"
+	"github.com/nitin737/GoAutoPosts/internal/platform"
+	"github.com/nitin737/GoAutoPosts/internal/template"
+)
+
+// Limits of Bluesky posts
+const (
+	MaxImages   = 4
+	MaxBlobSize = 1000000
+)
+
+// Publisher posts carousels to Bluesky as a post with up to MaxImages
+// slides and its own caption
+type Publisher struct {
+	client      *Client
+	renderer    *template.Renderer
+	identifier  string
+	appPassword string
+}
+
+// NewPublisher creates a publisher that logs in as identifier with an app
+// password and renders captions from the bluesky caption template
+func NewPublisher(client *Client, renderer *template.Renderer, identifier, appPassword string) *Publisher {
+	return &Publisher{
+		client:      client,
+		renderer:    renderer,
+		identifier:  identifier,
+		appPassword: appPassword,
+	}
+}
+
+// Name returns platform.Bluesky
+func (p *Publisher) Name() string {
+	return platform.Bluesky
+}
+
+// post is an app.bsky.feed.post record
+type post struct {
+	Type      string   `json:"$type"`
+	Text      string   `json:"text"`
+	CreatedAt string   `json:"createdAt"`
+	Facets    []Facet  `json:"facets,omitempty"`
+	Embed     *embed   `json:"embed,omitempty"`
+	Langs     []string `json:"langs,omitempty"`
+}
+
+// embed is an app.bsky.embed.images embed
+type embed struct {
+	Type   string       `json:"$type"`
+	Images []embedImage `json:"images"`
+}
+
+type embedImage struct {
+	Alt         string       `json:"alt"`
+	Image       *Blob        `json:"image"`
+	AspectRatio *aspectRatio `json:"aspectRatio,omitempty"`
+}
+
+type aspectRatio struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// Publish logs in, uploads the first MaxImages slides with their alt text
+// and creates the post, with links and hashtags marked up as facets. The
+// remote ID is the post's at:// URI.
+func (p *Publisher) Publish(ctx context.Context, post platform.Post) (platform.RemoteID, error) {
+	text, err := p.caption(post.Library, post.Hashtags)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := p.client.Login(ctx, p.identifier, p.appPassword); err != nil {
+		if IsAuthError(err) {
+			return "", fmt.Errorf("%w (check the handle and app password)", err)
+		}
+		return "", err
+	}
+
+	slides := post.Slides
+	if len(slides) > MaxImages {
+		slides = slides[:MaxImages]
+	}
+
+	var images []embedImage
+	for i, path := range slides {
+		image, err := p.uploadImage(ctx, path)
+		if err != nil {
+			return "", err
+		}
+		if i < len(post.AltText) {
+			image.Alt = post.AltText[i]
+		}
+		images = append(images, *image)
+	}
+
+	record := newPost(text, time.Now())
+	if len(images) > 0 {
+		record.Embed = &embed{Type: "app.bsky.embed.images", Images: images}
+	}
+
+	ref, err := p.client.CreateRecord(ctx, "app.bsky.feed.post", record)
+	if err != nil {
+		return "", err
+	}
+	return platform.RemoteID(ref.URI), nil
+}
+
+func newPost(text string, now time.Time) *post {
+	return &post{
+		Type:      "app.bsky.feed.post",
+		Text:      text,
+		CreatedAt: now.UTC().Format(time.RFC3339Nano),
+		Facets:    Facets(text),
+		Langs:     []string{"en"},
+	}
+}
+
+// caption renders the bluesky template with as many hashtags as fit in
+// MaxGraphemes. If the caption is too long even without hashtags, the
+// description is shortened so the name and link survive.
+func (p *Publisher) caption(lib *model.Library, hashtags []string) (string, error) {
+	for n := len(hashtags); n >= 0; n-- {
+		text, err := p.render(lib, hashtags[:n])
+		if err != nil {
+			return "", err
+		}
+		if Graphemes(text) <= MaxGraphemes {
+			return text, nil
+		}
+	}
+
+	short := *lib
+	words := strings.Fields(lib.Description)
+	for n := len(words) - 1; n >= 0; n-- {
+		short.Description = strings.Join(words[:n], " ") + "…"
+		text, err := p.render(&short, nil)
+		if err != nil {
+			return "", err
+		}
+		if Graphemes(text) <= MaxGraphemes {
+			return text, nil
+		}
+	}
+
+	return "", fmt.Errorf("caption for %s does not fit in %d graphemes", lib.Name, MaxGraphemes)
+}
+
+func (p *Publisher) render(lib *model.Library, hashtags []string) (string, error) {
+	text, err := p.renderer.RenderPlatformCaption(platform.Bluesky, lib, hashtags)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(text), nil
+}
+
+// uploadImage uploads a slide and reads its dimensions so clients can lay
+// it out before it loads
+func (p *Publisher) uploadImage(ctx context.Context, path string) (*embedImage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxBlobSize {
+		return nil, fmt.Errorf("%s is %d bytes, Bluesky accepts images up to %d", filepath.Base(path), len(data), MaxBlobSize)
+	}
+
+	mimeType := mime.TypeByExtension(filepath.Ext(path))
+	if mimeType == "" {
+		mimeType = "image/png"
+	}
+
+	blob, err := p.client.UploadBlob(ctx, data, mimeType)
+	if err != nil {
+		return nil, err
+	}
+
+	img := &embedImage{Image: blob}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if cfg, _, err := image.DecodeConfig(f); err == nil {
+		img.AspectRatio = &aspectRatio{Width: cfg.Width, Height: cfg.Height}
+	}
+	return img, nil
+}
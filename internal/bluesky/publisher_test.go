@@ -0,0 +1,107 @@
+package bluesky
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/nitin737/GoAutoPosts/internal/model"
+	"github.com/nitin737/GoAutoPosts/internal/template"
+)
+
+func newTestPublisher(t *testing.T) *Publisher {
+	t.Helper()
+
+	renderer, err := template.NewRenderer()
+	if err != nil {
+		t.Fatalf("NewRenderer: %v", err)
+	}
+	return NewPublisher(NewClient("http://127.0.0.1:0"), renderer, "gopher.test", "app-password")
+}
+
+func TestCaptionFitsGraphemeLimit(t *testing.T) {
+	hashtags := make([]string, 40)
+	for i := range hashtags {
+		hashtags[i] = fmt.Sprintf("golang%02d", i)
+	}
+
+	tests := []struct {
+		name        string
+		description string
+		hashtags    []string
+		// keepsDescription is false when the description has to be shortened
+		keepsDescription bool
+	}{
+		{
+			name:             "fits",
+			description:      "Fast HTTP web framework.",
+			hashtags:         []string{"golang", "web"},
+			keepsDescription: true,
+		},
+		{
+			name:             "hashtags dropped",
+			description:      "Fast HTTP web framework.",
+			hashtags:         hashtags,
+			keepsDescription: true,
+		},
+		{
+			// 209 graphemes, but 769 runes
+			name:             "counted in graphemes",
+			description:      strings.Repeat("👩‍💻👨‍👩‍👧‍👦 ", 70),
+			keepsDescription: true,
+		},
+		{
+			name:        "description shortened",
+			description: strings.Repeat("Ünïcödé descriptions run long. ", 20),
+			hashtags:    []string{"golang"},
+		},
+	}
+
+	p := newTestPublisher(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lib := &model.Library{Name: "gin", Description: strings.TrimSpace(tt.description), URL: "https://github.com/gin-gonic/gin"}
+
+			text, err := p.caption(lib, tt.hashtags)
+			if err != nil {
+				t.Fatalf("caption: %v", err)
+			}
+			if n := Graphemes(text); n > MaxGraphemes {
+				t.Fatalf("caption is %d graphemes, want at most %d:\n%s", n, MaxGraphemes, text)
+			}
+			if !strings.Contains(text, lib.Name) || !strings.Contains(text, lib.URL) {
+				t.Errorf("caption lost the name or link:\n%s", text)
+			}
+			if got := strings.Contains(text, lib.Description); got != tt.keepsDescription {
+				t.Errorf("caption keeps the whole description = %v, want %v:\n%s", got, tt.keepsDescription, text)
+			}
+			if !tt.keepsDescription && !strings.Contains(text, "…") {
+				t.Errorf("shortened description is not marked with …:\n%s", text)
+			}
+
+			// As many hashtags as fit, in order
+			kept := 0
+			for kept < len(tt.hashtags) && strings.Contains(text, "#"+tt.hashtags[kept]) {
+				kept++
+			}
+			if kept < len(tt.hashtags) && tt.keepsDescription {
+				longer, err := p.render(lib, tt.hashtags[:kept+1])
+				if err != nil {
+					t.Fatal(err)
+				}
+				if Graphemes(longer) <= MaxGraphemes {
+					t.Errorf("caption kept %d hashtags, but %d fit", kept, kept+1)
+				}
+			}
+		})
+	}
+}
+
+func TestCaptionTooLong(t *testing.T) {
+	p := newTestPublisher(t)
+	lib := &model.Library{Name: strings.Repeat("n", MaxGraphemes), Description: "Too long a name.", URL: "https://example.com"}
+
+	if text, err := p.caption(lib, nil); err == nil {
+		t.Errorf("caption = %q, want an error", text)
+	}
+}
@@ -0,0 +1,110 @@
+package bluesky
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxGraphemes is the length limit of a post's text
+const MaxGraphemes = 300
+
+// Graphemes counts the user-perceived characters of s, which is how
+// Bluesky measures post length. It approximates Unicode extended grapheme
+// clusters closely enough for captions: combining marks, variation
+// selectors, skin tone modifiers, tag sequences and ZWJ sequences join the
+// preceding character, and regional indicators pair up into flags.
+func Graphemes(s string) int {
+	n := 0
+	var prev rune
+	joinNext := false
+	flagHalf := false
+	for _, r := range s {
+		extends := false
+		switch {
+		case joinNext:
+			extends = true
+		case r == '\u200d', r == '\n' && prev == '\r':
+			extends = true
+		case unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc):
+			extends = true
+		case r >= 0xfe00 && r <= 0xfe0f, r >= 0x1f3fb && r <= 0x1f3ff, r >= 0xe0020 && r <= 0xe007f:
+			extends = true
+		case r >= 0x1f1e6 && r <= 0x1f1ff:
+			// Two regional indicators make one flag
+			extends = flagHalf
+			flagHalf = !flagHalf
+		}
+		if r < 0x1f1e6 || r > 0x1f1ff {
+			flagHalf = false
+		}
+
+		if !extends {
+			n++
+		}
+		joinNext = r == '\u200d'
+		prev = r
+	}
+	return n
+}
+
+// Facet annotates a byte range of the text as a link or a hashtag
+type Facet struct {
+	Type     string        `json:"$type"`
+	Index    ByteSlice     `json:"index"`
+	Features []interface{} `json:"features"`
+}
+
+// ByteSlice is a range of UTF-8 bytes, end exclusive
+type ByteSlice struct {
+	ByteStart int `json:"byteStart"`
+	ByteEnd   int `json:"byteEnd"`
+}
+
+// LinkFeature makes a facet a link to URI
+type LinkFeature struct {
+	Type string `json:"$type"`
+	URI  string `json:"uri"`
+}
+
+// TagFeature makes a facet a hashtag, given without the #
+type TagFeature struct {
+	Type string `json:"$type"`
+	Tag  string `json:"tag"`
+}
+
+var (
+	linkPattern = regexp.MustCompile(`https?://[^\s]+`)
+	tagPattern  = regexp.MustCompile(`(?:^|\s)(#[\p{L}\p{N}_]+)`)
+)
+
+// Facets finds the links and hashtags in text. Bluesky shows neither as
+// such without a facet.
+func Facets(text string) []Facet {
+	var facets []Facet
+
+	for _, loc := range linkPattern.FindAllStringIndex(text, -1) {
+		link := strings.TrimRight(text[loc[0]:loc[1]], ".,;:!?)'\"")
+		facets = append(facets, Facet{
+			Type:     "app.bsky.richtext.facet",
+			Index:    ByteSlice{ByteStart: loc[0], ByteEnd: loc[0] + len(link)},
+			Features: []interface{}{LinkFeature{Type: "app.bsky.richtext.facet#link", URI: link}},
+		})
+	}
+
+	for _, loc := range tagPattern.FindAllStringSubmatchIndex(text, -1) {
+		tag := text[loc[2]+1 : loc[3]]
+		if strings.IndexFunc(tag, func(r rune) bool { return !unicode.IsDigit(r) }) < 0 || utf8.RuneCountInString(tag) > 64 {
+			// Numbers alone are not hashtags, and tags are capped at 64
+			continue
+		}
+		facets = append(facets, Facet{
+			Type:     "app.bsky.richtext.facet",
+			Index:    ByteSlice{ByteStart: loc[2], ByteEnd: loc[3]},
+			Features: []interface{}{TagFeature{Type: "app.bsky.richtext.facet#tag", Tag: tag}},
+		})
+	}
+
+	return facets
+}
@@ -0,0 +1,113 @@
+package bluesky
+
+import (
+	"testing"
+)
+
+func TestGraphemes(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"empty", "", 0},
+		{"ascii", "hello", 5},
+		{"precomposed accent", "café", 4},
+		{"combining accent", "cafe\u0301", 4},
+		{"cjk", "日本語", 3},
+		{"emoji", "🚀 Go", 4},
+		{"variation selector", "❤️", 1},
+		{"skin tone", "👍🏽", 1},
+		{"zwj sequence", "👩‍💻", 1},
+		{"zwj family", "👨‍👩‍👧‍👦", 1},
+		{"flags", "🇯🇵🇫🇷", 2},
+		{"odd regional indicator", "🇯🇵🇫", 2},
+		{"tag sequence", "🏴\U000e0067\U000e0062\U000e0073\U000e0063\U000e0074\U000e007f", 1},
+		{"crlf", "a\r\nb", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Graphemes(tt.text); got != tt.want {
+				t.Errorf("Graphemes(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFacets(t *testing.T) {
+	type facet struct {
+		start, end int
+		// value is the link URI or the tag without #
+		value string
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want []facet
+	}{
+		{
+			name: "link after multi-byte text",
+			text: "café https://go.dev",
+			want: []facet{{6, 20, "https://go.dev"}},
+		},
+		{
+			name: "tag after emoji",
+			text: "🚀 #golang",
+			want: []facet{{5, 12, "golang"}},
+		},
+		{
+			name: "trailing punctuation left out of link",
+			text: "See https://github.com/gin-gonic/gin.",
+			want: []facet{{4, 36, "https://github.com/gin-gonic/gin"}},
+		},
+		{
+			name: "link in parentheses",
+			text: "email#notatag and (https://a.io)",
+			want: []facet{{19, 31, "https://a.io"}},
+		},
+		{
+			name: "multi-byte tag, numbers alone skipped",
+			text: "日本 #日本語 #123 #go_lang",
+			want: []facet{{7, 17, "日本語"}, {23, 31, "go_lang"}},
+		},
+		{
+			name: "links first, then tags",
+			text: "🔗 https://pkg.go.dev/x\n\n#go #opensource",
+			want: []facet{{5, 25, "https://pkg.go.dev/x"}, {27, 30, "go"}, {31, 42, "opensource"}},
+		},
+		{
+			name: "nothing to mark up",
+			text: "plain text, no links",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			facets := Facets(tt.text)
+			if len(facets) != len(tt.want) {
+				t.Fatalf("Facets(%q) = %+v, want %d facets", tt.text, facets, len(tt.want))
+			}
+
+			for i, f := range facets {
+				want := tt.want[i]
+				if f.Index.ByteStart != want.start || f.Index.ByteEnd != want.end {
+					t.Errorf("facet %d covers bytes %d-%d (%q), want %d-%d", i, f.Index.ByteStart, f.Index.ByteEnd,
+						tt.text[f.Index.ByteStart:f.Index.ByteEnd], want.start, want.end)
+				}
+
+				var value string
+				switch feature := f.Features[0].(type) {
+				case LinkFeature:
+					value = feature.URI
+				case TagFeature:
+					value = feature.Tag
+				}
+				if value != want.value {
+					t.Errorf("facet %d is %q, want %q", i, value, want.value)
+				}
+			}
+		})
+	}
+}
@@ -3,8 +3,11 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
+	"github.com/nitin737/GoAutoPosts/internal/platform"
 )
 
 // Config holds all application configuration
@@ -13,20 +16,127 @@ type Config struct {
 	InstagramAccessToken string
 	InstagramAccountID   string
 	GraphAPIURL          string
+	// GraphMaxRetries is how often a transient or rate limited call is retried,
+	// on Mastodon and Bluesky too
+	GraphMaxRetries int
+	// ContainerPollTimeout is how long to wait, in seconds, for Instagram to
+	// process a media container
+	ContainerPollTimeout int
+	// GraphConcurrency is how many carousel items are created at once
+	GraphConcurrency int
+	// PublishTimeout bounds publishing a post to every platform, in seconds
+	PublishTimeout int
+	// GraphTimeout bounds a single Graph API, Mastodon or Bluesky request, in
+	// seconds
+	GraphTimeout int
+	// RunTimeout bounds a whole command, in seconds. For all three timeouts 0
+	// means no limit.
+	RunTimeout int
+
+	// Platforms lists where posts go, e.g. instagram,mastodon. Instagram,
+	// when listed, is always published first.
+	Platforms string
+
+	// Mastodon cross-posting: the instance URL, a user access token with the
+	// write:media and write:statuses scopes, and the status visibility
+	MastodonURL         string
+	MastodonAccessToken string
+	MastodonVisibility  string
+
+	// Bluesky cross-posting: the PDS to log in to, the account handle and an
+	// app password
+	BlueskyPDSURL      string
+	BlueskyHandle      string
+	BlueskyAppPassword string
+
+	// Token management: the app credentials needed to exchange and refresh
+	// tokens, the login flavour (facebook or instagram, guessed from
+	// GraphAPIURL when empty), where the current token is kept (json or
+	// sqlite, the latter in SQLitePath), and how many days before expiry to
+	// refresh and to warn
+	FacebookAppID     string
+	FacebookAppSecret string
+	TokenAPI          string
+	TokenStoreBackend string
+	TokenPath         string
+	TokenRefreshDays  int
+	TokenWarnDays     int
 
 	// Data paths
 	LibrariesPath string
 	PostedPath    string
+	QueuePath     string
+
+	// Storage backends for the posted history and the library catalog: json or sqlite
+	StoreBackend   string
+	CatalogBackend string
+	SQLitePath     string
+
+	// Selection
+	SelectionStrategy  string
+	CooldownDays       int
+	CategoryGapDays    int
+	AuthorGapDays      int
+	CategoryMaxPosts   int
+	CategoryWindowDays int
+	SelectionSeed      int
+	SeedFromDate       bool
 
 	// Image generation settings
 	ImageBasePath string
 
+	// PostFormat is what a publish posts: carousel or reel
+	PostFormat string
+	// Reel rendering: seconds per slide, the xfade transition (or none), an
+	// optional audio track and the ffmpeg binary
+	ReelSlideSeconds int
+	ReelTransition   string
+	ReelAudioPath    string
+	FFmpegPath       string
+	// ReelPollTimeout is how long to wait, in seconds, for Instagram to
+	// process a reel, which takes longer than images
+	ReelPollTimeout int
+	// StoryEnabled publishes a Story teaser after each post
+	StoryEnabled bool
+
 	// Environment
 	Environment string
 
-	// Local Server
-	PublicURL  string
-	ServerPort string
+	// MediaHost is where media is published for Instagram to download:
+	// local, s3 or pages
+	MediaHost string
+
+	// Local Server: serves uploaded media on ServerPort, reachable at
+	// PublicURL, through signed URLs valid for ServerURLExpiry seconds. On
+	// shutdown it waits up to ServerShutdownTimeout seconds for Instagram to
+	// fetch every file.
+	PublicURL             string
+	ServerPort            string
+	ServerURLExpiry       int
+	ServerShutdownTimeout int
+
+	// S3-compatible bucket; without S3PublicURL objects are shared through
+	// presigned URLs valid for S3URLExpiry seconds
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	S3Prefix          string
+	S3PublicURL       string
+	S3URLExpiry       int
+
+	// Static site checkout served at PagesBaseURL; PagesPush commits and
+	// pushes every change and PagesWaitTimeout is how long, in seconds, to
+	// wait for a deploy
+	PagesDir         string
+	PagesBaseURL     string
+	PagesPush        bool
+	PagesWaitTimeout int
+
+	// Dry run: render everything into PreviewDir without publishing
+	DryRun     bool
+	PreviewDir string
 }
 
 // Load reads configuration from environment variables
@@ -35,26 +145,116 @@ func Load() (*Config, error) {
 	_ = godotenv.Load()
 
 	cfg := &Config{
-		InstagramAccessToken: os.Getenv("INSTAGRAM_ACCESS_TOKEN"),
-		InstagramAccountID:   os.Getenv("INSTAGRAM_ACCOUNT_ID"),
-		GraphAPIURL:          getEnvOrDefault("GRAPH_API_URL", "https://graph.facebook.com/v18.0"),
-		LibrariesPath:        getEnvOrDefault("LIBRARIES_PATH", "data/libraries.json"),
-		PostedPath:           getEnvOrDefault("POSTED_PATH", "data/posted.json"),
-		ImageBasePath:        getEnvOrDefault("IMAGE_BASE_PATH", "internal/image/assets/base.png"),
-		Environment:          getEnvOrDefault("ENVIRONMENT", "development"),
-		PublicURL:            os.Getenv("PUBLIC_URL"),
-		ServerPort:           getEnvOrDefault("SERVER_PORT", "8080"),
+		InstagramAccessToken:  os.Getenv("INSTAGRAM_ACCESS_TOKEN"),
+		InstagramAccountID:    os.Getenv("INSTAGRAM_ACCOUNT_ID"),
+		GraphAPIURL:           getEnvOrDefault("GRAPH_API_URL", "https://graph.facebook.com/v18.0"),
+		GraphMaxRetries:       getEnvAsInt("GRAPH_API_MAX_RETRIES", 3),
+		ContainerPollTimeout:  getEnvAsInt("CONTAINER_POLL_TIMEOUT", 120),
+		GraphConcurrency:      getEnvAsInt("GRAPH_API_CONCURRENCY", 4),
+		PublishTimeout:        getEnvAsInt("PUBLISH_TIMEOUT", 600),
+		GraphTimeout:          getEnvAsInt("GRAPH_API_TIMEOUT", 30),
+		RunTimeout:            getEnvAsInt("RUN_TIMEOUT", 900),
+		Platforms:             getEnvOrDefault("PLATFORMS", platform.Instagram),
+		MastodonURL:           os.Getenv("MASTODON_URL"),
+		MastodonAccessToken:   os.Getenv("MASTODON_ACCESS_TOKEN"),
+		MastodonVisibility:    getEnvOrDefault("MASTODON_VISIBILITY", "public"),
+		BlueskyPDSURL:         getEnvOrDefault("BLUESKY_PDS_URL", "https://bsky.social"),
+		BlueskyHandle:         os.Getenv("BLUESKY_HANDLE"),
+		BlueskyAppPassword:    os.Getenv("BLUESKY_APP_PASSWORD"),
+		FacebookAppID:         os.Getenv("FACEBOOK_APP_ID"),
+		FacebookAppSecret:     os.Getenv("FACEBOOK_APP_SECRET"),
+		TokenAPI:              os.Getenv("TOKEN_API"),
+		TokenStoreBackend:     getEnvOrDefault("TOKEN_STORE_BACKEND", "json"),
+		TokenPath:             getEnvOrDefault("TOKEN_PATH", "data/token.json"),
+		TokenRefreshDays:      getEnvAsInt("TOKEN_REFRESH_DAYS", 10),
+		TokenWarnDays:         getEnvAsInt("TOKEN_WARN_DAYS", 7),
+		LibrariesPath:         getEnvOrDefault("LIBRARIES_PATH", "data/libraries.json"),
+		PostedPath:            getEnvOrDefault("POSTED_PATH", "data/posted.json"),
+		QueuePath:             getEnvOrDefault("QUEUE_PATH", "data/queue.json"),
+		StoreBackend:          getEnvOrDefault("STORE_BACKEND", "json"),
+		CatalogBackend:        getEnvOrDefault("CATALOG_BACKEND", "json"),
+		SQLitePath:            getEnvOrDefault("SQLITE_PATH", "data/posted.db"),
+		SelectionStrategy:     getEnvOrDefault("SELECTION_STRATEGY", "random"),
+		CooldownDays:          getEnvAsInt("COOLDOWN_DAYS", 30),
+		CategoryGapDays:       getEnvAsInt("CATEGORY_GAP_DAYS", 0),
+		AuthorGapDays:         getEnvAsInt("AUTHOR_GAP_DAYS", 0),
+		CategoryMaxPosts:      getEnvAsInt("CATEGORY_MAX_POSTS", 0),
+		CategoryWindowDays:    getEnvAsInt("CATEGORY_WINDOW_DAYS", 0),
+		SelectionSeed:         getEnvAsInt("SELECTION_SEED", 0),
+		SeedFromDate:          getEnvAsBool("SELECTION_SEED_FROM_DATE", false),
+		ImageBasePath:         getEnvOrDefault("IMAGE_BASE_PATH", "internal/image/assets/base.png"),
+		PostFormat:            getEnvOrDefault("POST_FORMAT", "carousel"),
+		ReelSlideSeconds:      getEnvAsInt("REEL_SLIDE_SECONDS", 3),
+		ReelTransition:        getEnvOrDefault("REEL_TRANSITION", "fade"),
+		ReelAudioPath:         os.Getenv("REEL_AUDIO_PATH"),
+		FFmpegPath:            getEnvOrDefault("FFMPEG_PATH", "ffmpeg"),
+		ReelPollTimeout:       getEnvAsInt("REEL_POLL_TIMEOUT", 300),
+		StoryEnabled:          getEnvAsBool("STORY_ENABLED", false),
+		Environment:           getEnvOrDefault("ENVIRONMENT", "development"),
+		MediaHost:             getEnvOrDefault("MEDIA_HOST", "local"),
+		PublicURL:             os.Getenv("PUBLIC_URL"),
+		ServerPort:            getEnvOrDefault("SERVER_PORT", "8080"),
+		ServerURLExpiry:       getEnvAsInt("SERVER_URL_EXPIRY", 3600),
+		ServerShutdownTimeout: getEnvAsInt("SERVER_SHUTDOWN_TIMEOUT", 60),
+		S3Endpoint:            os.Getenv("S3_ENDPOINT"),
+		S3Region:              getEnvOrDefault("S3_REGION", "us-east-1"),
+		S3Bucket:              os.Getenv("S3_BUCKET"),
+		S3AccessKeyID:         os.Getenv("S3_ACCESS_KEY_ID"),
+		S3SecretAccessKey:     os.Getenv("S3_SECRET_ACCESS_KEY"),
+		S3Prefix:              os.Getenv("S3_PREFIX"),
+		S3PublicURL:           os.Getenv("S3_PUBLIC_URL"),
+		S3URLExpiry:           getEnvAsInt("S3_URL_EXPIRY", 3600),
+		PagesDir:              os.Getenv("PAGES_DIR"),
+		PagesBaseURL:          os.Getenv("PAGES_BASE_URL"),
+		PagesPush:             getEnvAsBool("PAGES_PUSH", false),
+		PagesWaitTimeout:      getEnvAsInt("PAGES_WAIT_TIMEOUT", 300),
+		DryRun:                getEnvAsBool("DRY_RUN", false),
+		PreviewDir:            getEnvOrDefault("PREVIEW_DIR", "preview"),
 	}
 
-	// Validate required fields
-	if cfg.InstagramAccessToken == "" {
-		return nil, fmt.Errorf("INSTAGRAM_ACCESS_TOKEN is required")
+	return cfg, nil
+}
+
+// Validate checks the fields required to publish to the configured
+// platforms. The Instagram access token may also come from the token store,
+// so it is checked when the token is loaded.
+func (c *Config) Validate() error {
+	platforms, err := platform.ParseNames(c.Platforms)
+	if err != nil {
+		return fmt.Errorf("PLATFORMS: %w", err)
 	}
-	if cfg.InstagramAccountID == "" {
-		return nil, fmt.Errorf("INSTAGRAM_ACCOUNT_ID is required")
+
+	for _, name := range platforms {
+		switch name {
+		case platform.Instagram:
+			if c.InstagramAccountID == "" {
+				return fmt.Errorf("INSTAGRAM_ACCOUNT_ID is required")
+			}
+		case platform.Mastodon:
+			if c.MastodonURL == "" || c.MastodonAccessToken == "" {
+				return fmt.Errorf("MASTODON_URL and MASTODON_ACCESS_TOKEN are required to post to Mastodon")
+			}
+		case platform.Bluesky:
+			if c.BlueskyHandle == "" || c.BlueskyAppPassword == "" {
+				return fmt.Errorf("BLUESKY_HANDLE and BLUESKY_APP_PASSWORD are required to post to Bluesky")
+			}
+		}
 	}
 
-	return cfg, nil
+	return nil
+}
+
+// InstagramLogin reports whether the access token comes from Instagram Login
+// (graph.instagram.com) rather than Facebook Login. TOKEN_API overrides the
+// guess made from GRAPH_API_URL.
+func (c *Config) InstagramLogin() bool {
+	switch c.TokenAPI {
+	case "instagram":
+		return true
+	case "facebook":
+		return false
+	}
+	return strings.Contains(c.GraphAPIURL, "graph.instagram.com")
 }
 
 func getEnvOrDefault(key, defaultValue string) string {
@@ -71,3 +271,15 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 	}
 	return valStr == "true" || valStr == "1"
 }
+
+func getEnvAsInt(key string, defaultValue int) int {
+	valStr := os.Getenv(key)
+	if valStr == "" {
+		return defaultValue
+	}
+	val, err := strconv.Atoi(valStr)
+	if err != nil {
+		return defaultValue
+	}
+	return val
+}
@@ -0,0 +1,268 @@
+// Package fakebluesky is an in-memory stand-in for the XRPC endpoints the
+// Bluesky publisher uses, for cross-posting locally without an account.
+package fakebluesky
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/nitin737/GoAutoPosts/internal/bluesky"
+)
+
+// Options controls how the fake PDS behaves
+type Options struct {
+	// AppPassword, when set, is the only password createSession accepts
+	AppPassword string
+}
+
+// Post is a created app.bsky.feed.post as the fake stored it
+type Post struct {
+	URI  string
+	Text string
+	// AltText is the alt text of each embedded image, in order
+	AltText []string
+	// Facets is the text each facet covers, e.g. a URL or #tag
+	Facets []string
+}
+
+// Server implements createSession, uploadBlob and createRecord
+type Server struct {
+	opts Options
+
+	mu     sync.Mutex
+	nextID int
+	blobs  map[string]int // CID -> size
+	posts  []Post
+}
+
+// NewServer creates a fake PDS
+func NewServer(opts Options) *Server {
+	return &Server{
+		opts:   opts,
+		nextID: 100,
+		blobs:  make(map[string]int),
+	}
+}
+
+// Posts returns the posts created so far, in order
+func (s *Server) Posts() []Post {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Post(nil), s.posts...)
+}
+
+// ServeHTTP routes the com.atproto.server.createSession,
+// com.atproto.repo.uploadBlob and com.atproto.repo.createRecord procedures
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "InvalidRequest", "Method not allowed")
+		return
+	}
+
+	switch r.URL.Path {
+	case "/xrpc/com.atproto.server.createSession":
+		s.createSession(w, r)
+	case "/xrpc/com.atproto.repo.uploadBlob":
+		if s.authorized(w, r) {
+			s.uploadBlob(w, r)
+		}
+	case "/xrpc/com.atproto.repo.createRecord":
+		if s.authorized(w, r) {
+			s.createRecord(w, r)
+		}
+	default:
+		writeError(w, http.StatusNotImplemented, "MethodNotImplemented", "Method Not Implemented")
+	}
+}
+
+func (s *Server) authorized(w http.ResponseWriter, r *http.Request) bool {
+	if r.Header.Get("Authorization") != "Bearer "+accessJWT {
+		writeError(w, http.StatusUnauthorized, "AuthenticationRequired", "Authentication Required")
+		return false
+	}
+	return true
+}
+
+const (
+	accessJWT = "fake-access-jwt"
+	did       = "did:plc:fakebluesky"
+)
+
+func (s *Server) createSession(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Identifier string `json:"identifier"`
+		Password   string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Identifier == "" || req.Password == "" {
+		writeError(w, http.StatusBadRequest, "InvalidRequest", "identifier and password are required")
+		return
+	}
+	if s.opts.AppPassword != "" && req.Password != s.opts.AppPassword {
+		writeError(w, http.StatusUnauthorized, "AuthenticationRequired", "Invalid identifier or password")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"accessJwt":  accessJWT,
+		"refreshJwt": "fake-refresh-jwt",
+		"did":        did,
+		"handle":     req.Identifier,
+	})
+}
+
+func (s *Server) uploadBlob(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(io.LimitReader(r.Body, bluesky.MaxBlobSize+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidRequest", err.Error())
+		return
+	}
+	if len(data) > bluesky.MaxBlobSize {
+		writeError(w, http.StatusBadRequest, "BlobTooLarge", "This file is too large")
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	cid := "bafkrei" + hex.EncodeToString(sum[:16])
+
+	s.mu.Lock()
+	s.blobs[cid] = len(data)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"blob": map[string]interface{}{
+			"$type":    "blob",
+			"ref":      map[string]string{"$link": cid},
+			"mimeType": r.Header.Get("Content-Type"),
+			"size":     len(data),
+		},
+	})
+}
+
+type record struct {
+	Repo       string `json:"repo"`
+	Collection string `json:"collection"`
+	Record     struct {
+		Type   string `json:"$type"`
+		Text   string `json:"text"`
+		Facets []struct {
+			Index    bluesky.ByteSlice `json:"index"`
+			Features []struct {
+				Type string `json:"$type"`
+				URI  string `json:"uri"`
+				Tag  string `json:"tag"`
+			} `json:"features"`
+		} `json:"facets"`
+		Embed *struct {
+			Type   string `json:"$type"`
+			Images []struct {
+				Alt   string       `json:"alt"`
+				Image bluesky.Blob `json:"image"`
+			} `json:"images"`
+		} `json:"embed"`
+	} `json:"record"`
+}
+
+func (s *Server) createRecord(w http.ResponseWriter, r *http.Request) {
+	var req record
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidRequest", err.Error())
+		return
+	}
+	if req.Repo != did {
+		writeError(w, http.StatusBadRequest, "InvalidRequest", "repo does not match the session")
+		return
+	}
+	if req.Collection != "app.bsky.feed.post" || req.Record.Type != req.Collection {
+		writeError(w, http.StatusBadRequest, "InvalidRequest", "unsupported collection "+req.Collection)
+		return
+	}
+
+	post, err := s.validate(&req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidRecord", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	rkey := fmt.Sprintf("3fake%d", s.nextID)
+	post.URI = "at://" + did + "/app.bsky.feed.post/" + rkey
+	s.posts = append(s.posts, *post)
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"uri": post.URI,
+		"cid": "bafyrei" + rkey,
+	})
+}
+
+// validate applies the lexicon limits the real PDS enforces: 300 graphemes,
+// four images of known blobs, and facets whose byte ranges cover the link or
+// tag they describe
+func (s *Server) validate(req *record) (*Post, error) {
+	text := req.Record.Text
+	if n := bluesky.Graphemes(text); n > bluesky.MaxGraphemes {
+		return nil, fmt.Errorf("Invalid app.bsky.feed.post record: record/text must not be longer than %d graphemes (%d)", bluesky.MaxGraphemes, n)
+	}
+
+	post := &Post{Text: text}
+	for i, facet := range req.Record.Facets {
+		start, end := facet.Index.ByteStart, facet.Index.ByteEnd
+		if start < 0 || end > len(text) || start >= end {
+			return nil, fmt.Errorf("facet %d has an invalid byte range %d-%d", i, start, end)
+		}
+		covered := text[start:end]
+		for _, feature := range facet.Features {
+			switch feature.Type {
+			case "app.bsky.richtext.facet#link":
+				if covered != feature.URI {
+					return nil, fmt.Errorf("facet %d covers %q, not link %q", i, covered, feature.URI)
+				}
+			case "app.bsky.richtext.facet#tag":
+				if covered != "#"+feature.Tag {
+					return nil, fmt.Errorf("facet %d covers %q, not tag %q", i, covered, feature.Tag)
+				}
+			default:
+				return nil, fmt.Errorf("facet %d has unknown feature %s", i, feature.Type)
+			}
+		}
+		post.Facets = append(post.Facets, covered)
+	}
+
+	if embed := req.Record.Embed; embed != nil {
+		if embed.Type != "app.bsky.embed.images" {
+			return nil, fmt.Errorf("unsupported embed %s", embed.Type)
+		}
+		if len(embed.Images) > 4 {
+			return nil, fmt.Errorf("Invalid app.bsky.feed.post record: embed/images must not have more than 4 elements")
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for _, image := range embed.Images {
+			if _, ok := s.blobs[image.Image.Ref.Link]; !ok {
+				return nil, fmt.Errorf("Could not find blob: %s", image.Image.Ref.Link)
+			}
+			post.AltText = append(post.AltText, image.Alt)
+		}
+	}
+
+	return post, nil
+}
+
+func writeError(w http.ResponseWriter, statusCode int, name, message string) {
+	writeJSON(w, statusCode, map[string]string{"error": name, "message": message})
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(v)
+}
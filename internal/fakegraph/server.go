@@ -0,0 +1,456 @@
+// Package fakegraph is an in-memory stand-in for the parts of the Instagram
+// Graph API the publisher uses, for running the full publish flow locally
+// without touching a real account.
+package fakegraph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options controls how the fake API behaves
+type Options struct {
+	// ProcessingPolls is how many status polls a container reports
+	// IN_PROGRESS before it is FINISHED
+	ProcessingPolls int
+	// FailImageURL makes every container whose image_url or video_url
+	// contains it end in ERROR
+	FailImageURL string
+	// ExpireImageURL makes every container whose image_url contains it end
+	// in EXPIRED
+	ExpireImageURL string
+	// TransientFailures is how many POSTs answer a transient 500 before any
+	// succeeds; they change nothing
+	TransientFailures int
+	// LostPublishes is how many media_publish calls publish the container
+	// but answer a 500, as if the response was lost
+	LostPublishes int
+	// TokenLifetime is how long exchanged tokens last; zero means 60 days
+	TokenLifetime time.Duration
+	// MissingScope is left out of the permissions of every token
+	MissingScope string
+	// ExpiredToken is rejected by every call and exchange, and reported
+	// invalid by debug_token, as a token past its expiry is
+	ExpiredToken string
+	// FetchMedia downloads every image_url and video_url when a container is
+	// created, as Instagram does; a failed download ends the container in
+	// ERROR
+	FetchMedia bool
+}
+
+type container struct {
+	id         string
+	mediaType  string
+	mediaURL   string
+	children   []string
+	caption    string
+	pollsLeft  int
+	statusCode string
+	status     string
+}
+
+// Server implements the media, media_publish and container status endpoints
+type Server struct {
+	opts Options
+
+	mu         sync.Mutex
+	nextID     int
+	containers map[string]*container
+	published  []string
+	media      []media // published posts, oldest first
+}
+
+// media is a post on the account
+type media struct {
+	id        string
+	caption   string
+	timestamp time.Time
+}
+
+// timestampFormat is how the Graph API formats the timestamp field
+const timestampFormat = "2006-01-02T15:04:05-0700"
+
+// NewServer creates a fake Graph API
+func NewServer(opts Options) *Server {
+	return &Server{
+		opts:       opts,
+		nextID:     1000,
+		containers: make(map[string]*container),
+	}
+}
+
+// Published returns the IDs of the containers published so far, in order
+func (s *Server) Published() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.published...)
+}
+
+// Container returns the media URL and children of a container
+func (s *Server) Container(id string) (mediaURL string, children []string, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.containers[id]
+	if !ok {
+		return "", nil, false
+	}
+	return c.mediaURL, append([]string(nil), c.children...), true
+}
+
+// MediaType returns the media_type a container was created with, empty for
+// a plain image
+func (s *Server) MediaType(id string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c, ok := s.containers[id]; ok {
+		return c.mediaType
+	}
+	return ""
+}
+
+// PostElsewhere adds a post published outside the publisher at the given
+// time, as from the Instagram app, and returns its ID
+func (s *Server) PostElsewhere(caption string, at time.Time) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	id := fmt.Sprintf("%d", s.nextID)
+	s.media = append(s.media, media{id: id, caption: caption, timestamp: at})
+	return id
+}
+
+// ServeHTTP routes /{account}/media (create and list), /{account}/media_publish,
+// /{container} and the token endpoints
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// The token exchange authenticates with the app credentials instead
+	if r.FormValue("access_token") == "" && r.URL.Path != "/oauth/access_token" {
+		writeError(w, http.StatusBadRequest, 190, "OAuthException", "Invalid OAuth access token - Cannot parse access token")
+		return
+	}
+
+	if s.expired(r.FormValue("access_token")) || s.expired(r.FormValue("fb_exchange_token")) {
+		writeError(w, http.StatusBadRequest, 190, "OAuthException", "Error validating access token: Session has expired")
+		return
+	}
+
+	if r.Method == http.MethodPost && s.failTransiently() {
+		writeTransientError(w)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.Method == http.MethodPost && len(parts) >= 2 && parts[len(parts)-1] == "media":
+		s.createContainer(w, r)
+	case r.Method == http.MethodPost && len(parts) >= 2 && parts[len(parts)-1] == "media_publish":
+		s.publish(w, r)
+	case r.Method == http.MethodGet && r.URL.Path == "/oauth/access_token":
+		s.exchangeToken(w, r)
+	case r.Method == http.MethodGet && r.URL.Path == "/debug_token":
+		s.debugToken(w, r)
+	case r.Method == http.MethodGet && r.URL.Path == "/me/permissions":
+		s.permissions(w)
+	case r.Method == http.MethodGet && r.URL.Path == "/me":
+		writeJSON(w, http.StatusOK, map[string]string{"id": "fakegraph"})
+	case r.Method == http.MethodGet && r.URL.Path == "/access_token":
+		s.exchangeInstagramToken(w, r)
+	case r.Method == http.MethodGet && r.URL.Path == "/refresh_access_token":
+		s.refreshInstagramToken(w, r)
+	case r.Method == http.MethodGet && len(parts) >= 2 && parts[len(parts)-1] == "media":
+		s.listMedia(w)
+	case r.Method == http.MethodGet && len(parts) >= 1:
+		s.containerStatus(w, parts[len(parts)-1])
+	default:
+		writeError(w, http.StatusBadRequest, 100, "GraphMethodException", fmt.Sprintf("Unsupported %s request", r.Method))
+	}
+}
+
+func (s *Server) createContainer(w http.ResponseWriter, r *http.Request) {
+	// Download outside the lock so containers are still created in parallel
+	var fetchErr error
+	if mediaURL := r.FormValue("image_url") + r.FormValue("video_url"); s.opts.FetchMedia && mediaURL != "" {
+		fetchErr = fetch(mediaURL)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := &container{
+		mediaType:  r.FormValue("media_type"),
+		mediaURL:   r.FormValue("image_url"),
+		caption:    r.FormValue("caption"),
+		pollsLeft:  s.opts.ProcessingPolls,
+		statusCode: "IN_PROGRESS",
+		status:     "In Progress",
+	}
+
+	switch r.FormValue("media_type") {
+	case "CAROUSEL":
+		c.children = strings.Split(r.FormValue("children"), ",")
+		for _, id := range c.children {
+			child, ok := s.containers[id]
+			if !ok || child.statusCode != "FINISHED" {
+				writeError(w, http.StatusBadRequest, 9007, "OAuthException", fmt.Sprintf("Media ID %s is not available", id))
+				return
+			}
+		}
+	case "STORIES":
+		if c.mediaURL == "" {
+			c.mediaURL = r.FormValue("video_url")
+		}
+		if c.mediaURL == "" {
+			writeError(w, http.StatusBadRequest, 100, "OAuthException", "The parameter image_url or video_url is required")
+			return
+		}
+	case "REELS":
+		c.mediaURL = r.FormValue("video_url")
+		if c.mediaURL == "" {
+			writeError(w, http.StatusBadRequest, 100, "OAuthException", "The parameter video_url is required")
+			return
+		}
+	default:
+		if c.mediaURL == "" {
+			writeError(w, http.StatusBadRequest, 100, "OAuthException", "The parameter image_url is required")
+			return
+		}
+	}
+
+	if fetchErr != nil {
+		c.statusCode = "ERROR"
+		c.status = "Error: Media download has failed. " + fetchErr.Error()
+	}
+
+	s.nextID++
+	c.id = fmt.Sprintf("%d", s.nextID)
+	s.containers[c.id] = c
+
+	writeJSON(w, http.StatusOK, map[string]string{"id": c.id})
+}
+
+// fetch downloads rawURL and discards it
+func fetch(rawURL string) error {
+	client := http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET returned %s", resp.Status)
+	}
+	return nil
+}
+
+func (s *Server) containerStatus(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.containers[id]
+	if !ok {
+		writeError(w, http.StatusBadRequest, 100, "GraphMethodException", fmt.Sprintf("Unsupported get request. Object with ID '%s' does not exist", id))
+		return
+	}
+
+	if c.statusCode == "IN_PROGRESS" {
+		if c.pollsLeft > 0 {
+			c.pollsLeft--
+		} else if s.opts.FailImageURL != "" && strings.Contains(c.mediaURL, s.opts.FailImageURL) {
+			c.statusCode = "ERROR"
+			c.status = "Error: Media download has failed. The media URI doesn't meet our requirements."
+		} else if s.opts.ExpireImageURL != "" && strings.Contains(c.mediaURL, s.opts.ExpireImageURL) {
+			c.statusCode = "EXPIRED"
+			c.status = "Expired: The container was not published within 24 hours."
+		} else {
+			c.statusCode = "FINISHED"
+			c.status = "Finished: Media has been uploaded and it is ready to be published."
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"id":          c.id,
+		"status_code": c.statusCode,
+		"status":      c.status,
+	})
+}
+
+func (s *Server) publish(w http.ResponseWriter, r *http.Request) {
+	id := r.FormValue("creation_id")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.containers[id]
+	if !ok || c.statusCode != "FINISHED" {
+		writeError(w, http.StatusBadRequest, 9007, "OAuthException", "Media ID is not available")
+		return
+	}
+
+	c.statusCode = "PUBLISHED"
+	c.status = "Published"
+	s.published = append(s.published, id)
+	s.nextID++
+	mediaID := fmt.Sprintf("%d", s.nextID)
+	s.media = append(s.media, media{id: mediaID, caption: c.caption, timestamp: time.Now()})
+
+	if s.opts.LostPublishes > 0 {
+		s.opts.LostPublishes--
+		writeTransientError(w)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"id": mediaID})
+}
+
+// failTransiently uses up one of the TransientFailures
+func (s *Server) failTransiently() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.opts.TransientFailures <= 0 {
+		return false
+	}
+	s.opts.TransientFailures--
+	return true
+}
+
+// listMedia returns the published posts, newest first
+func (s *Server) listMedia(w http.ResponseWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := make([]map[string]string, 0, len(s.media))
+	for i := len(s.media) - 1; i >= 0; i-- {
+		m := s.media[i]
+		data = append(data, map[string]string{
+			"id":        m.id,
+			"caption":   m.caption,
+			"timestamp": m.timestamp.UTC().Format(timestampFormat),
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+}
+
+func (s *Server) exchangeToken(w http.ResponseWriter, r *http.Request) {
+	if r.FormValue("client_id") == "" || r.FormValue("client_secret") == "" || r.FormValue("fb_exchange_token") == "" {
+		writeError(w, http.StatusBadRequest, 101, "OAuthException", "Missing client_id, client_secret or fb_exchange_token")
+		return
+	}
+	s.issueToken(w)
+}
+
+// exchangeInstagramToken implements the Instagram Login exchange of a
+// short-lived token
+func (s *Server) exchangeInstagramToken(w http.ResponseWriter, r *http.Request) {
+	if r.FormValue("grant_type") != "ig_exchange_token" || r.FormValue("client_secret") == "" {
+		writeError(w, http.StatusBadRequest, 101, "OAuthException", "Missing grant_type or client_secret")
+		return
+	}
+	s.issueToken(w)
+}
+
+// refreshInstagramToken extends long-lived Instagram Login tokens; tokens
+// starting with "short" count as short-lived and are rejected like the real API
+func (s *Server) refreshInstagramToken(w http.ResponseWriter, r *http.Request) {
+	if r.FormValue("grant_type") != "ig_refresh_token" || strings.HasPrefix(r.FormValue("access_token"), "short") {
+		writeError(w, http.StatusBadRequest, 190, "OAuthException", "Short-lived tokens cannot be refreshed")
+		return
+	}
+	s.issueToken(w)
+}
+
+func (s *Server) issueToken(w http.ResponseWriter) {
+	s.mu.Lock()
+	s.nextID++
+	token := fmt.Sprintf("fake-long-lived-%d", s.nextID)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": token,
+		"token_type":   "bearer",
+		"expires_in":   int64(s.tokenLifetime().Seconds()),
+	})
+}
+
+func (s *Server) debugToken(w http.ResponseWriter, r *http.Request) {
+	if s.expired(r.FormValue("input_token")) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{
+				"is_valid": false,
+				"error":    map[string]interface{}{"code": 190, "message": "Session has expired"},
+			},
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"app_id":     strings.SplitN(r.FormValue("access_token"), "|", 2)[0],
+			"user_id":    "fakegraph",
+			"is_valid":   r.FormValue("input_token") != "",
+			"expires_at": time.Now().Add(s.tokenLifetime()).Unix(),
+			"scopes":     s.scopes(),
+		},
+	})
+}
+
+func (s *Server) permissions(w http.ResponseWriter) {
+	var data []map[string]string
+	for _, scope := range s.scopes() {
+		data = append(data, map[string]string{"permission": scope, "status": "granted"})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+}
+
+func (s *Server) expired(token string) bool {
+	return token != "" && token == s.opts.ExpiredToken
+}
+
+func (s *Server) tokenLifetime() time.Duration {
+	if s.opts.TokenLifetime > 0 {
+		return s.opts.TokenLifetime
+	}
+	return 60 * 24 * time.Hour
+}
+
+func (s *Server) scopes() []string {
+	var scopes []string
+	for _, scope := range []string{"instagram_basic", "instagram_content_publish", "pages_show_list"} {
+		if scope != s.opts.MissingScope {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+func writeError(w http.ResponseWriter, statusCode, code int, errType, message string) {
+	writeJSON(w, statusCode, map[string]interface{}{
+		"error": map[string]interface{}{
+			"message":    message,
+			"type":       errType,
+			"code":       code,
+			"fbtrace_id": "fakegraph",
+		},
+	})
+}
+
+func writeTransientError(w http.ResponseWriter) {
+	writeError(w, http.StatusInternalServerError, 2, "OAuthException", "An unexpected error has occurred. Please retry your request later.")
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,223 @@
+// Package fakemastodon is an in-memory stand-in for the parts of the
+// Mastodon API the publisher uses, for cross-posting locally without an
+// account on a real instance.
+package fakemastodon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/nitin737/GoAutoPosts/internal/mastodon"
+)
+
+// Options controls how the fake instance behaves
+type Options struct {
+	// MaxCharacters is the status length limit; zero means 500
+	MaxCharacters int
+	// ProcessingPolls is how many polls an upload stays unprocessed
+	ProcessingPolls int
+}
+
+type attachment struct {
+	id          string
+	description string
+	pollsLeft   int
+}
+
+// Status is a posted status as the fake stored it
+type Status struct {
+	ID       string
+	Text     string
+	MediaIDs []string
+	// AltText is the description of each attachment, in order
+	AltText []string
+}
+
+// Server implements the instance, media and statuses endpoints
+type Server struct {
+	opts Options
+
+	mu          sync.Mutex
+	nextID      int
+	attachments map[string]*attachment
+	statuses    []Status
+	idempotent  map[string]string // Idempotency-Key -> status ID
+}
+
+// NewServer creates a fake Mastodon instance
+func NewServer(opts Options) *Server {
+	if opts.MaxCharacters <= 0 {
+		opts.MaxCharacters = mastodon.DefaultMaxCharacters
+	}
+	return &Server{
+		opts:        opts,
+		nextID:      100,
+		attachments: make(map[string]*attachment),
+		idempotent:  make(map[string]string),
+	}
+}
+
+// Statuses returns the statuses posted so far, in order
+func (s *Server) Statuses() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Status(nil), s.statuses...)
+}
+
+// ServeHTTP routes /api/v2/instance, /api/v2/media, /api/v1/media/{id} and
+// /api/v1/statuses
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		writeError(w, http.StatusUnauthorized, "The access token is invalid")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v2/instance":
+		s.instance(w)
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v2/media":
+		s.uploadMedia(w, r)
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/v1/media/"):
+		s.getMedia(w, strings.TrimPrefix(r.URL.Path, "/api/v1/media/"))
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v1/statuses":
+		s.postStatus(w, r)
+	default:
+		writeError(w, http.StatusNotFound, "Record not found")
+	}
+}
+
+func (s *Server) instance(w http.ResponseWriter) {
+	statuses := map[string]int{
+		"max_characters":              s.opts.MaxCharacters,
+		"max_media_attachments":       mastodon.DefaultMaxMediaAttachments,
+		"characters_reserved_per_url": mastodon.DefaultCharactersPerURL,
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"domain":        "fakemastodon.local",
+		"configuration": map[string]interface{}{"statuses": statuses},
+	})
+}
+
+func (s *Server) uploadMedia(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "Validation failed: File can't be blank")
+		return
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "Validation failed: File can't be blank")
+		return
+	}
+	file.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	a := &attachment{
+		id:          fmt.Sprintf("%d", s.nextID),
+		description: r.FormValue("description"),
+		pollsLeft:   s.opts.ProcessingPolls,
+	}
+	s.attachments[a.id] = a
+
+	if a.pollsLeft > 0 {
+		writeJSON(w, http.StatusAccepted, a.json())
+		return
+	}
+	writeJSON(w, http.StatusOK, a.json())
+}
+
+func (s *Server) getMedia(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.attachments[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Record not found")
+		return
+	}
+
+	if a.pollsLeft > 0 {
+		a.pollsLeft--
+		writeJSON(w, http.StatusPartialContent, a.json())
+		return
+	}
+	writeJSON(w, http.StatusOK, a.json())
+}
+
+func (s *Server) postStatus(w http.ResponseWriter, r *http.Request) {
+	text := r.FormValue("status")
+	mediaIDs := r.Form["media_ids[]"]
+	key := r.Header.Get("Idempotency-Key")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id, ok := s.idempotent[key]; ok && key != "" {
+		writeJSON(w, http.StatusOK, statusJSON(id))
+		return
+	}
+
+	if n := mastodon.Length(text, mastodon.DefaultCharactersPerURL); n > s.opts.MaxCharacters {
+		writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("Validation failed: Text character limit of %d exceeded (%d)", s.opts.MaxCharacters, n))
+		return
+	}
+	if len(mediaIDs) > mastodon.DefaultMaxMediaAttachments {
+		writeError(w, http.StatusUnprocessableEntity, "Validation failed: Too many attachments")
+		return
+	}
+
+	status := Status{Text: text, MediaIDs: mediaIDs}
+	for _, id := range mediaIDs {
+		a, ok := s.attachments[id]
+		if !ok || a.pollsLeft > 0 {
+			writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("Validation failed: media %s is not ready", id))
+			return
+		}
+		status.AltText = append(status.AltText, a.description)
+	}
+
+	s.nextID++
+	status.ID = fmt.Sprintf("%d", s.nextID)
+	s.statuses = append(s.statuses, status)
+	if key != "" {
+		s.idempotent[key] = status.ID
+	}
+
+	writeJSON(w, http.StatusOK, statusJSON(status.ID))
+}
+
+func (a *attachment) json() map[string]interface{} {
+	var url interface{}
+	if a.pollsLeft == 0 {
+		url = "https://fakemastodon.local/media/" + a.id + ".png"
+	}
+	return map[string]interface{}{
+		"id":          a.id,
+		"type":        "image",
+		"url":         url,
+		"description": a.description,
+	}
+}
+
+func statusJSON(id string) map[string]string {
+	return map[string]string{
+		"id":  id,
+		"url": "https://fakemastodon.local/@fake/" + id,
+	}
+}
+
+func writeError(w http.ResponseWriter, statusCode int, message string) {
+	writeJSON(w, statusCode, map[string]string{"error": message})
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,138 @@
+// Package fakes3 is an in-memory stand-in for an S3-compatible object store,
+// such as MinIO, for running and testing the S3 media host locally. Given a
+// secret key it checks the SigV4 signature of every request and the expiry
+// of presigned URLs.
+package fakes3
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+type object struct {
+	contentType string
+	data        []byte
+}
+
+// Server stores objects in memory, addressed path-style as /{bucket}/{key}
+type Server struct {
+	accessKeyID     string
+	secretAccessKey string
+
+	mu      sync.Mutex
+	objects map[string]object
+}
+
+// NewServer creates a fake store that accepts requests signed with
+// accessKeyID and secretAccessKey. An empty secret only checks the access key
+// and an empty access key accepts any request.
+func NewServer(accessKeyID, secretAccessKey string) *Server {
+	return &Server{
+		accessKeyID:     accessKeyID,
+		secretAccessKey: secretAccessKey,
+		objects:         make(map[string]object),
+	}
+}
+
+// Keys returns the paths of the stored objects
+func (s *Server) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.objects))
+	for key := range s.objects {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// ServeHTTP implements PUT, GET, HEAD and DELETE of single objects
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+		return
+	}
+
+	if err := s.authorize(r, data); err != nil {
+		var authErr *authError
+		if errors.As(err, &authErr) {
+			writeError(w, http.StatusForbidden, authErr.code, authErr.message)
+			return
+		}
+		writeError(w, http.StatusForbidden, "AccessDenied", err.Error())
+		return
+	}
+
+	key := r.URL.Path
+	if strings.Count(strings.Trim(key, "/"), "/") < 1 {
+		writeError(w, http.StatusBadRequest, "InvalidRequest", "Only object requests are supported")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		s.mu.Lock()
+		s.objects[key] = object{contentType: r.Header.Get("Content-Type"), data: data}
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet, http.MethodHead:
+		s.mu.Lock()
+		obj, ok := s.objects[key]
+		s.mu.Unlock()
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+			return
+		}
+		w.Header().Set("Content-Type", obj.contentType)
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(obj.data)
+		}
+	case http.MethodDelete:
+		s.mu.Lock()
+		delete(s.objects, key)
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed against this resource.")
+	}
+}
+
+// authorize accepts requests whose Authorization header or presigned
+// X-Amz-Credential names the configured access key and, when the server has
+// a secret, whose signature is valid and unexpired
+func (s *Server) authorize(r *http.Request, body []byte) error {
+	if s.accessKeyID == "" {
+		return nil
+	}
+	if s.secretAccessKey != "" {
+		return verify(r, body, s.accessKeyID, s.secretAccessKey, time.Now())
+	}
+
+	credential := r.URL.Query().Get("X-Amz-Credential")
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		if _, after, ok := strings.Cut(auth, "Credential="); ok {
+			credential = after
+		}
+	}
+	if !strings.HasPrefix(credential, s.accessKeyID+"/") {
+		return &authError{"InvalidAccessKeyId", "The AWS Access Key Id you provided does not exist in our records."}
+	}
+	return nil
+}
+
+func writeError(w http.ResponseWriter, statusCode int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(statusCode)
+	_ = xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string
+		Message string
+	}{Code: code, Message: message})
+}
@@ -0,0 +1,220 @@
+package fakes3
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AWS Signature Version 4 verification, written independently of the
+// signer in internal/hosting so each checks the other
+
+const (
+	sigAlgorithm    = "AWS4-HMAC-SHA256"
+	amzDateFormat   = "20060102T150405Z"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// authError is an S3 error response for a request that failed authentication
+type authError struct {
+	code    string
+	message string
+}
+
+func (e *authError) Error() string {
+	return e.code + ": " + e.message
+}
+
+var errSignature = &authError{"SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided."}
+
+// verify checks the signature of a request signed in its Authorization
+// header, or presigned in its query string, against secretAccessKey. body is
+// the request body, compared with the payload hash the client signed.
+func verify(r *http.Request, body []byte, accessKeyID, secretAccessKey string, now time.Time) error {
+	if r.Header.Get("Authorization") != "" {
+		return verifyHeader(r, body, accessKeyID, secretAccessKey)
+	}
+	if r.URL.Query().Get("X-Amz-Signature") != "" {
+		return verifyPresigned(r, accessKeyID, secretAccessKey, now)
+	}
+	return &authError{"AccessDenied", "Access Denied"}
+}
+
+func verifyHeader(r *http.Request, body []byte, accessKeyID, secretAccessKey string) error {
+	auth, ok := strings.CutPrefix(r.Header.Get("Authorization"), sigAlgorithm+" ")
+	if !ok {
+		return &authError{"AuthorizationHeaderMalformed", "Unsupported signing algorithm"}
+	}
+
+	fields := make(map[string]string)
+	for _, field := range strings.Split(auth, ",") {
+		if name, value, ok := strings.Cut(strings.TrimSpace(field), "="); ok {
+			fields[name] = value
+		}
+	}
+	scope, err := checkCredential(fields["Credential"], accessKeyID)
+	if err != nil {
+		return err
+	}
+
+	payloadHash := r.Header.Get("X-Amz-Content-Sha256")
+	if payloadHash != unsignedPayload && payloadHash != sha256Hex(body) {
+		return &authError{"XAmzContentSHA256Mismatch", "The provided 'x-amz-content-sha256' header does not match what was computed."}
+	}
+
+	signedHeaders := fields["SignedHeaders"]
+	var headers strings.Builder
+	for _, name := range strings.Split(signedHeaders, ";") {
+		value := strings.Join(r.Header.Values(name), ",")
+		if name == "host" {
+			value = r.Host
+		}
+		headers.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+
+	canonical := strings.Join([]string{
+		r.Method,
+		canonicalURI(r.URL),
+		canonicalQuery(r.URL.Query()),
+		headers.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	want := signature(secretAccessKey, scope, r.Header.Get("X-Amz-Date"), canonical)
+	if !hmac.Equal([]byte(fields["Signature"]), []byte(want)) {
+		return errSignature
+	}
+	return nil
+}
+
+func verifyPresigned(r *http.Request, accessKeyID, secretAccessKey string, now time.Time) error {
+	query := r.URL.Query()
+	if query.Get("X-Amz-Algorithm") != sigAlgorithm {
+		return &authError{"AuthorizationQueryParametersError", "Unsupported X-Amz-Algorithm"}
+	}
+	scope, err := checkCredential(query.Get("X-Amz-Credential"), accessKeyID)
+	if err != nil {
+		return err
+	}
+
+	amzDate := query.Get("X-Amz-Date")
+	signedAt, err := time.Parse(amzDateFormat, amzDate)
+	if err != nil {
+		return &authError{"AuthorizationQueryParametersError", "X-Amz-Date must be in the ISO8601 Long Format"}
+	}
+	expires, err := strconv.Atoi(query.Get("X-Amz-Expires"))
+	if err != nil || expires < 1 || expires > 7*24*60*60 {
+		return &authError{"AuthorizationQueryParametersError", "X-Amz-Expires must be between 1 and 604800 seconds"}
+	}
+	if now.After(signedAt.Add(time.Duration(expires) * time.Second)) {
+		return &authError{"AccessDenied", "Request has expired"}
+	}
+
+	got := query.Get("X-Amz-Signature")
+	query.Del("X-Amz-Signature")
+	canonical := strings.Join([]string{
+		r.Method,
+		canonicalURI(r.URL),
+		canonicalQuery(query),
+		"host:" + r.Host + "\n",
+		query.Get("X-Amz-SignedHeaders"),
+		unsignedPayload,
+	}, "\n")
+
+	want := signature(secretAccessKey, scope, amzDate, canonical)
+	if !hmac.Equal([]byte(got), []byte(want)) {
+		return errSignature
+	}
+	return nil
+}
+
+// checkCredential checks that credential names accessKeyID and returns its
+// scope, date/region/service/aws4_request
+func checkCredential(credential, accessKeyID string) (string, error) {
+	key, scope, ok := strings.Cut(credential, "/")
+	if !ok || key != accessKeyID {
+		return "", &authError{"InvalidAccessKeyId", "The AWS Access Key Id you provided does not exist in our records."}
+	}
+	if parts := strings.Split(scope, "/"); len(parts) != 4 || parts[3] != "aws4_request" {
+		return "", &authError{"AuthorizationHeaderMalformed", fmt.Sprintf("Malformed credential scope %q", scope)}
+	}
+	return scope, nil
+}
+
+func signature(secretAccessKey, scope, amzDate, canonicalRequest string) string {
+	parts := strings.Split(scope, "/")
+	stringToSign := strings.Join([]string{sigAlgorithm, amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := []byte("AWS4" + secretAccessKey)
+	for _, part := range parts {
+		key = hmacSHA256(key, part)
+	}
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// canonicalURI encodes each segment of the decoded path once
+func canonicalURI(u *url.URL) string {
+	if u.Path == "" {
+		return "/"
+	}
+	segments := strings.Split(u.Path, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery sorts the encoded parameters by name, then by value
+func canonicalQuery(query url.Values) string {
+	type param struct{ key, val string }
+	var params []param
+	for key, vals := range query {
+		for _, val := range vals {
+			params = append(params, param{uriEncode(key), uriEncode(val)})
+		}
+	}
+	sort.Slice(params, func(i, j int) bool {
+		if params[i].key != params[j].key {
+			return params[i].key < params[j].key
+		}
+		return params[i].val < params[j].val
+	})
+
+	parts := make([]string, len(params))
+	for i, p := range params {
+		parts[i] = p.key + "=" + p.val
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes everything except the RFC 3986 unreserved characters
+func uriEncode(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || strings.IndexByte("-_.~", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,126 @@
+// Package hosting makes rendered media reachable by the Graph API, which
+// downloads images and videos from public URLs instead of accepting uploads.
+package hosting
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// Backends accepted by the publisher's MEDIA_HOST setting
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+	BackendPages = "pages"
+)
+
+// MediaHost publishes local files under public URLs
+type MediaHost interface {
+	// Upload publishes the file at path and returns its public URL
+	Upload(ctx context.Context, path string) (string, error)
+	// Delete removes a file published under url
+	Delete(ctx context.Context, url string) error
+	// Close releases the host; files still published may become unreachable
+	Close() error
+}
+
+// BatchUploader is implemented by hosts that publish several files more
+// cheaply at once than one by one
+type BatchUploader interface {
+	UploadAll(ctx context.Context, paths []string) ([]string, error)
+}
+
+// BatchDeleter is implemented by hosts that delete several files more
+// cheaply at once than one by one
+type BatchDeleter interface {
+	DeleteAll(ctx context.Context, urls []string) error
+}
+
+// Releaser is implemented by hosts that serve files themselves and can stop
+// once the Graph API has fetched them, waiting for any file not fetched yet
+type Releaser interface {
+	Release() error
+}
+
+// Uploads publishes files to a host and remembers them so everything a run
+// published can be removed together once it is done
+type Uploads struct {
+	host MediaHost
+	urls []string
+}
+
+// NewUploads tracks uploads to host
+func NewUploads(host MediaHost) *Uploads {
+	return &Uploads{
+		host: host,
+	}
+}
+
+// Upload publishes paths and returns their URLs in the same order
+func (u *Uploads) Upload(ctx context.Context, paths ...string) ([]string, error) {
+	if batch, ok := u.host.(BatchUploader); ok {
+		urls, err := batch.UploadAll(ctx, paths)
+		u.urls = append(u.urls, urls...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload media: %w", err)
+		}
+		return urls, nil
+	}
+
+	urls := make([]string, 0, len(paths))
+	for _, path := range paths {
+		url, err := u.host.Upload(ctx, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload %s: %w", path, err)
+		}
+		u.urls = append(u.urls, url)
+		urls = append(urls, url)
+	}
+	return urls, nil
+}
+
+// Cleanup deletes everything uploaded so far
+func (u *Uploads) Cleanup(ctx context.Context) error {
+	urls := u.urls
+	u.urls = nil
+	if len(urls) == 0 {
+		return nil
+	}
+
+	if batch, ok := u.host.(BatchDeleter); ok {
+		return batch.DeleteAll(ctx, urls)
+	}
+
+	var errs []error
+	for _, url := range urls {
+		if err := u.host.Delete(ctx, url); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete %s: %w", url, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Release is called once the Graph API has fetched everything uploaded so
+// far. A host that is a Releaser is released first, then the uploads are
+// deleted.
+func (u *Uploads) Release(ctx context.Context) error {
+	var errs []error
+	if releaser, ok := u.host.(Releaser); ok {
+		errs = append(errs, releaser.Release())
+	}
+	errs = append(errs, u.Cleanup(ctx))
+	return errors.Join(errs...)
+}
+
+// randomID returns an unguessable path segment, so published files cannot be
+// found by trying names
+func randomID() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,281 @@
+package hosting
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults for LocalConfig
+const (
+	DefaultLocalURLExpiry       = time.Hour
+	DefaultLocalShutdownTimeout = time.Minute
+)
+
+// LocalConfig configures the built-in media server
+type LocalConfig struct {
+	// Addr is the address to listen on, e.g. :8080
+	Addr string
+	// BaseURL is how Instagram reaches the server, e.g. an ngrok tunnel
+	BaseURL string
+	// URLExpiry is how long a published URL stays valid
+	URLExpiry time.Duration
+	// ShutdownTimeout bounds how long Close waits for files that have not
+	// been fetched yet and for requests in flight
+	ShutdownTimeout time.Duration
+}
+
+// localFile is a file published by a LocalServer
+type localFile struct {
+	path    string
+	fetched bool
+}
+
+// LocalServer serves uploaded files over HTTP from this machine. Only files
+// passed to Upload are reachable, each under an unguessable path and through
+// a URL signed with a per-server HMAC key that expires after URLExpiry.
+// Every other request, including a bad signature or an expired URL, gets a
+// 404. The machine must be reachable at the public base URL, for example
+// through a tunnel.
+type LocalServer struct {
+	cfg    LocalConfig
+	key    []byte
+	server *http.Server
+	done   chan error
+
+	closeOnce sync.Once
+	closeErr  error
+
+	mu      sync.Mutex
+	files   map[string]*localFile // URL path -> file
+	changed chan struct{}
+}
+
+// NewLocalServer starts serving on cfg.Addr
+func NewLocalServer(cfg LocalConfig) (*LocalServer, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("the local media host needs a public base URL")
+	}
+	if cfg.URLExpiry <= 0 {
+		cfg.URLExpiry = DefaultLocalURLExpiry
+	}
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = DefaultLocalShutdownTimeout
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+
+	// The key only has to outlive the run, so a fresh one is generated for
+	// every server
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", cfg.Addr, err)
+	}
+
+	s := &LocalServer{
+		cfg:     cfg,
+		key:     key,
+		done:    make(chan error, 1),
+		files:   make(map[string]*localFile),
+		changed: make(chan struct{}, 1),
+	}
+	s.server = &http.Server{Handler: s}
+	go func() {
+		s.done <- s.server.Serve(listener)
+	}()
+
+	return s, nil
+}
+
+// Upload makes the file at path reachable and returns its signed URL
+func (s *LocalServer) Upload(ctx context.Context, localPath string) (string, error) {
+	if _, err := os.Stat(localPath); err != nil {
+		return "", err
+	}
+
+	id, err := randomID()
+	if err != nil {
+		return "", err
+	}
+	urlPath := path.Join("/media", id, filepath.Base(localPath))
+
+	s.mu.Lock()
+	s.files[urlPath] = &localFile{path: localPath}
+	s.mu.Unlock()
+
+	expires := strconv.FormatInt(time.Now().Add(s.cfg.URLExpiry).Unix(), 10)
+	query := url.Values{
+		"expires":   {expires},
+		"signature": {s.signature(urlPath, expires)},
+	}
+	return s.cfg.BaseURL + urlPath + "?" + query.Encode(), nil
+}
+
+// Delete stops serving the file behind rawURL. The local file is left alone.
+func (s *LocalServer) Delete(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(strings.TrimPrefix(rawURL, s.cfg.BaseURL))
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.files[u.Path]; !ok {
+		return fmt.Errorf("not served: %s", rawURL)
+	}
+	delete(s.files, u.Path)
+	s.notify()
+	return nil
+}
+
+// Close shuts the server down once every file still served has been fetched
+// or ShutdownTimeout passes, letting requests in flight finish. Closing again
+// returns the first result.
+func (s *LocalServer) Close() error {
+	s.closeOnce.Do(func() {
+		s.closeErr = s.shutdown()
+	})
+	return s.closeErr
+}
+
+// Release shuts the server down like Close once the Graph API is done with
+// it, so it stops listening before the run cross-posts elsewhere
+func (s *LocalServer) Release() error {
+	return s.Close()
+}
+
+func (s *LocalServer) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+	defer cancel()
+
+	var errs []error
+	if unfetched := s.waitFetched(ctx); unfetched > 0 {
+		errs = append(errs, fmt.Errorf("%d media files were never fetched", unfetched))
+	}
+
+	if err := s.server.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to shut down media server: %w", err), s.server.Close())
+	}
+	if err := <-s.done; !errors.Is(err, http.ErrServerClosed) {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// waitFetched blocks until every served file has been fetched or ctx is done
+// and returns how many were not
+func (s *LocalServer) waitFetched(ctx context.Context) int {
+	for {
+		unfetched := s.unfetched()
+		if unfetched == 0 {
+			return 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return unfetched
+		case <-s.changed:
+		}
+	}
+}
+
+func (s *LocalServer) unfetched() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for _, f := range s.files {
+		if !f.fetched {
+			n++
+		}
+	}
+	return n
+}
+
+// notify wakes waitFetched; callers hold s.mu
+func (s *LocalServer) notify() {
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+// ServeHTTP serves uploaded files behind valid, unexpired signatures and
+// answers 404 for everything else
+func (s *LocalServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.NotFound(w, r)
+		return
+	}
+	if !s.validSignature(r.URL) {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	file, ok := s.files[r.URL.Path]
+	s.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	f, err := os.Open(file.path)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+
+	if r.Method == http.MethodGet {
+		s.mu.Lock()
+		file.fetched = true
+		s.notify()
+		s.mu.Unlock()
+	}
+}
+
+func (s *LocalServer) validSignature(u *url.URL) bool {
+	query := u.Query()
+	expires := query.Get("expires")
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return false
+	}
+
+	want := s.signature(u.Path, expires)
+	return hmac.Equal([]byte(query.Get("signature")), []byte(want))
+}
+
+// signature authenticates a URL path and its expiry
+func (s *LocalServer) signature(urlPath, expires string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(urlPath + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
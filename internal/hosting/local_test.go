@@ -0,0 +1,209 @@
+package hosting
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestLocalServer starts a LocalServer on a free port with one uploaded
+// file and returns the file's URL
+func newTestLocalServer(t *testing.T, shutdownTimeout time.Duration) (*LocalServer, *url.URL) {
+	t.Helper()
+
+	s, err := NewLocalServer(LocalConfig{
+		Addr:            "127.0.0.1:0",
+		BaseURL:         "https://media.example.com/",
+		ShutdownTimeout: shutdownTimeout,
+	})
+	if err != nil {
+		t.Fatalf("NewLocalServer: %v", err)
+	}
+	t.Cleanup(func() { _ = s.server.Close() })
+
+	path := filepath.Join(t.TempDir(), "slide-1.png")
+	if err := os.WriteFile(path, []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rawURL, err := s.Upload(context.Background(), path)
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if !strings.HasPrefix(rawURL, "https://media.example.com/media/") {
+		t.Fatalf("Upload returned %s, want a URL under the base URL", rawURL)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, u
+}
+
+// serve sends a request for u straight to the server's handler
+func serve(s *LocalServer, method string, u *url.URL) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(method, u.RequestURI(), nil))
+	return w
+}
+
+// withQuery returns a copy of u with key set to value
+func withQuery(u *url.URL, key, value string) *url.URL {
+	changed := *u
+	query := changed.Query()
+	query.Set(key, value)
+	changed.RawQuery = query.Encode()
+	return &changed
+}
+
+func TestLocalServerServesSignedURL(t *testing.T) {
+	s, u := newTestLocalServer(t, time.Second)
+
+	w := serve(s, http.MethodGet, u)
+	if w.Code != http.StatusOK || w.Body.String() != "png" {
+		t.Fatalf("GET = %d %q, want 200 with the file", w.Code, w.Body.String())
+	}
+	if got := s.unfetched(); got != 0 {
+		t.Errorf("%d files unfetched after the GET, want 0", got)
+	}
+}
+
+func TestLocalServerRejectsBadRequests(t *testing.T) {
+	s, u := newTestLocalServer(t, time.Second)
+
+	past := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+	expired := withQuery(withQuery(u, "expires", past), "signature", s.signature(u.Path, past))
+
+	unknown := *u
+	unknown.Path = "/media/0123456789abcdef01234567/other.png"
+	unknown = *withQuery(&unknown, "signature", s.signature(unknown.Path, u.Query().Get("expires")))
+
+	tests := []struct {
+		name   string
+		method string
+		url    *url.URL
+	}{
+		{"tampered signature", http.MethodGet, withQuery(u, "signature", strings.Repeat("0", 64))},
+		{"missing signature", http.MethodGet, withQuery(u, "signature", "")},
+		{"extended expiry", http.MethodGet, withQuery(u, "expires", strconv.FormatInt(time.Now().Add(24*time.Hour).Unix(), 10))},
+		{"expired", http.MethodGet, expired},
+		{"unknown path", http.MethodGet, &unknown},
+		{"post", http.MethodPost, u},
+		{"put", http.MethodPut, u},
+		{"delete", http.MethodDelete, u},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serve(s, tt.method, tt.url); w.Code != http.StatusNotFound {
+				t.Errorf("%s = %d, want 404", tt.method, w.Code)
+			}
+		})
+	}
+
+	if got := s.unfetched(); got != 1 {
+		t.Errorf("%d files unfetched after rejected requests, want 1", got)
+	}
+}
+
+func TestLocalServerStopsServingDeletedFiles(t *testing.T) {
+	s, u := newTestLocalServer(t, time.Second)
+
+	rawURL := "https://media.example.com" + u.RequestURI()
+	if err := s.Delete(context.Background(), rawURL); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if w := serve(s, http.MethodGet, u); w.Code != http.StatusNotFound {
+		t.Errorf("GET after Delete = %d, want 404", w.Code)
+	}
+}
+
+func TestLocalServerCloseWaitsForFetch(t *testing.T) {
+	s, u := newTestLocalServer(t, 5*time.Second)
+
+	closed := make(chan error, 1)
+	go func() { closed <- s.Close() }()
+
+	select {
+	case err := <-closed:
+		t.Fatalf("Close returned %v before the file was fetched", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	serve(s, http.MethodGet, u)
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Errorf("Close: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close still waiting after the file was fetched")
+	}
+}
+
+func TestLocalServerCloseGivesUpAfterTimeout(t *testing.T) {
+	s, _ := newTestLocalServer(t, 100*time.Millisecond)
+
+	start := time.Now()
+	err := s.Close()
+	elapsed := time.Since(start)
+
+	if err == nil || !strings.Contains(err.Error(), "1 media files were never fetched") {
+		t.Errorf("Close error = %v, want the unfetched file reported", err)
+	}
+	if elapsed < 100*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("Close took %s, want about the 100ms shutdown timeout", elapsed)
+	}
+	if again := s.Close(); again == nil || again.Error() != err.Error() {
+		t.Errorf("second Close = %v, want the first result", again)
+	}
+}
+
+// releasingHost records what Uploads asks of it
+type releasingHost struct {
+	released bool
+	deleted  []string
+}
+
+func (h *releasingHost) Upload(ctx context.Context, path string) (string, error) {
+	return "https://media.example.com/" + filepath.Base(path), nil
+}
+
+func (h *releasingHost) Delete(ctx context.Context, url string) error {
+	h.deleted = append(h.deleted, url)
+	return nil
+}
+
+func (h *releasingHost) Close() error { return nil }
+
+func (h *releasingHost) Release() error {
+	h.released = true
+	return nil
+}
+
+var _ Releaser = (*LocalServer)(nil)
+
+func TestUploadsReleaseReleasesHost(t *testing.T) {
+	host := &releasingHost{}
+	uploads := NewUploads(host)
+	if _, err := uploads.Upload(context.Background(), "/tmp/slide-1.png"); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+
+	if err := uploads.Release(context.Background()); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if !host.released {
+		t.Error("Release did not release the host")
+	}
+	if len(host.deleted) != 1 {
+		t.Errorf("deleted %v, want the upload", host.deleted)
+	}
+}
@@ -0,0 +1,201 @@
+package hosting
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// PagesConfig configures a static site published from a git checkout, such
+// as GitHub Pages
+type PagesConfig struct {
+	// Dir is the checkout the site is published from
+	Dir string
+	// BaseURL is the URL the site's root is served at
+	BaseURL string
+	// Prefix is the directory inside Dir that media is copied into
+	Prefix string
+	// Push commits and pushes every change; without it Dir is expected to be
+	// served as is
+	Push bool
+	// WaitTimeout is how long to wait for uploaded files to go live
+	WaitTimeout time.Duration
+}
+
+// PagesHost publishes files by copying them into a static site
+type PagesHost struct {
+	cfg        PagesConfig
+	httpClient *http.Client
+}
+
+// NewPagesHost creates a host for the site in cfg
+func NewPagesHost(cfg PagesConfig) (*PagesHost, error) {
+	if cfg.Dir == "" || cfg.BaseURL == "" {
+		return nil, fmt.Errorf("the pages media host needs a directory and a base URL")
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = "media"
+	}
+	if cfg.WaitTimeout <= 0 {
+		cfg.WaitTimeout = 5 * time.Minute
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+
+	return &PagesHost{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Upload publishes a single file
+func (h *PagesHost) Upload(ctx context.Context, localPath string) (string, error) {
+	urls, err := h.UploadAll(ctx, []string{localPath})
+	if err != nil {
+		return "", err
+	}
+	return urls[0], nil
+}
+
+// UploadAll copies paths into the site, pushes them in a single commit and
+// waits until every file is served
+func (h *PagesHost) UploadAll(ctx context.Context, paths []string) ([]string, error) {
+	var urls, relPaths []string
+	for _, localPath := range paths {
+		id, err := randomID()
+		if err != nil {
+			return urls, err
+		}
+		rel := path.Join(h.cfg.Prefix, id, filepath.Base(localPath))
+		if err := copyFile(localPath, filepath.Join(h.cfg.Dir, filepath.FromSlash(rel))); err != nil {
+			return urls, err
+		}
+		urls = append(urls, h.cfg.BaseURL+"/"+rel)
+		relPaths = append(relPaths, rel)
+	}
+
+	if h.cfg.Push {
+		if err := h.push(ctx, fmt.Sprintf("Add %d media files", len(relPaths)), relPaths); err != nil {
+			return urls, err
+		}
+	}
+
+	for _, url := range urls {
+		if err := h.waitLive(ctx, url); err != nil {
+			return urls, err
+		}
+	}
+	return urls, nil
+}
+
+// Delete removes a single file
+func (h *PagesHost) Delete(ctx context.Context, url string) error {
+	return h.DeleteAll(ctx, []string{url})
+}
+
+// DeleteAll removes the files behind urls and pushes the removal in a single
+// commit
+func (h *PagesHost) DeleteAll(ctx context.Context, urls []string) error {
+	var relPaths []string
+	for _, url := range urls {
+		rel, ok := strings.CutPrefix(url, h.cfg.BaseURL+"/")
+		if !ok || !strings.HasPrefix(rel, h.cfg.Prefix+"/") {
+			return fmt.Errorf("not published by this host: %s", url)
+		}
+
+		localPath := filepath.Join(h.cfg.Dir, filepath.FromSlash(rel))
+		if err := os.Remove(localPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		// Drop the per-upload directory once it is empty
+		_ = os.Remove(filepath.Dir(localPath))
+		relPaths = append(relPaths, rel)
+	}
+
+	if h.cfg.Push && len(relPaths) > 0 {
+		return h.push(ctx, "Remove published media", relPaths)
+	}
+	return nil
+}
+
+// Close is a no-op; the site keeps serving files until they are deleted
+func (h *PagesHost) Close() error {
+	return nil
+}
+
+func (h *PagesHost) push(ctx context.Context, message string, relPaths []string) error {
+	if err := h.git(ctx, append([]string{"add", "--all", "--"}, relPaths...)...); err != nil {
+		return err
+	}
+	if err := h.git(ctx, "commit", "--quiet", "-m", message); err != nil {
+		return err
+	}
+	return h.git(ctx, "push", "--quiet")
+}
+
+func (h *PagesHost) git(ctx context.Context, args ...string) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", h.cfg.Dir}, args...)...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// waitLive polls url until the site serves it; static hosts usually take a
+// while to deploy a push
+func (h *PagesHost) waitLive(ctx context.Context, url string) error {
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.WaitTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+		if err != nil {
+			return err
+		}
+		if resp, err := h.httpClient.Do(req); err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s did not go live within %s: %w", url, h.cfg.WaitTimeout, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,139 @@
+package hosting
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestPagesHost serves dir over HTTP and returns a pages host for it
+func newTestPagesHost(t *testing.T, dir string, push bool) *PagesHost {
+	t.Helper()
+
+	server := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	t.Cleanup(server.Close)
+
+	host, err := NewPagesHost(PagesConfig{
+		Dir:         dir,
+		BaseURL:     server.URL + "/",
+		Push:        push,
+		WaitTimeout: 2 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewPagesHost: %v", err)
+	}
+	return host
+}
+
+func writeSlides(t *testing.T, n int) []string {
+	t.Helper()
+
+	dir := t.TempDir()
+	var paths []string
+	for i := 1; i <= n; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("slide-%d.png", i))
+		if err := os.WriteFile(path, []byte("png"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestPagesHostUploadAndDelete(t *testing.T) {
+	dir := t.TempDir()
+	host := newTestPagesHost(t, dir, false)
+	ctx := context.Background()
+
+	urls, err := host.UploadAll(ctx, writeSlides(t, 2))
+	if err != nil {
+		t.Fatalf("UploadAll: %v", err)
+	}
+	if len(urls) != 2 {
+		t.Fatalf("UploadAll returned %v, want 2 URLs", urls)
+	}
+	for i, rawURL := range urls {
+		if !strings.HasPrefix(rawURL, host.cfg.BaseURL+"/media/") || !strings.HasSuffix(rawURL, fmt.Sprintf("/slide-%d.png", i+1)) {
+			t.Errorf("URL %d is %s, want the slide under the media prefix", i, rawURL)
+		}
+		if status, body := get(t, rawURL); status != http.StatusOK || body != "png" {
+			t.Errorf("GET %s = %d %q, want 200 with the file", rawURL, status, body)
+		}
+	}
+
+	if err := host.DeleteAll(ctx, urls); err != nil {
+		t.Fatalf("DeleteAll: %v", err)
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "media"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("%d upload directories left after DeleteAll", len(entries))
+	}
+}
+
+func TestPagesHostRejectsForeignURL(t *testing.T) {
+	host := newTestPagesHost(t, t.TempDir(), false)
+
+	for _, rawURL := range []string{
+		"https://elsewhere.example.com/media/abc/slide-1.png",
+		host.cfg.BaseURL + "/index.html",
+	} {
+		if err := host.Delete(context.Background(), rawURL); err == nil {
+			t.Errorf("Delete(%s) succeeded, want it refused", rawURL)
+		}
+	}
+}
+
+func TestPagesHostPushes(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	remote := filepath.Join(t.TempDir(), "site.git")
+	dir := filepath.Join(t.TempDir(), "site")
+	runGit(t, "", "init", "--quiet", "--bare", remote)
+	runGit(t, "", "clone", "--quiet", remote, dir)
+	runGit(t, dir, "config", "user.name", "test")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+	runGit(t, dir, "commit", "--quiet", "--allow-empty", "-m", "Initial commit")
+	runGit(t, dir, "push", "--quiet", "origin", "HEAD")
+
+	host := newTestPagesHost(t, dir, true)
+	ctx := context.Background()
+
+	urls, err := host.UploadAll(ctx, writeSlides(t, 2))
+	if err != nil {
+		t.Fatalf("UploadAll: %v", err)
+	}
+	if err := host.DeleteAll(ctx, urls); err != nil {
+		t.Fatalf("DeleteAll: %v", err)
+	}
+
+	log := runGit(t, remote, "log", "--format=%s")
+	want := "Remove published media\nAdd 2 media files\nInitial commit"
+	if log != want {
+		t.Errorf("pushed commits =\n%s\nwant\n%s", log, want)
+	}
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,166 @@
+package hosting
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// S3Config configures an S3-compatible bucket
+type S3Config struct {
+	// Endpoint is the store's base URL, e.g. https://s3.eu-west-1.amazonaws.com
+	// or http://localhost:9000 for MinIO. Buckets are addressed path-style.
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// Prefix is prepended to every object key
+	Prefix string
+	// PublicURL is the base URL of a publicly readable bucket. When empty,
+	// objects are shared through presigned URLs valid for URLExpiry.
+	PublicURL string
+	URLExpiry time.Duration
+}
+
+// S3Host uploads files to an S3-compatible bucket
+type S3Host struct {
+	cfg        S3Config
+	endpoint   *url.URL
+	signer     signer
+	httpClient *http.Client
+
+	mu   sync.Mutex
+	keys map[string]string // public URL -> object key
+}
+
+// NewS3Host creates a host for the bucket in cfg
+func NewS3Host(cfg S3Config) (*S3Host, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, fmt.Errorf("the s3 media host needs an endpoint and a bucket")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("the s3 media host needs an access key ID and secret")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.URLExpiry <= 0 {
+		cfg.URLExpiry = time.Hour
+	}
+
+	endpoint, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint: %w", err)
+	}
+
+	return &S3Host{
+		cfg:        cfg,
+		endpoint:   endpoint,
+		signer:     signer{accessKeyID: cfg.AccessKeyID, secretAccessKey: cfg.SecretAccessKey, region: cfg.Region},
+		httpClient: &http.Client{Timeout: 2 * time.Minute},
+		keys:       make(map[string]string),
+	}, nil
+}
+
+// Upload puts the file at path into the bucket and returns its URL
+func (h *S3Host) Upload(ctx context.Context, localPath string) (string, error) {
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		return "", err
+	}
+
+	id, err := randomID()
+	if err != nil {
+		return "", err
+	}
+	key := path.Join(h.cfg.Prefix, id, filepath.Base(localPath))
+
+	contentType := mime.TypeByExtension(filepath.Ext(localPath))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, h.objectURL(key).String(), bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if err := h.do(req, sha256Hex(data)); err != nil {
+		return "", fmt.Errorf("failed to store object %s: %w", key, err)
+	}
+
+	publicURL := h.publicURL(key)
+	h.mu.Lock()
+	h.keys[publicURL] = key
+	h.mu.Unlock()
+
+	return publicURL, nil
+}
+
+// Delete removes the object behind url
+func (h *S3Host) Delete(ctx context.Context, publicURL string) error {
+	h.mu.Lock()
+	key, ok := h.keys[publicURL]
+	h.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("not uploaded by this host: %s", publicURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, h.objectURL(key).String(), nil)
+	if err != nil {
+		return err
+	}
+	if err := h.do(req, emptyPayloadHash); err != nil {
+		return fmt.Errorf("failed to delete object %s: %w", key, err)
+	}
+
+	h.mu.Lock()
+	delete(h.keys, publicURL)
+	h.mu.Unlock()
+	return nil
+}
+
+// Close is a no-op; objects stay until they are deleted
+func (h *S3Host) Close() error {
+	return nil
+}
+
+func (h *S3Host) objectURL(key string) *url.URL {
+	u := *h.endpoint
+	u.Path = path.Join("/", u.Path, h.cfg.Bucket, key)
+	return &u
+}
+
+func (h *S3Host) publicURL(key string) string {
+	if h.cfg.PublicURL != "" {
+		return strings.TrimRight(h.cfg.PublicURL, "/") + "/" + key
+	}
+	return h.signer.presign(h.objectURL(key), h.cfg.URLExpiry, time.Now()).String()
+}
+
+func (h *S3Host) do(req *http.Request, payloadHash string) error {
+	h.signer.sign(req, payloadHash, time.Now())
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
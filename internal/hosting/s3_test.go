@@ -0,0 +1,157 @@
+package hosting
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/fakes3"
+)
+
+// newTestS3Host starts the fake S3 store and returns a host for its media
+// bucket signing with secret
+func newTestS3Host(t *testing.T, secret string) (*S3Host, *fakes3.Server) {
+	t.Helper()
+
+	fake := fakes3.NewServer("AKIDTEST", "test-secret")
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	host, err := NewS3Host(S3Config{
+		Endpoint:        server.URL,
+		Bucket:          "media",
+		AccessKeyID:     "AKIDTEST",
+		SecretAccessKey: secret,
+		Prefix:          "posts",
+	})
+	if err != nil {
+		t.Fatalf("NewS3Host: %v", err)
+	}
+	return host, fake
+}
+
+// get fetches rawURL and returns the status and body
+func get(t *testing.T, rawURL string) (int, string) {
+	t.Helper()
+
+	resp, err := http.Get(rawURL)
+	if err != nil {
+		t.Fatalf("GET %s: %v", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+func TestS3HostUploadAndDelete(t *testing.T) {
+	host, fake := newTestS3Host(t, "test-secret")
+	ctx := context.Background()
+
+	rawURL, err := host.Upload(ctx, writeSlides(t, 1)[0])
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if keys := fake.Keys(); len(keys) != 1 || !strings.HasPrefix(keys[0], "/media/posts/") || !strings.HasSuffix(keys[0], "/slide-1.png") {
+		t.Errorf("stored %v, want one object under /media/posts/", keys)
+	}
+
+	if status, body := get(t, rawURL); status != http.StatusOK || body != "png" {
+		t.Fatalf("GET presigned URL = %d %q, want 200 with the file", status, body)
+	}
+
+	if err := host.Delete(ctx, rawURL); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if keys := fake.Keys(); len(keys) != 0 {
+		t.Errorf("objects left after Delete: %v", keys)
+	}
+	if status, _ := get(t, rawURL); status != http.StatusNotFound {
+		t.Errorf("GET after Delete = %d, want 404", status)
+	}
+	if err := host.Delete(ctx, rawURL); err == nil {
+		t.Error("Delete of a deleted URL succeeded")
+	}
+}
+
+func TestS3HostRejectsWrongSecret(t *testing.T) {
+	host, fake := newTestS3Host(t, "wrong-secret")
+
+	_, err := host.Upload(context.Background(), writeSlides(t, 1)[0])
+	if err == nil || !strings.Contains(err.Error(), "SignatureDoesNotMatch") {
+		t.Fatalf("Upload error = %v, want SignatureDoesNotMatch", err)
+	}
+	if keys := fake.Keys(); len(keys) != 0 {
+		t.Errorf("stored %v with a bad signature", keys)
+	}
+}
+
+func TestS3PresignedURLCannotBeAlteredOrReusedAfterExpiry(t *testing.T) {
+	host, _ := newTestS3Host(t, "test-secret")
+
+	rawURL, err := host.Upload(context.Background(), writeSlides(t, 1)[0])
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alter := func(key, value string) string {
+		changed := *u
+		query := changed.Query()
+		query.Set(key, value)
+		changed.RawQuery = query.Encode()
+		return changed.String()
+	}
+	otherPath := *u
+	otherPath.Path = strings.Replace(u.Path, "slide-1", "slide-2", 1)
+
+	expired := host.signer.presign(host.objectURL(host.keys[rawURL]), time.Minute, time.Now().Add(-time.Hour))
+
+	tests := []struct {
+		name string
+		url  string
+		code string
+	}{
+		{"altered signature", alter("X-Amz-Signature", strings.Repeat("0", 64)), "SignatureDoesNotMatch"},
+		{"extended expiry", alter("X-Amz-Expires", "604800"), "SignatureDoesNotMatch"},
+		{"other object", otherPath.String(), "SignatureDoesNotMatch"},
+		{"other access key", alter("X-Amz-Credential", strings.Replace(u.Query().Get("X-Amz-Credential"), "AKIDTEST", "AKIDOTHER", 1)), "InvalidAccessKeyId"},
+		{"expired", expired.String(), "Request has expired"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := get(t, tt.url)
+			if status != http.StatusForbidden || !strings.Contains(body, tt.code) {
+				t.Errorf("GET = %d %q, want 403 %s", status, body, tt.code)
+			}
+		})
+	}
+}
+
+func TestS3HostPublicURL(t *testing.T) {
+	host, _ := newTestS3Host(t, "test-secret")
+	host.cfg.PublicURL = "https://cdn.example.com/"
+
+	rawURL, err := host.Upload(context.Background(), writeSlides(t, 1)[0])
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if !strings.HasPrefix(rawURL, "https://cdn.example.com/posts/") || strings.Contains(rawURL, "X-Amz-") {
+		t.Errorf("Upload returned %s, want an unsigned URL under the public URL", rawURL)
+	}
+	if err := host.Delete(context.Background(), rawURL); err != nil {
+		t.Errorf("Delete: %v", err)
+	}
+}
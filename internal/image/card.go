@@ -16,6 +16,7 @@ const (
 	CardTypeContent CardType = "content"
 	CardTypeCode    CardType = "code"
 	CardTypeCTA     CardType = "cta"
+	CardTypeStory   CardType = "story"
 )
 
 // Card represents a single slide in the carousel
@@ -95,3 +96,50 @@ func GenerateStoryboard(lib *model.Library) []Card {
 
 	return cards
 }
+
+// StoryCard creates the vertical Story teaser for a library: the cover plus a
+// prompt pointing to the new post
+func StoryCard(lib *model.Library) Card {
+	return Card{
+		Type:     CardTypeStory,
+		Title:    lib.Name,
+		Subtitle: "Go Library Spotlight",
+		Body:     "NEW POST",
+	}
+}
+
+// AltText describes a card for screen readers
+func (c Card) AltText() string {
+	var alt string
+	for _, text := range []string{c.Title, c.Subtitle, c.Body, c.Code} {
+		text = strings.Join(strings.Fields(text), " ")
+		if text == "" {
+			continue
+		}
+		if alt != "" && !strings.ContainsAny(alt[len(alt)-1:], ".?!:") {
+			alt += "."
+		}
+		if alt != "" {
+			alt += " "
+		}
+		alt += text
+	}
+
+	if c.TotalSlides > 0 {
+		alt = fmt.Sprintf("Slide %d of %d: %s", c.Index, c.TotalSlides, alt)
+	}
+	return alt
+}
+
+// AltTexts returns the alt text of every carousel slide of a library, in the
+// order GenerateCarousel renders them
+func AltTexts(lib *model.Library) []string {
+	cards := GenerateStoryboard(lib)
+	texts := make([]string, len(cards))
+	for i := range cards {
+		cards[i].Index = i + 1
+		cards[i].TotalSlides = len(cards)
+		texts[i] = cards[i].AltText()
+	}
+	return texts
+}
@@ -68,18 +68,31 @@ func (e *Engine) RenderCard(card Card) (image.Image, error) {
 	return dc.Image(), nil
 }
 
+// RenderStory draws a card on the vertical Story canvas
+func (e *Engine) RenderStory(card Card) (image.Image, error) {
+	dc := gg.NewContext(StoryWidth, StoryHeight)
+
+	e.drawBackground(dc)
+	e.renderStory(dc, card)
+
+	return dc.Image(), nil
+}
+
+// drawBackground fills the whole canvas, square or vertical
 func (e *Engine) drawBackground(dc *gg.Context) {
+	w, h := float64(dc.Width()), float64(dc.Height())
+
 	// Gradient Background
-	grad := gg.NewLinearGradient(0, 0, 0, float64(Height))
+	grad := gg.NewLinearGradient(0, 0, 0, h)
 	grad.AddColorStop(0, ColorBackgroundStart)
 	grad.AddColorStop(1, ColorBackgroundEnd)
 	dc.SetFillStyle(grad)
-	dc.DrawRectangle(0, 0, float64(Width), float64(Height))
+	dc.DrawRectangle(0, 0, w, h)
 	dc.Fill()
 
 	// Subtle header accent
 	dc.SetColor(ColorAccent)
-	dc.DrawRectangle(0, 0, float64(Width), 20)
+	dc.DrawRectangle(0, 0, w, 20)
 	dc.Fill()
 }
 
@@ -207,3 +220,40 @@ func (e *Engine) renderCTA(dc *gg.Context, card Card) {
 	dc.SetFontFace(truetype.NewFace(e.fontRegular, &truetype.Options{Size: FontSizeSubtitle}))
 	dc.DrawStringAnchored("Follow @go.daily for more!", Width/2, Height/2+100, 0.5, 0.5)
 }
+
+// renderStory lays the cover out vertically inside the Story safe zone, with
+// the prompt as a pill below it
+func (e *Engine) renderStory(dc *gg.Context, card Card) {
+	centerX := float64(StoryWidth) / 2
+	centerY := float64(StoryHeight) / 2
+
+	// Title
+	dc.SetColor(ColorTextPrimary)
+	dc.SetFontFace(truetype.NewFace(e.fontBold, &truetype.Options{Size: FontSizeTitle * 1.4}))
+	dc.DrawStringWrapped(strings.ToUpper(card.Title), centerX, centerY-200, 0.5, 0.5, StoryWidth-Padding*2, 1.2, gg.AlignCenter)
+
+	// Subtitle
+	dc.SetColor(ColorAccent)
+	dc.SetFontFace(truetype.NewFace(e.fontRegular, &truetype.Options{Size: FontSizeSubtitle}))
+	dc.DrawStringAnchored(card.Subtitle, centerX, centerY, 0.5, 0.5)
+
+	// Prompt pill
+	face := truetype.NewFace(e.fontBold, &truetype.Options{Size: FontSizeSubtitle})
+	dc.SetFontFace(face)
+	textW, _ := dc.MeasureString(card.Body)
+	pillW, pillH := textW+Padding*2, FontSizeSubtitle*2
+	pillY := centerY + 250
+	dc.SetColor(ColorAccent)
+	dc.DrawRoundedRectangle(centerX-pillW/2, pillY-pillH/2, pillW, pillH, pillH/2)
+	dc.Fill()
+	dc.SetColor(ColorBackgroundStart)
+	dc.DrawStringAnchored(card.Body, centerX, pillY, 0.5, 0.5)
+
+	dc.SetColor(ColorTextSecondary)
+	dc.SetFontFace(truetype.NewFace(e.fontRegular, &truetype.Options{Size: FontSizeBody}))
+	dc.DrawStringAnchored("Check out the full post on our profile", centerX, pillY+pillH+40, 0.5, 0.5)
+
+	// Branding, above the bottom of the safe zone
+	dc.SetFontFace(truetype.NewFace(e.fontBold, &truetype.Options{Size: FontSizeFooter}))
+	dc.DrawStringAnchored("GO DAILY", centerX, StoryHeight-StorySafeMargin, 0.5, 0.5)
+}
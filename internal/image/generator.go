@@ -1,6 +1,7 @@
 package image
 
 import (
+	"context"
 	"fmt"
 	"image"
 	"image/png"
@@ -45,7 +46,8 @@ func (g *Generator) Generate(lib *model.Library, outputPath string) error {
 }
 
 // GenerateCarousel creates a set of images for a library and returns their paths.
-func (g *Generator) GenerateCarousel(lib *model.Library, outputDir string) ([]string, error) {
+// It stops between slides once ctx is done.
+func (g *Generator) GenerateCarousel(ctx context.Context, lib *model.Library, outputDir string) ([]string, error) {
 	cards := GenerateStoryboard(lib)
 	var paths []string
 
@@ -54,6 +56,10 @@ func (g *Generator) GenerateCarousel(lib *model.Library, outputDir string) ([]st
 	}
 
 	for i := range cards {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
 		cards[i].Index = i + 1
 		cards[i].TotalSlides = len(cards)
 
@@ -75,6 +81,30 @@ func (g *Generator) GenerateCarousel(lib *model.Library, outputDir string) ([]st
 	return paths, nil
 }
 
+// GenerateStory renders the vertical Story teaser for a library into
+// outputDir and returns its path
+func (g *Generator) GenerateStory(ctx context.Context, lib *model.Library, outputDir string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to ensure output dir: %w", err)
+	}
+
+	img, err := g.engine.RenderStory(StoryCard(lib))
+	if err != nil {
+		return "", fmt.Errorf("failed to render story: %w", err)
+	}
+
+	fullPath := filepath.Join(outputDir, fmt.Sprintf("%s_story.png", sanitizeFilename(lib.Name)))
+	if err := g.saveImage(img, fullPath); err != nil {
+		return "", fmt.Errorf("failed to save story: %w", err)
+	}
+
+	return fullPath, nil
+}
+
 func (g *Generator) saveImage(img image.Image, path string) error {
 	f, err := os.Create(path)
 	if err != nil {
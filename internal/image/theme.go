@@ -13,6 +13,14 @@ const (
 	Padding = 80
 )
 
+// Story dimensions (9:16). Instagram overlays its own UI on roughly the top
+// and bottom StorySafeMargin pixels, so content stays between them.
+const (
+	StoryWidth      = 1080
+	StoryHeight     = 1920
+	StorySafeMargin = 250
+)
+
 // Colors
 var (
 	// Modern Developer Dark Theme
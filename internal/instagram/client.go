@@ -2,22 +2,29 @@ package instagram
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"mime/multipart"
 	"net/http"
 	"net/url"
-	"os"
 	"strings"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/retry"
 )
 
+// DefaultHTTPTimeout bounds a single Graph API request
+const DefaultHTTPTimeout = 30 * time.Second
+
 // Client handles Instagram Graph API interactions
 type Client struct {
 	accessToken string
 	accountID   string
 	graphAPIURL string
 	httpClient  *http.Client
+	retry       RetryPolicy
 }
 
 // NewClient creates a new Instagram API client
@@ -26,13 +33,19 @@ func NewClient(accessToken, accountID, graphAPIURL string) *Client {
 		accessToken: accessToken,
 		accountID:   accountID,
 		graphAPIURL: graphAPIURL,
-		httpClient:  &http.Client{},
+		httpClient:  &http.Client{Timeout: DefaultHTTPTimeout},
+		retry:       DefaultRetryPolicy,
 	}
 }
 
-// UploadImageResponse represents the response from image upload
-type UploadImageResponse struct {
-	ID string `json:"id"`
+// SetTimeout sets the timeout of a single request; retries get their own
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.httpClient.Timeout = timeout
+}
+
+// SetRetryPolicy replaces the retry policy used for every call
+func (c *Client) SetRetryPolicy(policy RetryPolicy) {
+	c.retry = policy
 }
 
 // CreateMediaResponse represents the response from media creation
@@ -45,173 +58,315 @@ type PublishResponse struct {
 	ID string `json:"id"`
 }
 
-// UploadImage uploads an image to Instagram (Legacy single post)
-func (c *Client) UploadImage(imagePath string) (string, error) {
-	file, err := os.Open(imagePath)
-	if err != nil {
-		return "", fmt.Errorf("failed to open image: %w", err)
-	}
-	defer file.Close()
+// Container status codes reported in status_code
+const (
+	StatusInProgress = "IN_PROGRESS"
+	StatusFinished   = "FINISHED"
+	StatusError      = "ERROR"
+	StatusExpired    = "EXPIRED"
+	StatusPublished  = "PUBLISHED"
+)
 
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
+// ContainerStatus represents the processing status of a media container
+type ContainerStatus struct {
+	ID         string `json:"id"`
+	StatusCode string `json:"status_code"`
+	// Status describes the status code, including the reason for ERROR
+	Status string `json:"status"`
+}
 
-	part, err := writer.CreateFormFile("image", imagePath)
-	if err != nil {
-		return "", err
-	}
+// CreateCarouselItem creates a carousel item container from a public image URL
+func (c *Client) CreateCarouselItem(ctx context.Context, imageURL string) (string, error) {
+	params := url.Values{}
+	params.Set("is_carousel_item", "true")
+	params.Set("image_url", imageURL)
 
-	if _, err := io.Copy(part, file); err != nil {
+	var mediaResp CreateMediaResponse
+	if err := c.postForm(ctx, "create carousel item", c.accountID+"/media", params, &mediaResp); err != nil {
 		return "", err
 	}
 
-	if err := writer.Close(); err != nil {
-		return "", err
-	}
+	return mediaResp.ID, nil
+}
 
+// CreateMedia creates a single image container from a public image URL.
+// The caption is sent as is; the form encoding escapes it.
+func (c *Client) CreateMedia(ctx context.Context, imageURL, caption string) (string, error) {
 	params := url.Values{}
-	params.Set("access_token", c.accessToken)
+	params.Set("image_url", imageURL)
+	params.Set("caption", caption)
 
-	u := fmt.Sprintf("%s/%s/media?%s", c.graphAPIURL, c.accountID, params.Encode())
-	req, err := http.NewRequest("POST", u, body)
-	if err != nil {
+	var mediaResp CreateMediaResponse
+	if err := c.postForm(ctx, "create media", c.accountID+"/media", params, &mediaResp); err != nil {
 		return "", err
 	}
 
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
+	return mediaResp.ID, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("upload failed (status %d): %s", resp.StatusCode, string(bodyBytes))
-	}
+// CreateReel creates a reel container from a public MP4 URL. The reel is
+// also shown in the profile grid.
+func (c *Client) CreateReel(ctx context.Context, videoURL, caption string) (string, error) {
+	params := url.Values{}
+	params.Set("media_type", "REELS")
+	params.Set("video_url", videoURL)
+	params.Set("caption", caption)
+	params.Set("share_to_feed", "true")
 
-	var uploadResp UploadImageResponse
-	if err := json.NewDecoder(resp.Body).Decode(&uploadResp); err != nil {
+	var mediaResp CreateMediaResponse
+	if err := c.postForm(ctx, "create reel", c.accountID+"/media", params, &mediaResp); err != nil {
 		return "", err
 	}
 
-	return uploadResp.ID, nil
+	return mediaResp.ID, nil
 }
 
-// CreateCarouselItem creates a carousel item container from a public image URL
-func (c *Client) CreateCarouselItem(imageURL string) (string, error) {
+// CreateStory creates a Story container from a public image URL. Stories
+// have no caption.
+func (c *Client) CreateStory(ctx context.Context, imageURL string) (string, error) {
 	params := url.Values{}
-	params.Set("is_carousel_item", "true")
+	params.Set("media_type", "STORIES")
 	params.Set("image_url", imageURL)
-	params.Set("access_token", c.accessToken)
 
-	u := fmt.Sprintf("%s/%s/media?%s", c.graphAPIURL, c.accountID, params.Encode())
-
-	resp, err := c.httpClient.Post(u, "application/json", nil)
-	if err != nil {
+	var mediaResp CreateMediaResponse
+	if err := c.postForm(ctx, "create story", c.accountID+"/media", params, &mediaResp); err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("create carousel item failed (status %d): %s", resp.StatusCode, string(bodyBytes))
-	}
+	return mediaResp.ID, nil
+}
 
-	var uploadResp UploadImageResponse
-	if err := json.NewDecoder(resp.Body).Decode(&uploadResp); err != nil {
+// CreateCarouselContainer creates the carousel container with children.
+// The caption is sent as is; the form encoding escapes it.
+func (c *Client) CreateCarouselContainer(ctx context.Context, children []string, caption string) (string, error) {
+	params := url.Values{}
+	params.Set("media_type", "CAROUSEL")
+	params.Set("children", strings.Join(children, ","))
+	params.Set("caption", caption)
+
+	var mediaResp CreateMediaResponse
+	if err := c.postForm(ctx, "create carousel container", c.accountID+"/media", params, &mediaResp); err != nil {
 		return "", err
 	}
 
-	return uploadResp.ID, nil
+	return mediaResp.ID, nil
 }
 
-// CreateMedia creates a single media container
-func (c *Client) CreateMedia(imageURLOrID, caption string) (string, error) {
-	// If the "CreateMedia" was used with IDs (from upload), we use image_url?
-	// That's confusing. But if existing worked...
-	// Standard: image_url=URL
+// PublishMedia publishes a media container (works for both single and carousel).
+// Publishing is not idempotent, so it is never retried blindly: a rate limit
+// means Instagram refused the call and it is retried after a backoff, but
+// after a 5xx or a network error the publish may have gone through with the
+// response lost. Then the container is read back, and the call is only
+// repeated if the container is not PUBLISHED. If it is, the post is the one
+// made since the first attempt; when that is not a single post the error is
+// returned instead of a guess.
+func (c *Client) PublishMedia(ctx context.Context, creationID string) (string, error) {
 	params := url.Values{}
-	params.Set("image_url", imageURLOrID)
-	params.Set("caption", caption)
+	params.Set("creation_id", creationID)
 	params.Set("access_token", c.accessToken)
+	u := fmt.Sprintf("%s/%s/media_publish", c.graphAPIURL, c.accountID)
+
+	started := time.Now()
+	for attempt := 0; ; attempt++ {
+		var publishResp PublishResponse
+		err := c.doOnce(ctx, "POST", "publish", u, "application/x-www-form-urlencoded", []byte(params.Encode()), &publishResp)
+		if err == nil {
+			return publishResp.ID, nil
+		}
+		if attempt >= c.retry.MaxRetries || ctx.Err() != nil || !shouldRetry(err) {
+			return "", err
+		}
+
+		if IsTransient(err) {
+			status, statusErr := c.GetContainerStatus(ctx, creationID)
+			if statusErr != nil {
+				return "", fmt.Errorf("%w (container status unknown: %v)", err, statusErr)
+			}
+			if status.StatusCode == StatusPublished {
+				mediaID, recoverErr := c.mediaPublishedSince(ctx, started)
+				if recoverErr != nil {
+					return "", fmt.Errorf("%w (container %s was published but its post is unknown: %v)", err, creationID, recoverErr)
+				}
+				return mediaID, nil
+			}
+		}
+
+		if err := retry.Sleep(ctx, c.retry.Delay(attempt, err)); err != nil {
+			return "", err
+		}
+	}
+}
+
+// Media is a post on the account
+type Media struct {
+	ID        string `json:"id"`
+	Caption   string `json:"caption"`
+	Timestamp string `json:"timestamp"`
+}
+
+// mediaTimestampFormat is how the Graph API formats Media.Timestamp
+const mediaTimestampFormat = "2006-01-02T15:04:05-0700"
 
-	u := fmt.Sprintf("%s/%s/media?%s", c.graphAPIURL, c.accountID, params.Encode())
+// RecentMedia returns up to limit of the account's posts, newest first
+func (c *Client) RecentMedia(ctx context.Context, limit int) ([]Media, error) {
+	params := url.Values{}
+	params.Set("fields", "id,caption,timestamp")
+	params.Set("limit", fmt.Sprintf("%d", limit))
+
+	var media struct {
+		Data []Media `json:"data"`
+	}
+	if err := c.getJSON(ctx, "get recent media", c.accountID+"/media", params, &media); err != nil {
+		return nil, err
+	}
+	if len(media.Data) > limit {
+		media.Data = media.Data[:limit]
+	}
+
+	return media.Data, nil
+}
 
-	resp, err := c.httpClient.Post(u, "application/json", nil)
+// LatestMediaID returns the ID of the account's most recent post
+func (c *Client) LatestMediaID(ctx context.Context) (string, error) {
+	media, err := c.RecentMedia(ctx, 1)
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("create media failed (status %d): %s", resp.StatusCode, string(bodyBytes))
+	if len(media) == 0 {
+		return "", fmt.Errorf("get recent media: account %s has no media", c.accountID)
 	}
 
-	var mediaResp CreateMediaResponse
-	if err := json.NewDecoder(resp.Body).Decode(&mediaResp); err != nil {
+	return media[0].ID, nil
+}
+
+// mediaPublishedSince returns the ID of the only post made since since. It
+// recovers the ID of a publish whose response was lost; a post made from the
+// app in the meantime makes it ambiguous, and then it fails rather than
+// guess. Timestamps only have seconds, so since is rounded down.
+func (c *Client) mediaPublishedSince(ctx context.Context, since time.Time) (string, error) {
+	media, err := c.RecentMedia(ctx, 2)
+	if err != nil {
 		return "", err
 	}
 
-	return mediaResp.ID, nil
+	since = since.Truncate(time.Second)
+	var ids []string
+	for _, m := range media {
+		posted, err := time.Parse(mediaTimestampFormat, m.Timestamp)
+		if err != nil {
+			return "", fmt.Errorf("media %s has a bad timestamp %q: %w", m.ID, m.Timestamp, err)
+		}
+		if posted.Before(since) {
+			break
+		}
+		ids = append(ids, m.ID)
+	}
+
+	switch len(ids) {
+	case 0:
+		return "", fmt.Errorf("no post since %s", since.UTC().Format(time.RFC3339))
+	case 1:
+		return ids[0], nil
+	default:
+		return "", fmt.Errorf("posts %s were all made since %s", strings.Join(ids, ", "), since.UTC().Format(time.RFC3339))
+	}
 }
 
-// CreateCarouselContainer creates the carousel container with children
-func (c *Client) CreateCarouselContainer(children []string, caption string) (string, error) {
-	childrenStr := strings.Join(children, ",")
-	// media_type=CAROUSEL
+// GetContainerStatus reads the processing status of a media container
+func (c *Client) GetContainerStatus(ctx context.Context, containerID string) (*ContainerStatus, error) {
 	params := url.Values{}
-	params.Set("media_type", "CAROUSEL")
-	params.Set("children", childrenStr)
-	params.Set("caption", caption)
-	params.Set("access_token", c.accessToken)
-
-	u := fmt.Sprintf("%s/%s/media?%s", c.graphAPIURL, c.accountID, params.Encode())
+	params.Set("fields", "id,status_code,status")
 
-	resp, err := c.httpClient.Post(u, "application/json", nil)
-	if err != nil {
-		return "", err
+	var status ContainerStatus
+	if err := c.getJSON(ctx, "get container status", containerID, params, &status); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("create carousel container failed (status %d): %s", resp.StatusCode, string(bodyBytes))
+	return &status, nil
+}
+
+// WaitForContainer polls a container every interval until Instagram has
+// finished processing it. It fails when the container reports ERROR or
+// EXPIRED, or when it is still in progress after timeout.
+func (c *Client) WaitForContainer(ctx context.Context, containerID string, timeout, interval time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		status, err := c.GetContainerStatus(ctx, containerID)
+		if err != nil {
+			return err
+		}
+
+		switch status.StatusCode {
+		case StatusFinished, StatusPublished:
+			return nil
+		case StatusError, StatusExpired:
+			return &ContainerError{ContainerID: containerID, StatusCode: status.StatusCode, Status: status.Status}
+		}
+
+		if time.Now().Add(interval).After(deadline) {
+			return fmt.Errorf("container %s still %s after %s", containerID, status.StatusCode, timeout)
+		}
+		if err := retry.Sleep(ctx, interval); err != nil {
+			return err
+		}
 	}
+}
 
-	var mediaResp CreateMediaResponse
-	if err := json.NewDecoder(resp.Body).Decode(&mediaResp); err != nil {
-		return "", err
+// postForm sends params and the access token as a form-encoded POST body.
+// Captions can be up to 2,200 characters, too long to trust to a query string.
+func (c *Client) postForm(ctx context.Context, op, path string, params url.Values, out interface{}) error {
+	params.Set("access_token", c.accessToken)
+	u := fmt.Sprintf("%s/%s", c.graphAPIURL, path)
+
+	return c.do(ctx, "POST", op, u, "application/x-www-form-urlencoded", []byte(params.Encode()), out)
+}
+
+// getJSON sends a GET with params in the query string. The client's access
+// token is added unless params already carry one or the client has none.
+func (c *Client) getJSON(ctx context.Context, op, path string, params url.Values, out interface{}) error {
+	if params.Get("access_token") == "" && c.accessToken != "" {
+		params.Set("access_token", c.accessToken)
 	}
+	u := fmt.Sprintf("%s/%s?%s", c.graphAPIURL, path, params.Encode())
 
-	return mediaResp.ID, nil
+	return c.do(ctx, "GET", op, u, "", nil, out)
 }
 
-// PublishMedia publishes a media container (works for both single and carousel)
-func (c *Client) PublishMedia(creationID string) (string, error) {
-	params := url.Values{}
-	params.Set("creation_id", creationID)
-	params.Set("access_token", c.accessToken)
+// do sends a request and decodes the JSON response into out.
+// Transient and rate limit errors are retried according to the retry policy;
+// any other error is returned straight away. It is used for GETs and
+// container creation only, where a repeat is harmless: at worst it leaves an
+// unused container behind, which expires after a day.
+func (c *Client) do(ctx context.Context, method, op, u, contentType string, body []byte, out interface{}) error {
+	return retry.Do(ctx, c.retry, shouldRetry, func() error {
+		return c.doOnce(ctx, method, op, u, contentType, body, out)
+	})
+}
 
-	u := fmt.Sprintf("%s/%s/media_publish?%s", c.graphAPIURL, c.accountID, params.Encode())
+func (c *Client) doOnce(ctx context.Context, method, op, u, contentType string, body []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
 
-	resp, err := c.httpClient.Post(u, "application/json", nil)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", err
+		// The query string of a GET carries tokens and secrets
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = strings.SplitN(urlErr.URL, "?", 2)[0]
+		}
+		return fmt.Errorf("%s failed: %w", op, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("publish failed (status %d): %s", resp.StatusCode, string(bodyBytes))
-	}
-
-	var publishResp PublishResponse
-	if err := json.NewDecoder(resp.Body).Decode(&publishResp); err != nil {
-		return "", err
+		return parseGraphError(op, resp.StatusCode, bodyBytes)
 	}
 
-	return publishResp.ID, nil
+	return json.NewDecoder(resp.Body).Decode(out)
 }
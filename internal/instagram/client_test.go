@@ -0,0 +1,310 @@
+package instagram
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/fakegraph"
+)
+
+// recorder wraps the fake Graph API to count requests, fail some of them
+// before they reach the fake, slow others down and act before others
+type recorder struct {
+	handler http.Handler
+	before  func(key string)
+
+	mu       sync.Mutex
+	requests map[string]int
+	served   map[string]int
+	failures map[string]int
+	delay    func(r *http.Request) time.Duration
+}
+
+// requestKey names a request by method and last path segment, e.g.
+// "POST media_publish"; container status reads are "GET status"
+func requestKey(r *http.Request) string {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	last := parts[len(parts)-1]
+	if r.Method == http.MethodGet && last != "media" {
+		last = "status"
+	}
+	return r.Method + " " + last
+}
+
+func (rec *recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := requestKey(r)
+
+	rec.mu.Lock()
+	rec.requests[key]++
+	fail := rec.failures[key] > 0
+	if fail {
+		rec.failures[key]--
+	}
+	rec.mu.Unlock()
+
+	if rec.before != nil {
+		rec.before(key)
+	}
+	if fail {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"error":{"message":"Please retry your request later.","type":"OAuthException","code":2}}`))
+		return
+	}
+
+	if rec.delay != nil {
+		select {
+		case <-time.After(rec.delay(r)):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	rec.mu.Lock()
+	rec.served[key]++
+	rec.mu.Unlock()
+	rec.handler.ServeHTTP(w, r)
+}
+
+// count returns how many requests were received for key
+func (rec *recorder) count(key string) int {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.requests[key]
+}
+
+// servedCount returns how many requests for key reached the fake
+func (rec *recorder) servedCount(key string) int {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.served[key]
+}
+
+var testRetryPolicy = RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+// newTestClient starts the fake Graph API and returns a client for it with
+// fast retries
+func newTestClient(t *testing.T, opts fakegraph.Options) (*Client, *fakegraph.Server, *recorder) {
+	t.Helper()
+
+	fake := fakegraph.NewServer(opts)
+	rec := &recorder{handler: fake, requests: make(map[string]int), served: make(map[string]int), failures: make(map[string]int)}
+	server := httptest.NewServer(rec)
+	t.Cleanup(server.Close)
+
+	client := NewClient("test-token", "1", server.URL)
+	client.SetRetryPolicy(testRetryPolicy)
+	return client, fake, rec
+}
+
+func TestWaitForContainerFinishesAfterPolls(t *testing.T) {
+	client, _, rec := newTestClient(t, fakegraph.Options{ProcessingPolls: 3})
+	ctx := context.Background()
+
+	id, err := client.CreateCarouselItem(ctx, "https://example.com/a.png")
+	if err != nil {
+		t.Fatalf("CreateCarouselItem: %v", err)
+	}
+	if err := client.WaitForContainer(ctx, id, time.Second, time.Millisecond); err != nil {
+		t.Fatalf("WaitForContainer: %v", err)
+	}
+
+	if got := rec.count("GET status"); got != 4 {
+		t.Errorf("status polls = %d, want 4 (3 in progress, then finished)", got)
+	}
+}
+
+func TestWaitForContainerReportsFailure(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       fakegraph.Options
+		statusCode string
+		reason     string
+	}{
+		{"error", fakegraph.Options{FailImageURL: "broken"}, StatusError, "Media download has failed"},
+		{"expired", fakegraph.Options{ExpireImageURL: "broken"}, StatusExpired, "not published within 24 hours"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _, _ := newTestClient(t, tt.opts)
+			ctx := context.Background()
+
+			id, err := client.CreateCarouselItem(ctx, "https://example.com/broken.png")
+			if err != nil {
+				t.Fatalf("CreateCarouselItem: %v", err)
+			}
+			err = client.WaitForContainer(ctx, id, time.Second, time.Millisecond)
+
+			var containerErr *ContainerError
+			if !errors.As(err, &containerErr) {
+				t.Fatalf("WaitForContainer error = %v, want a ContainerError", err)
+			}
+			if containerErr.StatusCode != tt.statusCode {
+				t.Errorf("status code = %s, want %s", containerErr.StatusCode, tt.statusCode)
+			}
+			if !strings.Contains(err.Error(), tt.reason) {
+				t.Errorf("error %q does not give the reason %q", err, tt.reason)
+			}
+		})
+	}
+}
+
+func TestWaitForContainerTimesOut(t *testing.T) {
+	client, _, _ := newTestClient(t, fakegraph.Options{ProcessingPolls: 1000})
+	ctx := context.Background()
+
+	id, err := client.CreateCarouselItem(ctx, "https://example.com/a.png")
+	if err != nil {
+		t.Fatalf("CreateCarouselItem: %v", err)
+	}
+
+	err = client.WaitForContainer(ctx, id, 20*time.Millisecond, 5*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "still IN_PROGRESS") {
+		t.Fatalf("WaitForContainer error = %v, want a timeout while IN_PROGRESS", err)
+	}
+}
+
+func TestContainerCreationRetriesTransientErrors(t *testing.T) {
+	client, _, rec := newTestClient(t, fakegraph.Options{TransientFailures: 2})
+
+	if _, err := client.CreateCarouselItem(context.Background(), "https://example.com/a.png"); err != nil {
+		t.Fatalf("CreateCarouselItem: %v", err)
+	}
+	if got := rec.count("POST media"); got != 3 {
+		t.Errorf("create attempts = %d, want 3", got)
+	}
+}
+
+func TestContainerCreationGivesUpAfterMaxRetries(t *testing.T) {
+	client, _, rec := newTestClient(t, fakegraph.Options{TransientFailures: 10})
+
+	_, err := client.CreateCarouselItem(context.Background(), "https://example.com/a.png")
+	if !IsTransient(err) {
+		t.Fatalf("CreateCarouselItem error = %v, want a transient error", err)
+	}
+	if got := rec.count("POST media"); got != testRetryPolicy.MaxRetries+1 {
+		t.Errorf("create attempts = %d, want %d", got, testRetryPolicy.MaxRetries+1)
+	}
+}
+
+func TestAuthErrorsAreNotRetried(t *testing.T) {
+	client, _, rec := newTestClient(t, fakegraph.Options{})
+	client.accessToken = ""
+
+	_, err := client.CreateCarouselItem(context.Background(), "https://example.com/a.png")
+	if !IsAuthError(err) {
+		t.Fatalf("CreateCarouselItem error = %v, want an auth error", err)
+	}
+	if got := rec.count("POST media"); got != 1 {
+		t.Errorf("create attempts = %d, want 1", got)
+	}
+}
+
+// finishedContainer creates a single image container and waits for it
+func finishedContainer(t *testing.T, client *Client) string {
+	t.Helper()
+	ctx := context.Background()
+
+	id, err := client.CreateMedia(ctx, "https://example.com/a.png", "caption")
+	if err != nil {
+		t.Fatalf("CreateMedia: %v", err)
+	}
+	if err := client.WaitForContainer(ctx, id, time.Second, time.Millisecond); err != nil {
+		t.Fatalf("WaitForContainer: %v", err)
+	}
+	return id
+}
+
+func TestPublishMediaRetriesWhenNotPublished(t *testing.T) {
+	client, fake, rec := newTestClient(t, fakegraph.Options{})
+	id := finishedContainer(t, client)
+	rec.failures["POST media_publish"] = 1
+
+	postID, err := client.PublishMedia(context.Background(), id)
+	if err != nil {
+		t.Fatalf("PublishMedia: %v", err)
+	}
+	if postID == "" {
+		t.Error("PublishMedia returned no post ID")
+	}
+	if got := rec.count("POST media_publish"); got != 2 {
+		t.Errorf("publish attempts = %d, want 2", got)
+	}
+	if got := len(fake.Published()); got != 1 {
+		t.Errorf("published %d times, want once", got)
+	}
+}
+
+func TestPublishMediaDoesNotRepublishAfterLostResponse(t *testing.T) {
+	client, fake, rec := newTestClient(t, fakegraph.Options{LostPublishes: 1})
+	id := finishedContainer(t, client)
+	earlier := fake.PostElsewhere("posted from the app", time.Now().Add(-time.Hour))
+
+	postID, err := client.PublishMedia(context.Background(), id)
+	if err != nil {
+		t.Fatalf("PublishMedia: %v", err)
+	}
+	if got := rec.count("POST media_publish"); got != 1 {
+		t.Errorf("publish attempts = %d, want 1", got)
+	}
+	if got := len(fake.Published()); got != 1 {
+		t.Errorf("published %d times, want once", got)
+	}
+
+	latest, err := client.LatestMediaID(context.Background())
+	if err != nil {
+		t.Fatalf("LatestMediaID: %v", err)
+	}
+	if postID != latest || postID == earlier {
+		t.Errorf("post ID = %s, want the published media %s", postID, latest)
+	}
+}
+
+func TestPublishMediaDoesNotGuessAfterLostResponse(t *testing.T) {
+	client, fake, rec := newTestClient(t, fakegraph.Options{LostPublishes: 1})
+	id := finishedContainer(t, client)
+
+	// Someone posts from the app while the publish response is lost
+	var foreign string
+	rec.before = func(key string) {
+		if key == "GET status" && foreign == "" {
+			foreign = fake.PostElsewhere("posted from the app", time.Now())
+		}
+	}
+
+	postID, err := client.PublishMedia(context.Background(), id)
+	if err == nil {
+		t.Fatalf("PublishMedia = %s, want an error instead of guessing between it and %s", postID, foreign)
+	}
+	if !IsTransient(err) || !strings.Contains(err.Error(), "was published but its post is unknown") {
+		t.Errorf("PublishMedia error = %v, want the lost response explained", err)
+	}
+	if got := rec.count("POST media_publish"); got != 1 {
+		t.Errorf("publish attempts = %d, want 1", got)
+	}
+	if got := len(fake.Published()); got != 1 {
+		t.Errorf("published %d times, want once", got)
+	}
+}
+
+func TestPublishMediaDoesNotRetryAuthErrors(t *testing.T) {
+	client, fake, rec := newTestClient(t, fakegraph.Options{})
+	id := finishedContainer(t, client)
+	client.accessToken = ""
+
+	if _, err := client.PublishMedia(context.Background(), id); !IsAuthError(err) {
+		t.Fatalf("PublishMedia error = %v, want an auth error", err)
+	}
+	if got := rec.count("POST media_publish"); got != 1 {
+		t.Errorf("publish attempts = %d, want 1", got)
+	}
+	if got := len(fake.Published()); got != 0 {
+		t.Errorf("published %d times, want never", got)
+	}
+}
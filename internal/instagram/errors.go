@@ -0,0 +1,143 @@
+package instagram
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/nitin737/GoAutoPosts/internal/retry"
+)
+
+// Graph API error codes, see
+// https://developers.facebook.com/docs/graph-api/guides/error-handling
+const (
+	codeUnknown            = 1
+	codeServiceUnavailable = 2
+	codeAppRateLimit       = 4
+	codeUserRateLimit      = 17
+	codePermissionDenied   = 10
+	codePageRateLimit      = 32
+	codeCustomRateLimit    = 613
+	codeAccessToken        = 190
+	codeIGRateLimit        = 80002
+)
+
+// GraphError is an error returned by the Graph API, parsed from its
+// {"error": {...}} envelope
+type GraphError struct {
+	// Op names the client call that failed, e.g. "publish"
+	Op         string `json:"-"`
+	StatusCode int    `json:"-"`
+
+	Message     string `json:"message"`
+	Type        string `json:"type"`
+	Code        int    `json:"code"`
+	Subcode     int    `json:"error_subcode"`
+	FBTraceID   string `json:"fbtrace_id"`
+	IsTransient bool   `json:"is_transient"`
+
+	// Body is the raw response body, kept for responses without an envelope
+	Body string `json:"-"`
+}
+
+func (e *GraphError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s failed (status %d): %s", e.Op, e.StatusCode, e.Body)
+	}
+	return fmt.Sprintf("%s failed (status %d, code %d, subcode %d, type %s, fbtrace_id %s): %s",
+		e.Op, e.StatusCode, e.Code, e.Subcode, e.Type, e.FBTraceID, e.Message)
+}
+
+// parseGraphError builds a GraphError from a non-200 response body
+func parseGraphError(op string, statusCode int, body []byte) *GraphError {
+	var envelope struct {
+		Error *GraphError `json:"error"`
+	}
+
+	graphErr := &GraphError{}
+	if err := json.Unmarshal(body, &envelope); err == nil && envelope.Error != nil {
+		graphErr = envelope.Error
+	}
+
+	graphErr.Op = op
+	graphErr.StatusCode = statusCode
+	graphErr.Body = strings.TrimSpace(string(body))
+	return graphErr
+}
+
+// RateLimited reports whether the Graph API refused the call for exceeding
+// a rate limit
+func (e *GraphError) RateLimited() bool {
+	switch e.Code {
+	case codeAppRateLimit, codeUserRateLimit, codePageRateLimit, codeCustomRateLimit, codeIGRateLimit:
+		return true
+	}
+	return e.StatusCode == http.StatusTooManyRequests
+}
+
+// IsRateLimited reports whether err is a Graph API rate limit error
+func IsRateLimited(err error) bool {
+	var graphErr *GraphError
+	return errors.As(err, &graphErr) && graphErr.RateLimited()
+}
+
+// IsAuthError reports whether err means the access token is invalid, expired
+// or lacks a permission. Retrying will not help.
+func IsAuthError(err error) bool {
+	var graphErr *GraphError
+	if !errors.As(err, &graphErr) {
+		return false
+	}
+
+	if graphErr.Code == codeAccessToken || graphErr.Code == codePermissionDenied {
+		return true
+	}
+	if graphErr.Code >= 200 && graphErr.Code <= 299 {
+		// 2xx codes are permission errors
+		return true
+	}
+	return graphErr.StatusCode == http.StatusUnauthorized
+}
+
+// IsTransient reports whether err is a temporary failure worth retrying: a
+// network error, a 5xx response or an error the Graph API marks as transient
+func IsTransient(err error) bool {
+	var graphErr *GraphError
+	if errors.As(err, &graphErr) {
+		if graphErr.IsTransient {
+			return true
+		}
+		if graphErr.Code == codeUnknown || graphErr.Code == codeServiceUnavailable {
+			return true
+		}
+		return graphErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	return retry.IsNetworkError(err)
+}
+
+// ContainerError means Instagram could not process a media container
+type ContainerError struct {
+	ContainerID string
+	StatusCode  string
+	Status      string
+}
+
+func (e *ContainerError) Error() string {
+	if e.Status == "" {
+		return fmt.Sprintf("container %s is %s", e.ContainerID, e.StatusCode)
+	}
+	return fmt.Sprintf("container %s is %s: %s", e.ContainerID, e.StatusCode, e.Status)
+}
+
+// IsContainerFailed reports whether err means a container ended in ERROR or
+// EXPIRED. It can never be published and has to be created again.
+func IsContainerFailed(err error) bool {
+	var containerErr *ContainerError
+	if !errors.As(err, &containerErr) {
+		return false
+	}
+	return containerErr.StatusCode == StatusError || containerErr.StatusCode == StatusExpired
+}
@@ -0,0 +1,124 @@
+package instagram
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nitin737/GoAutoPosts/internal/hosting"
+	"github.com/nitin737/GoAutoPosts/internal/platform"
+)
+
+// Containers are the media containers of a post. They are kept so a failed
+// publish can be retried without uploading and creating them again.
+type Containers struct {
+	ContainerID string
+	ChildIDs    []string
+}
+
+// Platform publishes posts to Instagram. Instagram downloads media from
+// public URLs, so the post's media is uploaded to a media host first.
+type Platform struct {
+	publisher  *Publisher
+	uploads    *hosting.Uploads
+	containers Containers
+	onChange   func(ctx context.Context, containers Containers) error
+}
+
+// NewPlatform creates an Instagram platform that uploads through uploads
+func NewPlatform(publisher *Publisher, uploads *hosting.Uploads) *Platform {
+	return &Platform{
+		publisher: publisher,
+		uploads:   uploads,
+	}
+}
+
+// SetContainers resumes from the containers of an earlier attempt, if
+// containers has an ID, and registers onChange to be called with the new
+// containers once they are ready. When the earlier containers expired or
+// failed, onChange is first called with empty Containers before they are
+// created again.
+func (p *Platform) SetContainers(containers Containers, onChange func(ctx context.Context, containers Containers) error) {
+	p.containers = containers
+	p.onChange = onChange
+}
+
+// Name returns platform.Instagram
+func (p *Platform) Name() string {
+	return platform.Instagram
+}
+
+// Publish posts a reel when the post has a video, a single image when it has
+// one slide, since a carousel needs at least two items, and a carousel
+// otherwise. Containers of an earlier attempt are published if they are
+// still usable; ones that expired, after 24 hours, or ended in ERROR are
+// dropped and created again.
+func (p *Platform) Publish(ctx context.Context, post platform.Post) (platform.RemoteID, error) {
+	if p.containers.ContainerID != "" {
+		postID, err := p.publisher.Publish(ctx, p.containers.ContainerID)
+		if err == nil {
+			return platform.RemoteID(postID), nil
+		}
+		if !IsContainerFailed(err) {
+			return "", err
+		}
+
+		p.containers = Containers{}
+		if err := p.changed(ctx); err != nil {
+			return "", err
+		}
+	}
+
+	containers, err := p.createContainers(ctx, post)
+	if err != nil {
+		return "", fmt.Errorf("failed to create Instagram containers: %w", err)
+	}
+	p.containers = containers
+	if err := p.changed(ctx); err != nil {
+		return "", err
+	}
+
+	postID, err := p.publisher.Publish(ctx, p.containers.ContainerID)
+	if err != nil {
+		return "", err
+	}
+	return platform.RemoteID(postID), nil
+}
+
+// changed reports the current containers to onChange
+func (p *Platform) changed(ctx context.Context) error {
+	if p.onChange == nil {
+		return nil
+	}
+	return p.onChange(ctx, p.containers)
+}
+
+// PublishStory uploads the image at path and publishes it as a Story
+func (p *Platform) PublishStory(ctx context.Context, path string) (string, error) {
+	urls, err := p.uploads.Upload(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	return p.publisher.PublishStory(ctx, urls[0])
+}
+
+func (p *Platform) createContainers(ctx context.Context, post platform.Post) (Containers, error) {
+	mediaPaths := post.Slides
+	if post.Video != "" {
+		mediaPaths = []string{post.Video}
+	}
+	mediaURLs, err := p.uploads.Upload(ctx, mediaPaths...)
+	if err != nil {
+		return Containers{}, err
+	}
+
+	var containers Containers
+	switch {
+	case post.Video != "":
+		containers.ContainerID, err = p.publisher.CreateReelContainer(ctx, mediaURLs[0], post.Caption)
+	case len(mediaURLs) == 1:
+		containers.ContainerID, err = p.publisher.CreateImageContainer(ctx, mediaURLs[0], post.Caption)
+	default:
+		containers.ChildIDs, containers.ContainerID, err = p.publisher.CreateCarouselContainers(ctx, mediaURLs, post.Caption)
+	}
+	return containers, err
+}
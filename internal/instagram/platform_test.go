@@ -0,0 +1,198 @@
+package instagram
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/nitin737/GoAutoPosts/internal/fakegraph"
+	"github.com/nitin737/GoAutoPosts/internal/hosting"
+	"github.com/nitin737/GoAutoPosts/internal/platform"
+)
+
+// stubHost pretends to publish files under https://media.example.com
+type stubHost struct{}
+
+func (stubHost) Upload(ctx context.Context, path string) (string, error) {
+	return "https://media.example.com/" + filepath.Base(path), nil
+}
+
+func (stubHost) Delete(ctx context.Context, url string) error { return nil }
+
+func (stubHost) Close() error { return nil }
+
+// resumedPlatform returns a platform resuming from a container created for
+// imageURL, and the containers it reports through onChange
+func resumedPlatform(t *testing.T, client *Client, imageURL string) (*Platform, *[]Containers) {
+	t.Helper()
+
+	oldID, err := client.CreateMedia(context.Background(), imageURL, "caption")
+	if err != nil {
+		t.Fatalf("CreateMedia: %v", err)
+	}
+
+	var changes []Containers
+	ig := NewPlatform(newTestPublisher(client, 1), hosting.NewUploads(stubHost{}))
+	ig.SetContainers(Containers{ContainerID: oldID}, func(ctx context.Context, containers Containers) error {
+		changes = append(changes, containers)
+		return nil
+	})
+	return ig, &changes
+}
+
+func TestResumeRecreatesFailedContainers(t *testing.T) {
+	tests := []struct {
+		name string
+		opts fakegraph.Options
+	}{
+		{"expired", fakegraph.Options{ExpireImageURL: "stale"}},
+		{"error", fakegraph.Options{FailImageURL: "stale"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, fake, _ := newTestClient(t, tt.opts)
+			ig, changes := resumedPlatform(t, client, "https://example.com/stale.png")
+
+			post := platform.Post{Caption: "caption", Slides: []string{"/tmp/slide-1.png"}}
+			postID, err := ig.Publish(context.Background(), post)
+			if err != nil {
+				t.Fatalf("Publish: %v", err)
+			}
+			if postID == "" {
+				t.Error("Publish returned no post ID")
+			}
+
+			if len(*changes) != 2 || (*changes)[0].ContainerID != "" || (*changes)[1].ContainerID == "" {
+				t.Fatalf("containers changed to %v, want cleared and then recreated", *changes)
+			}
+			newID := (*changes)[1].ContainerID
+			if mediaURL, _, _ := fake.Container(newID); mediaURL != "https://media.example.com/slide-1.png" {
+				t.Errorf("recreated container has media %s, want the uploaded slide", mediaURL)
+			}
+			if published := fake.Published(); len(published) != 1 || published[0] != newID {
+				t.Errorf("published %v, want only the recreated container %s", published, newID)
+			}
+		})
+	}
+}
+
+func TestResumeReusesFinishedContainers(t *testing.T) {
+	client, fake, rec := newTestClient(t, fakegraph.Options{})
+	ig, changes := resumedPlatform(t, client, "https://example.com/a.png")
+
+	post := platform.Post{Caption: "caption", Slides: []string{"/tmp/slide-1.png"}}
+	if _, err := ig.Publish(context.Background(), post); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	if len(*changes) != 0 {
+		t.Errorf("containers changed to %v, want the earlier ones kept", *changes)
+	}
+	if got := rec.count("POST media"); got != 1 {
+		t.Errorf("%d containers were created, want only the earlier one", got)
+	}
+	if got := len(fake.Published()); got != 1 {
+		t.Errorf("published %d times, want once", got)
+	}
+}
+
+func TestPublishSingleSlideAsImage(t *testing.T) {
+	client, fake, rec := newTestClient(t, fakegraph.Options{ProcessingPolls: 1})
+	ig := NewPlatform(newTestPublisher(client, 1), hosting.NewUploads(stubHost{}))
+	var changes []Containers
+	ig.SetContainers(Containers{}, func(ctx context.Context, containers Containers) error {
+		changes = append(changes, containers)
+		return nil
+	})
+
+	post := platform.Post{Caption: "caption", Slides: []string{"/tmp/slide-1.png"}}
+	if _, err := ig.Publish(context.Background(), post); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	// A carousel needs two items, so the slide is posted as a plain image
+	if got := rec.count("POST media"); got != 1 {
+		t.Errorf("%d containers were created, want a single image container", got)
+	}
+	if len(changes) != 1 || changes[0].ContainerID == "" || len(changes[0].ChildIDs) != 0 {
+		t.Fatalf("containers changed to %v, want one image container without children", changes)
+	}
+	mediaURL, children, _ := fake.Container(changes[0].ContainerID)
+	if mediaURL != "https://media.example.com/slide-1.png" || len(children) != 0 {
+		t.Errorf("container has media %q and children %v, want the uploaded slide alone", mediaURL, children)
+	}
+	if published := fake.Published(); len(published) != 1 || published[0] != changes[0].ContainerID {
+		t.Errorf("published %v, want the image container %s", published, changes[0].ContainerID)
+	}
+}
+
+func TestResumeReusesSavedCarousel(t *testing.T) {
+	client, fake, rec := newTestClient(t, fakegraph.Options{})
+	urls := []string{"https://example.com/a.png", "https://example.com/b.png"}
+	childIDs, carouselID, err := newTestPublisher(client, 1).CreateCarouselContainers(context.Background(), urls, "caption")
+	if err != nil {
+		t.Fatalf("CreateCarouselContainers: %v", err)
+	}
+	created := rec.count("POST media")
+
+	// A later run resumes from the containers saved with the post
+	ig := NewPlatform(newTestPublisher(client, 1), hosting.NewUploads(stubHost{}))
+	var changes []Containers
+	ig.SetContainers(Containers{ContainerID: carouselID, ChildIDs: childIDs}, func(ctx context.Context, containers Containers) error {
+		changes = append(changes, containers)
+		return nil
+	})
+
+	post := platform.Post{Caption: "caption", Slides: []string{"/tmp/slide-1.png", "/tmp/slide-2.png"}}
+	postID, err := ig.Publish(context.Background(), post)
+	if err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if postID == "" {
+		t.Error("Publish returned no post ID")
+	}
+
+	if got := rec.count("POST media") - created; got != 0 {
+		t.Errorf("%d containers were created on resume, want none", got)
+	}
+	if len(changes) != 0 {
+		t.Errorf("containers changed to %v, want the saved ones kept", changes)
+	}
+	if published := fake.Published(); len(published) != 1 || published[0] != carouselID {
+		t.Errorf("published %v, want the saved carousel %s", published, carouselID)
+	}
+}
+
+func TestPublishStory(t *testing.T) {
+	client, fake, _ := newTestClient(t, fakegraph.Options{ProcessingPolls: 1})
+	ig := NewPlatform(newTestPublisher(client, 1), hosting.NewUploads(stubHost{}))
+	ctx := context.Background()
+
+	post := platform.Post{Caption: "caption", Slides: []string{"/tmp/slide-1.png", "/tmp/slide-2.png"}}
+	if _, err := ig.Publish(ctx, post); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	storyID, err := ig.PublishStory(ctx, "/tmp/gin_story.png")
+	if err != nil {
+		t.Fatalf("PublishStory: %v", err)
+	}
+	if storyID == "" {
+		t.Error("PublishStory returned no media ID")
+	}
+
+	published := fake.Published()
+	if len(published) != 2 {
+		t.Fatalf("published %v, want the feed post and then the story", published)
+	}
+	if got := fake.MediaType(published[0]); got != "CAROUSEL" {
+		t.Errorf("feed post media type = %q, want CAROUSEL", got)
+	}
+	story := published[1]
+	if got := fake.MediaType(story); got != "STORIES" {
+		t.Errorf("story media type = %q, want STORIES", got)
+	}
+	if mediaURL, _, _ := fake.Container(story); mediaURL != "https://media.example.com/gin_story.png" {
+		t.Errorf("story has media %s, want the uploaded story image", mediaURL)
+	}
+}
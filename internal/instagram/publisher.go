@@ -1,70 +1,218 @@
 package instagram
 
 import (
+	"context"
 	"fmt"
-	"net/url"
+	"sync"
+	"time"
 )
 
+// Default container polling, see SetContainerPolling
+const (
+	DefaultPollTimeout  = 2 * time.Minute
+	DefaultPollInterval = 2 * time.Second
+	// DefaultReelPollTimeout is longer because Instagram transcodes videos
+	DefaultReelPollTimeout = 5 * time.Minute
+)
+
+// DefaultConcurrency is how many carousel items are created at once
+const DefaultConcurrency = 4
+
 // Publisher handles the complete publishing workflow
 type Publisher struct {
-	client *Client
+	client          *Client
+	pollTimeout     time.Duration
+	reelPollTimeout time.Duration
+	pollInterval    time.Duration
+	concurrency     int
 }
 
 // NewPublisher creates a new publisher
 func NewPublisher(client *Client) *Publisher {
 	return &Publisher{
-		client: client,
+		client:          client,
+		pollTimeout:     DefaultPollTimeout,
+		reelPollTimeout: DefaultReelPollTimeout,
+		pollInterval:    DefaultPollInterval,
+		concurrency:     DefaultConcurrency,
 	}
 }
 
-// PublishPost publishes a complete single post to Instagram
-func (p *Publisher) PublishPost(imagePath, caption string) (string, error) {
-	// Step 1: Upload image
-	imageID, err := p.client.UploadImage(imagePath)
+// SetConcurrency sets how many carousel items are created and polled at once
+func (p *Publisher) SetConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+	p.concurrency = n
+}
+
+// SetContainerPolling sets how long to wait for Instagram to process each
+// container and how often to check on it
+func (p *Publisher) SetContainerPolling(timeout, interval time.Duration) {
+	p.pollTimeout = timeout
+	p.pollInterval = interval
+}
+
+// SetReelPollTimeout sets how long to wait for Instagram to process a reel
+func (p *Publisher) SetReelPollTimeout(timeout time.Duration) {
+	p.reelPollTimeout = timeout
+}
+
+// PublishPost publishes a single image post from a public image URL
+func (p *Publisher) PublishPost(ctx context.Context, imageURL, caption string) (string, error) {
+	creationID, err := p.CreateImageContainer(ctx, imageURL, caption)
 	if err != nil {
-		return "", fmt.Errorf("failed to upload image: %w", err)
+		return "", err
 	}
 
-	// Step 2: Create media container with caption
-	encodedCaption := url.QueryEscape(caption)
-	creationID, err := p.client.CreateMedia(imageID, encodedCaption)
+	return p.Publish(ctx, creationID)
+}
+
+// CreateImageContainer creates the container for a single image post and
+// waits until Instagram has processed it
+func (p *Publisher) CreateImageContainer(ctx context.Context, imageURL, caption string) (string, error) {
+	creationID, err := p.client.CreateMedia(ctx, imageURL, caption)
 	if err != nil {
 		return "", fmt.Errorf("failed to create media: %w", err)
 	}
+	if err := p.client.WaitForContainer(ctx, creationID, p.pollTimeout, p.pollInterval); err != nil {
+		return "", fmt.Errorf("media container not ready: %w", err)
+	}
 
-	// Step 3: Publish media
-	postID, err := p.client.PublishMedia(creationID)
+	return creationID, nil
+}
+
+// PublishReel publishes a reel from a public MP4 URL
+func (p *Publisher) PublishReel(ctx context.Context, videoURL, caption string) (string, error) {
+	creationID, err := p.CreateReelContainer(ctx, videoURL, caption)
 	if err != nil {
-		return "", fmt.Errorf("failed to publish media: %w", err)
+		return "", err
 	}
 
-	return postID, nil
+	return p.Publish(ctx, creationID)
+}
+
+// CreateReelContainer creates a reel container and waits until Instagram has
+// finished processing the video
+func (p *Publisher) CreateReelContainer(ctx context.Context, videoURL, caption string) (string, error) {
+	creationID, err := p.client.CreateReel(ctx, videoURL, caption)
+	if err != nil {
+		return "", fmt.Errorf("failed to create reel: %w", err)
+	}
+	if err := p.client.WaitForContainer(ctx, creationID, p.reelPollTimeout, p.pollInterval); err != nil {
+		return "", fmt.Errorf("reel container not ready: %w", err)
+	}
+
+	return creationID, nil
+}
+
+// PublishStory publishes a Story from a public 1080x1920 image URL
+func (p *Publisher) PublishStory(ctx context.Context, imageURL string) (string, error) {
+	creationID, err := p.client.CreateStory(ctx, imageURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to create story: %w", err)
+	}
+
+	return p.Publish(ctx, creationID)
 }
 
 // PublishCarousel publishes a carousel post to Instagram
-func (p *Publisher) PublishCarousel(imageURLs []string, caption string) (string, error) {
+func (p *Publisher) PublishCarousel(ctx context.Context, imageURLs []string, caption string) (string, error) {
+	_, creationID, err := p.CreateCarouselContainers(ctx, imageURLs, caption)
+	if err != nil {
+		return "", err
+	}
+
+	return p.Publish(ctx, creationID)
+}
+
+// CreateCarouselContainers creates a container per image and the carousel
+// container holding them, returning the child IDs and the carousel ID
+func (p *Publisher) CreateCarouselContainers(ctx context.Context, imageURLs []string, caption string) ([]string, string, error) {
 	// Step 1: Create media containers for all carousel items
-	var childrenIDs []string
-	for _, rawURL := range imageURLs {
-		id, err := p.client.CreateCarouselItem(rawURL)
-		if err != nil {
-			return "", fmt.Errorf("failed to create carousel item %s: %w", rawURL, err)
-		}
-		childrenIDs = append(childrenIDs, id)
+	childrenIDs, err := p.createCarouselItems(ctx, imageURLs)
+	if err != nil {
+		return nil, "", err
 	}
 
 	// Step 2: Create Carousel container
-	encodedCaption := url.QueryEscape(caption)
-	creationID, err := p.client.CreateCarouselContainer(childrenIDs, encodedCaption)
+	creationID, err := p.client.CreateCarouselContainer(ctx, childrenIDs, caption)
 	if err != nil {
-		return "", fmt.Errorf("failed to create carousel container: %w", err)
+		return childrenIDs, "", fmt.Errorf("failed to create carousel container: %w", err)
+	}
+	if err := p.client.WaitForContainer(ctx, creationID, p.pollTimeout, p.pollInterval); err != nil {
+		return childrenIDs, "", fmt.Errorf("carousel container not ready: %w", err)
+	}
+
+	return childrenIDs, creationID, nil
+}
+
+// Publish publishes a container created earlier, once Instagram has finished
+// processing it
+func (p *Publisher) Publish(ctx context.Context, creationID string) (string, error) {
+	if err := p.client.WaitForContainer(ctx, creationID, p.pollTimeout, p.pollInterval); err != nil {
+		return "", fmt.Errorf("media container not ready: %w", err)
 	}
 
-	// Step 3: Publish
-	postID, err := p.client.PublishMedia(creationID)
+	postID, err := p.client.PublishMedia(ctx, creationID)
 	if err != nil {
-		return "", fmt.Errorf("failed to publish carousel: %w", err)
+		return "", fmt.Errorf("failed to publish media: %w", err)
 	}
 
 	return postID, nil
 }
+
+// createCarouselItems creates and polls the carousel items with at most
+// p.concurrency in flight. The IDs keep the order of imageURLs. The first
+// failure cancels the items still running and is returned.
+func (p *Publisher) createCarouselItems(ctx context.Context, imageURLs []string) ([]string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ids := make([]string, len(imageURLs))
+	sem := make(chan struct{}, p.concurrency)
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	for i, rawURL := range imageURLs {
+		wg.Add(1)
+		go func(i int, rawURL string) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				fail(ctx.Err())
+				return
+			}
+
+			id, err := p.client.CreateCarouselItem(ctx, rawURL)
+			if err != nil {
+				fail(fmt.Errorf("failed to create carousel item %s: %w", rawURL, err))
+				return
+			}
+			if err := p.client.WaitForContainer(ctx, id, p.pollTimeout, p.pollInterval); err != nil {
+				fail(fmt.Errorf("carousel item %s not ready: %w", rawURL, err))
+				return
+			}
+			ids[i] = id
+		}(i, rawURL)
+	}
+
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return ids, nil
+}
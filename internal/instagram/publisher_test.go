@@ -0,0 +1,102 @@
+package instagram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/fakegraph"
+)
+
+// slowURLs delays container creation for image URLs containing "slow"
+func slowURLs(d time.Duration) func(r *http.Request) time.Duration {
+	return func(r *http.Request) time.Duration {
+		if strings.Contains(r.FormValue("image_url"), "slow") {
+			return d
+		}
+		return 0
+	}
+}
+
+func newTestPublisher(client *Client, concurrency int) *Publisher {
+	p := NewPublisher(client)
+	p.SetContainerPolling(time.Second, time.Millisecond)
+	p.SetConcurrency(concurrency)
+	return p
+}
+
+func TestCarouselItemsKeepOrder(t *testing.T) {
+	client, fake, rec := newTestClient(t, fakegraph.Options{ProcessingPolls: 1})
+	rec.delay = slowURLs(30 * time.Millisecond)
+	p := newTestPublisher(client, 4)
+
+	urls := []string{
+		"https://example.com/slow-1.png",
+		"https://example.com/fast-2.png",
+		"https://example.com/slow-3.png",
+		"https://example.com/fast-4.png",
+	}
+	childIDs, carouselID, err := p.CreateCarouselContainers(context.Background(), urls, "caption")
+	if err != nil {
+		t.Fatalf("CreateCarouselContainers: %v", err)
+	}
+
+	for i, id := range childIDs {
+		mediaURL, _, ok := fake.Container(id)
+		if !ok || mediaURL != urls[i] {
+			t.Errorf("child %d is %s (%s), want %s", i, id, mediaURL, urls[i])
+		}
+	}
+
+	_, children, _ := fake.Container(carouselID)
+	if fmt.Sprint(children) != fmt.Sprint(childIDs) {
+		t.Errorf("carousel children = %v, want %v", children, childIDs)
+	}
+}
+
+func TestCarouselItemsCancelOnFirstFailure(t *testing.T) {
+	client, _, rec := newTestClient(t, fakegraph.Options{FailImageURL: "broken"})
+	rec.delay = slowURLs(5 * time.Second)
+
+	urls := []string{"https://example.com/broken.png"}
+	for i := 1; i <= 5; i++ {
+		urls = append(urls, fmt.Sprintf("https://example.com/slow-%d.png", i))
+	}
+	p := newTestPublisher(client, len(urls))
+
+	start := time.Now()
+	_, _, err := p.CreateCarouselContainers(context.Background(), urls, "caption")
+
+	var containerErr *ContainerError
+	if !errors.As(err, &containerErr) || containerErr.StatusCode != StatusError {
+		t.Fatalf("CreateCarouselContainers error = %v, want the broken item's ERROR", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("took %s; the slow items were not cancelled", elapsed)
+	}
+	if got := rec.servedCount("POST media"); got != 1 {
+		t.Errorf("%d items were created, want only the broken one", got)
+	}
+}
+
+func TestCarouselItemsDoNotStartAfterFailure(t *testing.T) {
+	client, _, rec := newTestClient(t, fakegraph.Options{FailImageURL: "broken"})
+	p := newTestPublisher(client, 1)
+
+	urls := []string{"https://example.com/broken.png"}
+	for i := 1; i <= 5; i++ {
+		urls = append(urls, fmt.Sprintf("https://example.com/broken-%d.png", i))
+	}
+
+	if _, _, err := p.CreateCarouselContainers(context.Background(), urls, "caption"); err == nil {
+		t.Fatal("CreateCarouselContainers succeeded with broken items")
+	}
+	// One at a time, the first item to fail stops every item still waiting
+	if got := rec.count("POST media"); got != 1 {
+		t.Errorf("%d items were started, want 1", got)
+	}
+}
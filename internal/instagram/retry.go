@@ -0,0 +1,17 @@
+package instagram
+
+import "github.com/nitin737/GoAutoPosts/internal/retry"
+
+// RetryPolicy controls how transient and rate limit errors are retried
+type RetryPolicy = retry.Policy
+
+// DefaultRetryPolicy retries three times, waiting about 1s, 2s and 4s
+var DefaultRetryPolicy = retry.DefaultPolicy
+
+// shouldRetry reports whether a failed attempt is worth repeating
+func shouldRetry(err error) bool {
+	if IsAuthError(err) {
+		return false
+	}
+	return IsTransient(err) || IsRateLimited(err)
+}
@@ -0,0 +1,401 @@
+package instagram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/model"
+)
+
+// Default token windows, see SetWindows
+const (
+	DefaultTokenRefreshBefore = 10 * 24 * time.Hour
+	DefaultTokenWarnBefore    = 7 * 24 * time.Hour
+)
+
+// RequiredScopes are the Facebook Login permissions the publisher needs
+var RequiredScopes = []string{"instagram_basic", "instagram_content_publish"}
+
+// ErrNoToken means neither the token store nor the environment has a token
+var ErrNoToken = errors.New("no access token")
+
+// TokenResponse is the result of a token exchange
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	// ExpiresIn is in seconds; zero means the token does not expire
+	ExpiresIn int64 `json:"expires_in"`
+}
+
+// TokenInfo is what debug_token reports about a token
+type TokenInfo struct {
+	AppID   string `json:"app_id"`
+	UserID  string `json:"user_id"`
+	IsValid bool   `json:"is_valid"`
+	// ExpiresAt is a Unix time; zero means the token does not expire
+	ExpiresAt int64    `json:"expires_at"`
+	Scopes    []string `json:"scopes"`
+	Error     *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// SetAccessToken replaces the token used for every call
+func (c *Client) SetAccessToken(token string) {
+	c.accessToken = token
+}
+
+// ExchangeToken swaps a user token for a long-lived one. Exchanging a
+// long-lived token before it expires extends it.
+func (c *Client) ExchangeToken(ctx context.Context, appID, appSecret, token string) (*TokenResponse, error) {
+	params := url.Values{}
+	params.Set("grant_type", "fb_exchange_token")
+	params.Set("client_id", appID)
+	params.Set("client_secret", appSecret)
+	params.Set("fb_exchange_token", token)
+
+	var resp TokenResponse
+	if err := c.getJSON(ctx, "exchange token", "oauth/access_token", params, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// ExchangeInstagramToken swaps a short-lived Instagram Login token for a
+// long-lived one
+func (c *Client) ExchangeInstagramToken(ctx context.Context, appSecret, token string) (*TokenResponse, error) {
+	params := url.Values{}
+	params.Set("grant_type", "ig_exchange_token")
+	params.Set("client_secret", appSecret)
+	params.Set("access_token", token)
+
+	var resp TokenResponse
+	if err := c.getJSON(ctx, "exchange token", "access_token", params, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// RefreshInstagramToken extends a long-lived Instagram Login token that is
+// at least a day old
+func (c *Client) RefreshInstagramToken(ctx context.Context, token string) (*TokenResponse, error) {
+	params := url.Values{}
+	params.Set("grant_type", "ig_refresh_token")
+	params.Set("access_token", token)
+
+	var resp TokenResponse
+	if err := c.getJSON(ctx, "refresh token", "refresh_access_token", params, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// CheckToken reads the account behind the client's token, which fails
+// when the token is invalid
+func (c *Client) CheckToken(ctx context.Context) error {
+	params := url.Values{}
+	params.Set("fields", "id")
+
+	var resp struct {
+		ID string `json:"id"`
+	}
+	return c.getJSON(ctx, "check token", "me", params, &resp)
+}
+
+// DebugToken inspects a token with the app's credentials
+func (c *Client) DebugToken(ctx context.Context, appID, appSecret, token string) (*TokenInfo, error) {
+	params := url.Values{}
+	params.Set("input_token", token)
+	params.Set("access_token", appID+"|"+appSecret)
+
+	var resp struct {
+		Data TokenInfo `json:"data"`
+	}
+	if err := c.getJSON(ctx, "debug token", "debug_token", params, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp.Data, nil
+}
+
+// GrantedPermissions lists the permissions granted to the client's token
+func (c *Client) GrantedPermissions(ctx context.Context) ([]string, error) {
+	var resp struct {
+		Data []struct {
+			Permission string `json:"permission"`
+			Status     string `json:"status"`
+		} `json:"data"`
+	}
+	if err := c.getJSON(ctx, "get permissions", "me/permissions", url.Values{}, &resp); err != nil {
+		return nil, err
+	}
+
+	var granted []string
+	for _, p := range resp.Data {
+		if p.Status == "granted" {
+			granted = append(granted, p.Permission)
+		}
+	}
+	return granted, nil
+}
+
+// TokenStore persists the access token between runs
+type TokenStore interface {
+	// Load returns the stored token, or nil when none is stored
+	Load(ctx context.Context) (*model.AccessToken, error)
+	Save(ctx context.Context, token *model.AccessToken) error
+}
+
+// TokenStatus is the outcome of TokenManager.Ensure
+type TokenStatus struct {
+	Token     *model.AccessToken
+	Refreshed bool
+	// ExpiresSoon is set when the token expires inside the warning window
+	ExpiresSoon bool
+	// RefreshErr is a failed refresh; the current token is still valid
+	RefreshErr error
+}
+
+// TokenManager keeps a valid, long-lived access token in a TokenStore.
+//
+// Facebook Login tokens (graph.facebook.com) are exchanged, refreshed and
+// inspected with the app ID and secret; without them they are only checked
+// for their permissions. Instagram Login tokens (graph.instagram.com) refresh
+// themselves, need the app secret only for the first exchange, and cannot
+// list their permissions, so they are only checked for validity.
+type TokenManager struct {
+	client         *Client
+	store          TokenStore
+	appID          string
+	appSecret      string
+	instagramLogin bool
+	refreshBefore  time.Duration
+	warnBefore     time.Duration
+}
+
+// NewTokenManager creates a token manager that sets the token it settles on
+// on client
+func NewTokenManager(client *Client, store TokenStore, appID, appSecret string) *TokenManager {
+	return &TokenManager{
+		client:        client,
+		store:         store,
+		appID:         appID,
+		appSecret:     appSecret,
+		refreshBefore: DefaultTokenRefreshBefore,
+		warnBefore:    DefaultTokenWarnBefore,
+	}
+}
+
+// SetWindows sets how long before expiry a token is refreshed and how long
+// before expiry Ensure reports it as expiring soon
+func (m *TokenManager) SetWindows(refreshBefore, warnBefore time.Duration) {
+	m.refreshBefore = refreshBefore
+	m.warnBefore = warnBefore
+}
+
+// SetInstagramLogin selects the Instagram Login token endpoints
+func (m *TokenManager) SetInstagramLogin(enabled bool) {
+	m.instagramLogin = enabled
+}
+
+// CanRefresh reports whether tokens can be refreshed: always for Instagram
+// Login, only with the app ID and secret for Facebook Login
+func (m *TokenManager) CanRefresh() bool {
+	return m.instagramLogin || m.hasAppCredentials()
+}
+
+func (m *TokenManager) hasAppCredentials() bool {
+	return m.appID != "" && m.appSecret != ""
+}
+
+// Ensure settles on the token for this run and checks that it is valid and
+// has RequiredScopes. seed is the token from the environment. The stored
+// token is used unless seed replaces it: when nothing is stored, the stored
+// token has expired, or it was not created from seed because the
+// environment has a new token since. When the token chosen fails the check,
+// the other one is tried. A seed is exchanged for a long-lived token when
+// possible, and a token close to expiry is refreshed.
+func (m *TokenManager) Ensure(ctx context.Context, seed string, now time.Time) (*TokenStatus, error) {
+	stored, seeded, err := m.current(ctx, seed, now)
+	if err != nil {
+		return nil, err
+	}
+
+	token, fallback := stored, seeded
+	if stored == nil || (seeded != nil && (stored.Expired(now) || stored.SeedHash != seeded.SeedHash)) {
+		token, fallback = seeded, stored
+	}
+	if err := m.inspect(ctx, token); err != nil {
+		if fallback == nil || m.inspect(ctx, fallback) != nil {
+			return nil, err
+		}
+		token = fallback
+	}
+
+	status := &TokenStatus{Token: token}
+	if m.CanRefresh() && (token == seeded || token.ExpiresWithin(m.refreshBefore, now)) {
+		refreshed, err := m.refresh(ctx, token, now)
+		if err != nil {
+			status.RefreshErr = err
+		} else {
+			status.Token = refreshed
+			status.Refreshed = true
+		}
+	}
+
+	if !status.Refreshed {
+		if err := m.store.Save(ctx, status.Token); err != nil {
+			return nil, fmt.Errorf("failed to save access token: %w", err)
+		}
+	}
+
+	status.ExpiresSoon = status.Token.ExpiresWithin(m.warnBefore, now)
+	m.client.SetAccessToken(status.Token.Token)
+	return status, nil
+}
+
+// Exchange swaps a short-lived token for a long-lived one, checks it and
+// stores it
+func (m *TokenManager) Exchange(ctx context.Context, token string, now time.Time) (*model.AccessToken, error) {
+	return m.exchange(ctx, token, model.TokenHash(token), now)
+}
+
+// exchange swaps token for a long-lived one that keeps seedHash
+func (m *TokenManager) exchange(ctx context.Context, token, seedHash string, now time.Time) (*model.AccessToken, error) {
+	var resp *TokenResponse
+	var err error
+	switch {
+	case m.instagramLogin && m.appSecret != "":
+		resp, err = m.client.ExchangeInstagramToken(ctx, m.appSecret, token)
+	case !m.instagramLogin && m.hasAppCredentials():
+		resp, err = m.client.ExchangeToken(ctx, m.appID, m.appSecret, token)
+	default:
+		return nil, fmt.Errorf("exchanging tokens needs the app secret (and app ID for Facebook Login)")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange access token: %w", err)
+	}
+
+	return m.save(ctx, resp, seedHash, now)
+}
+
+// refresh extends a long-lived token. A token seeded from the environment
+// may still be short-lived; Instagram Login cannot refresh those, so they
+// are exchanged instead.
+func (m *TokenManager) refresh(ctx context.Context, token *model.AccessToken, now time.Time) (*model.AccessToken, error) {
+	if !m.instagramLogin {
+		return m.exchange(ctx, token.Token, token.SeedHash, now)
+	}
+
+	resp, err := m.client.RefreshInstagramToken(ctx, token.Token)
+	if err != nil {
+		if m.appSecret == "" {
+			return nil, fmt.Errorf("failed to refresh access token: %w", err)
+		}
+		return m.exchange(ctx, token.Token, token.SeedHash, now)
+	}
+
+	return m.save(ctx, resp, token.SeedHash, now)
+}
+
+// save checks a token returned by an exchange or refresh and stores it
+func (m *TokenManager) save(ctx context.Context, resp *TokenResponse, seedHash string, now time.Time) (*model.AccessToken, error) {
+	token := &model.AccessToken{Token: resp.AccessToken, RefreshedAt: now, SeedHash: seedHash}
+	if err := m.inspect(ctx, token); err != nil {
+		return nil, err
+	}
+	if !token.Expires() && resp.ExpiresIn > 0 {
+		token.ExpiresAt = now.Add(time.Duration(resp.ExpiresIn) * time.Second)
+	}
+
+	if err := m.store.Save(ctx, token); err != nil {
+		return nil, fmt.Errorf("failed to save access token: %w", err)
+	}
+	return token, nil
+}
+
+// current returns the stored token and the seed, when it is set and not the
+// stored token itself; at least one of them
+func (m *TokenManager) current(ctx context.Context, seed string, now time.Time) (stored, seeded *model.AccessToken, err error) {
+	stored, err = m.store.Load(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load access token: %w", err)
+	}
+
+	if seed != "" && (stored == nil || seed != stored.Token) {
+		seeded = &model.AccessToken{Token: seed, RefreshedAt: now, SeedHash: model.TokenHash(seed)}
+	}
+	if stored == nil && seeded == nil {
+		return nil, nil, ErrNoToken
+	}
+
+	return stored, seeded, nil
+}
+
+// inspect fills in what the Graph API reports about token and fails when
+// it is invalid or lacks RequiredScopes. The expiry is only known from
+// debug_token; otherwise the stored one is kept.
+func (m *TokenManager) inspect(ctx context.Context, token *model.AccessToken) error {
+	switch {
+	case m.instagramLogin:
+		m.client.SetAccessToken(token.Token)
+		if err := m.client.CheckToken(ctx); err != nil {
+			return fmt.Errorf("failed to check access token: %w", err)
+		}
+		return nil
+
+	case m.hasAppCredentials():
+		info, err := m.client.DebugToken(ctx, m.appID, m.appSecret, token.Token)
+		if err != nil {
+			return fmt.Errorf("failed to check access token: %w", err)
+		}
+		if !info.IsValid {
+			reason := "rejected by the Graph API"
+			if info.Error != nil {
+				reason = info.Error.Message
+			}
+			return fmt.Errorf("access token is invalid: %s", reason)
+		}
+		token.Scopes = info.Scopes
+		token.ExpiresAt = time.Time{}
+		if info.ExpiresAt > 0 {
+			token.ExpiresAt = time.Unix(info.ExpiresAt, 0).UTC()
+		}
+
+	default:
+		m.client.SetAccessToken(token.Token)
+		scopes, err := m.client.GrantedPermissions(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to check access token: %w", err)
+		}
+		token.Scopes = scopes
+	}
+
+	if missing := missingScopes(token.Scopes); len(missing) > 0 {
+		return fmt.Errorf("access token lacks permissions: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func missingScopes(granted []string) []string {
+	have := make(map[string]bool, len(granted))
+	for _, scope := range granted {
+		have[scope] = true
+	}
+
+	var missing []string
+	for _, scope := range RequiredScopes {
+		if !have[scope] {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
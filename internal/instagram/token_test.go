@@ -0,0 +1,255 @@
+package instagram
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/fakegraph"
+	"github.com/nitin737/GoAutoPosts/internal/model"
+)
+
+// memoryTokens is a TokenStore kept in memory
+type memoryTokens struct {
+	token *model.AccessToken
+}
+
+func (s *memoryTokens) Load(ctx context.Context) (*model.AccessToken, error) {
+	if s.token == nil {
+		return nil, nil
+	}
+	token := *s.token
+	return &token, nil
+}
+
+func (s *memoryTokens) Save(ctx context.Context, token *model.AccessToken) error {
+	saved := *token
+	s.token = &saved
+	return nil
+}
+
+func TestEnsure(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	envHash := model.TokenHash("env-token")
+
+	tests := []struct {
+		name           string
+		opts           fakegraph.Options
+		appID          string
+		appSecret      string
+		instagramLogin bool
+		stored         *model.AccessToken
+		seed           string
+
+		// wantToken is the token settled on; "fake-long-lived" matches any
+		// token the fake issued
+		wantToken       string
+		wantSeedHash    string
+		wantRefreshed   bool
+		wantExpiresSoon bool
+		wantErr         string
+	}{
+		{
+			name:          "seed exchanged",
+			appID:         "app",
+			appSecret:     "secret",
+			seed:          "env-token",
+			wantToken:     "fake-long-lived",
+			wantSeedHash:  envHash,
+			wantRefreshed: true,
+		},
+		{
+			name:         "stored token kept",
+			appID:        "app",
+			appSecret:    "secret",
+			stored:       &model.AccessToken{Token: "stored-token", ExpiresAt: now.Add(50 * day), SeedHash: envHash},
+			seed:         "env-token",
+			wantToken:    "stored-token",
+			wantSeedHash: envHash,
+		},
+		{
+			name:          "refreshed inside the window",
+			opts:          fakegraph.Options{TokenLifetime: 8 * day},
+			appID:         "app",
+			appSecret:     "secret",
+			stored:        &model.AccessToken{Token: "stored-token", ExpiresAt: now.Add(50 * day), SeedHash: envHash},
+			seed:          "env-token",
+			wantToken:     "fake-long-lived",
+			wantSeedHash:  envHash,
+			wantRefreshed: true,
+		},
+		{
+			name:            "expires inside the warning window",
+			stored:          &model.AccessToken{Token: "stored-token", ExpiresAt: now.Add(5 * day), SeedHash: envHash},
+			seed:            "env-token",
+			wantToken:       "stored-token",
+			wantSeedHash:    envHash,
+			wantExpiresSoon: true,
+		},
+		{
+			name:      "expired without a seed",
+			opts:      fakegraph.Options{ExpiredToken: "stored-token"},
+			appID:     "app",
+			appSecret: "secret",
+			stored:    &model.AccessToken{Token: "stored-token", ExpiresAt: now.Add(-time.Hour)},
+			wantErr:   "access token is invalid: Session has expired",
+		},
+		{
+			name:          "expired and replaced by the seed",
+			opts:          fakegraph.Options{ExpiredToken: "stored-token"},
+			appID:         "app",
+			appSecret:     "secret",
+			stored:        &model.AccessToken{Token: "stored-token", ExpiresAt: now.Add(-time.Hour), SeedHash: envHash},
+			seed:          "env-token",
+			wantToken:     "fake-long-lived",
+			wantSeedHash:  envHash,
+			wantRefreshed: true,
+		},
+		{
+			name:         "new seed replaces a valid stored token",
+			stored:       &model.AccessToken{Token: "stored-token", ExpiresAt: now.Add(50 * day), SeedHash: model.TokenHash("old-env-token")},
+			seed:         "env-token",
+			wantToken:    "env-token",
+			wantSeedHash: envHash,
+		},
+		{
+			name:         "stored token rejected, seed used",
+			opts:         fakegraph.Options{ExpiredToken: "stored-token"},
+			stored:       &model.AccessToken{Token: "stored-token", SeedHash: envHash},
+			seed:         "env-token",
+			wantToken:    "env-token",
+			wantSeedHash: envHash,
+		},
+		{
+			name:         "seed rejected, stored token used",
+			opts:         fakegraph.Options{ExpiredToken: "env-token"},
+			stored:       &model.AccessToken{Token: "stored-token", SeedHash: model.TokenHash("exchanged-by-hand")},
+			seed:         "env-token",
+			wantToken:    "stored-token",
+			wantSeedHash: model.TokenHash("exchanged-by-hand"),
+		},
+		{
+			name:      "missing permission",
+			opts:      fakegraph.Options{MissingScope: "instagram_content_publish"},
+			appID:     "app",
+			appSecret: "secret",
+			seed:      "env-token",
+			wantErr:   "access token lacks permissions: instagram_content_publish",
+		},
+		{
+			name:    "missing permission without app credentials",
+			opts:    fakegraph.Options{MissingScope: "instagram_basic"},
+			stored:  &model.AccessToken{Token: "stored-token"},
+			wantErr: "access token lacks permissions: instagram_basic",
+		},
+		{
+			name:    "no token",
+			wantErr: ErrNoToken.Error(),
+		},
+		{
+			name:           "instagram login refreshed inside the window",
+			instagramLogin: true,
+			stored:         &model.AccessToken{Token: "stored-token", ExpiresAt: now.Add(5 * day), SeedHash: envHash},
+			seed:           "env-token",
+			wantToken:      "fake-long-lived",
+			wantSeedHash:   envHash,
+			wantRefreshed:  true,
+		},
+		{
+			name:           "instagram login short-lived seed exchanged",
+			appSecret:      "secret",
+			instagramLogin: true,
+			seed:           "short-lived-token",
+			wantToken:      "fake-long-lived",
+			wantSeedHash:   model.TokenHash("short-lived-token"),
+			wantRefreshed:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _, _ := newTestClient(t, tt.opts)
+			store := &memoryTokens{token: tt.stored}
+			m := NewTokenManager(client, store, tt.appID, tt.appSecret)
+			m.SetInstagramLogin(tt.instagramLogin)
+
+			status, err := m.Ensure(context.Background(), tt.seed, now)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Ensure error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Ensure: %v", err)
+			}
+
+			token := status.Token
+			if !strings.HasPrefix(token.Token, tt.wantToken) {
+				t.Errorf("token = %s, want %s", token.Token, tt.wantToken)
+			}
+			if token.SeedHash != tt.wantSeedHash {
+				t.Errorf("seed hash = %q, want %q", token.SeedHash, tt.wantSeedHash)
+			}
+			if status.Refreshed != tt.wantRefreshed || status.ExpiresSoon != tt.wantExpiresSoon || status.RefreshErr != nil {
+				t.Errorf("refreshed = %v (%v), expires soon = %v, want %v, %v",
+					status.Refreshed, status.RefreshErr, status.ExpiresSoon, tt.wantRefreshed, tt.wantExpiresSoon)
+			}
+			if store.token == nil || store.token.Token != token.Token || store.token.SeedHash != token.SeedHash {
+				t.Errorf("stored token = %+v, want %s", store.token, token.Token)
+			}
+			if client.accessToken != token.Token {
+				t.Errorf("client token = %s, want %s", client.accessToken, token.Token)
+			}
+		})
+	}
+}
+
+func TestEnsureKeepsTokenWhenRefreshFails(t *testing.T) {
+	now := time.Now()
+	client, _, _ := newTestClient(t, fakegraph.Options{})
+	stored := &model.AccessToken{Token: "short-lived-token", ExpiresAt: now.Add(3 * 24 * time.Hour)}
+	m := NewTokenManager(client, &memoryTokens{token: stored}, "", "")
+	m.SetInstagramLogin(true)
+
+	status, err := m.Ensure(context.Background(), "", now)
+	if err != nil {
+		t.Fatalf("Ensure: %v", err)
+	}
+	if status.Refreshed || status.RefreshErr == nil || status.Token.Token != "short-lived-token" || !status.ExpiresSoon {
+		t.Errorf("status = %+v, want the stored token kept, expiring soon, with the refresh error", status)
+	}
+}
+
+func TestExchange(t *testing.T) {
+	now := time.Now()
+	client, _, _ := newTestClient(t, fakegraph.Options{TokenLifetime: 30 * 24 * time.Hour})
+	store := &memoryTokens{}
+
+	if _, err := NewTokenManager(client, store, "", "").Exchange(context.Background(), "short-lived-token", now); err == nil {
+		t.Error("Exchange without app credentials succeeded")
+	}
+
+	token, err := NewTokenManager(client, store, "app", "secret").Exchange(context.Background(), "short-lived-token", now)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if !strings.HasPrefix(token.Token, "fake-long-lived") || token.SeedHash != model.TokenHash("short-lived-token") {
+		t.Errorf("token = %+v, want a long-lived token seeded from the short-lived one", token)
+	}
+	if d := token.ExpiresAt.Sub(now); d < 29*24*time.Hour || d > 31*24*time.Hour {
+		t.Errorf("token expires in %s, want 30 days", d)
+	}
+	if store.token == nil || store.token.Token != token.Token {
+		t.Errorf("stored token = %+v, want %s", store.token, token.Token)
+	}
+
+	_, err = NewTokenManager(client, store, "app", "secret").Exchange(context.Background(), "", now)
+	var graphErr *GraphError
+	if !errors.As(err, &graphErr) {
+		t.Errorf("Exchange of an empty token = %v, want a Graph API error", err)
+	}
+}
@@ -0,0 +1,258 @@
+// Package mastodon cross-posts to a Mastodon instance through its REST API.
+package mastodon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/retry"
+)
+
+// DefaultHTTPTimeout bounds a single API request
+const DefaultHTTPTimeout = 30 * time.Second
+
+// Client calls the API of one Mastodon instance with a user access token
+type Client struct {
+	baseURL     string
+	accessToken string
+	httpClient  *http.Client
+	retry       retry.Policy
+}
+
+// NewClient creates a client for the instance at baseURL, e.g.
+// https://mastodon.social
+func NewClient(baseURL, accessToken string) *Client {
+	return &Client{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		accessToken: accessToken,
+		httpClient:  &http.Client{Timeout: DefaultHTTPTimeout},
+		retry:       retry.DefaultPolicy,
+	}
+}
+
+// SetTimeout sets the timeout of a single request; retries get their own
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.httpClient.Timeout = timeout
+}
+
+// SetMaxRetries sets how often server errors and rate limits are retried
+func (c *Client) SetMaxRetries(n int) {
+	c.retry.MaxRetries = n
+}
+
+// SetRetryPolicy replaces the retry policy used for every call
+func (c *Client) SetRetryPolicy(policy retry.Policy) {
+	c.retry = policy
+}
+
+// APIError is an error response from the instance
+type APIError struct {
+	// Op names the client call that failed, e.g. "post status"
+	Op         string
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s failed (status %d): %s", e.Op, e.StatusCode, e.Message)
+}
+
+// RateLimited reports whether the instance refused the call for exceeding
+// its rate limit
+func (e *APIError) RateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests
+}
+
+// retryable reports whether a failed request is worth repeating
+func retryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return retry.TemporaryStatus(apiErr.StatusCode)
+	}
+	return retry.IsNetworkError(err)
+}
+
+// Instance is the part of the instance metadata the publisher needs
+type Instance struct {
+	Configuration struct {
+		Statuses struct {
+			MaxCharacters            int `json:"max_characters"`
+			MaxMediaAttachments      int `json:"max_media_attachments"`
+			CharactersReservedPerURL int `json:"characters_reserved_per_url"`
+		} `json:"statuses"`
+	} `json:"configuration"`
+}
+
+// Instance reads the instance's limits
+func (c *Client) Instance(ctx context.Context) (*Instance, error) {
+	var instance Instance
+	if err := c.do(ctx, "get instance", http.MethodGet, "/api/v2/instance", "", nil, nil, &instance); err != nil {
+		return nil, err
+	}
+	return &instance, nil
+}
+
+// Attachment is an uploaded media file. URL is empty while the instance is
+// still processing it.
+type Attachment struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	URL         string `json:"url"`
+	Description string `json:"description"`
+}
+
+// UploadMedia uploads the file at path with description as its alt text
+func (c *Client) UploadMedia(ctx context.Context, path, description string) (*Attachment, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if description != "" {
+		if err := form.WriteField("description", description); err != nil {
+			return nil, err
+		}
+	}
+	part, err := form.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(data); err != nil {
+		return nil, err
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	var attachment Attachment
+	if err := c.do(ctx, "upload media", http.MethodPost, "/api/v2/media", form.FormDataContentType(), body.Bytes(), nil, &attachment); err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// GetMedia reads an uploaded attachment
+func (c *Client) GetMedia(ctx context.Context, id string) (*Attachment, error) {
+	var attachment Attachment
+	if err := c.do(ctx, "get media", http.MethodGet, "/api/v1/media/"+url.PathEscape(id), "", nil, nil, &attachment); err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// WaitForMedia polls an attachment every interval until the instance has
+// processed it, or fails after timeout
+func (c *Client) WaitForMedia(ctx context.Context, id string, timeout, interval time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		attachment, err := c.GetMedia(ctx, id)
+		if err != nil {
+			return err
+		}
+		if attachment.URL != "" {
+			return nil
+		}
+
+		if time.Now().Add(interval).After(deadline) {
+			return fmt.Errorf("media %s still processing after %s", id, timeout)
+		}
+		if err := retry.Sleep(ctx, interval); err != nil {
+			return err
+		}
+	}
+}
+
+// Status is a published post
+type Status struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+}
+
+// PostStatus publishes text with the given attachments. The instance ignores
+// a repeated request with the same idempotency key, so retries cannot post
+// twice.
+func (c *Client) PostStatus(ctx context.Context, text string, mediaIDs []string, visibility, idempotencyKey string) (*Status, error) {
+	params := url.Values{}
+	params.Set("status", text)
+	for _, id := range mediaIDs {
+		params.Add("media_ids[]", id)
+	}
+	if visibility != "" {
+		params.Set("visibility", visibility)
+	}
+
+	header := http.Header{}
+	if idempotencyKey != "" {
+		header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	var status Status
+	if err := c.do(ctx, "post status", http.MethodPost, "/api/v1/statuses", "application/x-www-form-urlencoded", []byte(params.Encode()), header, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// do sends a request and decodes the JSON response into out, retrying
+// server errors and rate limits with exponential backoff
+func (c *Client) do(ctx context.Context, op, method, path, contentType string, body []byte, header http.Header, out interface{}) error {
+	return retry.Do(ctx, c.retry, retryable, func() error {
+		return c.doOnce(ctx, op, method, path, contentType, body, header, out)
+	})
+}
+
+func (c *Client) doOnce(ctx context.Context, op, method, path, contentType string, body []byte, header http.Header, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return parseError(op, resp.StatusCode, data)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s: failed to decode response: %w", op, err)
+	}
+	return nil
+}
+
+// parseError reads the {"error": "..."} body Mastodon sends with failures
+func parseError(op string, statusCode int, body []byte) *APIError {
+	var envelope struct {
+		Error string `json:"error"`
+	}
+	message := strings.TrimSpace(string(body))
+	if err := json.Unmarshal(body, &envelope); err == nil && envelope.Error != "" {
+		message = envelope.Error
+	}
+	return &APIError{Op: op, StatusCode: statusCode, Message: message}
+}
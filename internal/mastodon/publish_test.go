@@ -0,0 +1,193 @@
+package mastodon_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/fakemastodon"
+	"github.com/nitin737/GoAutoPosts/internal/mastodon"
+	"github.com/nitin737/GoAutoPosts/internal/platform"
+	"github.com/nitin737/GoAutoPosts/internal/retry"
+)
+
+// recorder counts requests to the fake instance by method and path, with
+// media reads as "GET /api/v1/media", and can lose the response to the
+// first lost[key] requests after the fake has handled them
+type recorder struct {
+	handler http.Handler
+
+	mu       sync.Mutex
+	requests map[string]int
+	lost     map[string]int
+}
+
+func (rec *recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := r.Method + " " + r.URL.Path
+	if strings.HasPrefix(r.URL.Path, "/api/v1/media/") {
+		key = r.Method + " /api/v1/media"
+	}
+
+	rec.mu.Lock()
+	rec.requests[key]++
+	lose := rec.lost[key] > 0
+	if lose {
+		rec.lost[key]--
+	}
+	rec.mu.Unlock()
+
+	if lose {
+		rec.handler.ServeHTTP(httptest.NewRecorder(), r)
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	rec.handler.ServeHTTP(w, r)
+}
+
+func (rec *recorder) count(key string) int {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.requests[key]
+}
+
+// newTestPublisher starts the fake instance and returns a publisher for it
+// with fast retries and media polling
+func newTestPublisher(t *testing.T, opts fakemastodon.Options) (*mastodon.Publisher, *fakemastodon.Server, *recorder) {
+	t.Helper()
+
+	fake := fakemastodon.NewServer(opts)
+	rec := &recorder{handler: fake, requests: make(map[string]int), lost: make(map[string]int)}
+	server := httptest.NewServer(rec)
+	t.Cleanup(server.Close)
+
+	client := mastodon.NewClient(server.URL, "token")
+	client.SetRetryPolicy(retry.Policy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond})
+	publisher := mastodon.NewPublisher(client, "unlisted")
+	publisher.SetMediaPolling(time.Second, time.Millisecond)
+	return publisher, fake, rec
+}
+
+// testPost returns a post with n slides, each with its alt text
+func testPost(t *testing.T, n int) platform.Post {
+	t.Helper()
+
+	post := platform.Post{
+		ID:      "20260315T090000-gin",
+		Caption: "gin: a HTTP web framework for Go https://github.com/gin-gonic/gin #golang",
+	}
+	dir := t.TempDir()
+	for i := 1; i <= n; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("slide-%d.png", i))
+		if err := os.WriteFile(path, []byte("png"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		post.Slides = append(post.Slides, path)
+		post.AltText = append(post.AltText, fmt.Sprintf("Slide %d of gin", i))
+	}
+	return post
+}
+
+func TestPublish(t *testing.T) {
+	publisher, fake, rec := newTestPublisher(t, fakemastodon.Options{})
+
+	remoteID, err := publisher.Publish(context.Background(), testPost(t, 2))
+	if err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	statuses := fake.Statuses()
+	if len(statuses) != 1 {
+		t.Fatalf("posted %d statuses, want 1", len(statuses))
+	}
+	status := statuses[0]
+	if string(remoteID) != status.ID {
+		t.Errorf("remote ID = %s, want the status ID %s", remoteID, status.ID)
+	}
+	if status.Text != testPost(t, 0).Caption {
+		t.Errorf("status text = %q, want the caption", status.Text)
+	}
+	if got, want := strings.Join(status.AltText, ","), "Slide 1 of gin,Slide 2 of gin"; got != want {
+		t.Errorf("alt text = %s, want %s", got, want)
+	}
+	if got := rec.count("GET /api/v1/media"); got != 0 {
+		t.Errorf("polled media %d times, want none for processed uploads", got)
+	}
+}
+
+func TestPublishKeepsAttachmentLimit(t *testing.T) {
+	publisher, fake, rec := newTestPublisher(t, fakemastodon.Options{})
+
+	if _, err := publisher.Publish(context.Background(), testPost(t, 6)); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	if got := rec.count("POST /api/v2/media"); got != mastodon.DefaultMaxMediaAttachments {
+		t.Errorf("uploaded %d slides, want %d", got, mastodon.DefaultMaxMediaAttachments)
+	}
+	statuses := fake.Statuses()
+	if len(statuses) != 1 {
+		t.Fatalf("posted %d statuses, want 1", len(statuses))
+	}
+	if got, want := strings.Join(statuses[0].AltText, ","), "Slide 1 of gin,Slide 2 of gin,Slide 3 of gin,Slide 4 of gin"; got != want {
+		t.Errorf("alt text = %s, want the first %d slides: %s", got, mastodon.DefaultMaxMediaAttachments, want)
+	}
+}
+
+func TestPublishWaitsForMedia(t *testing.T) {
+	publisher, fake, rec := newTestPublisher(t, fakemastodon.Options{ProcessingPolls: 3})
+
+	if _, err := publisher.Publish(context.Background(), testPost(t, 2)); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	// Each upload is read until it has a URL, which it gets on the third read
+	if got := rec.count("GET /api/v1/media"); got != 6 {
+		t.Errorf("polled media %d times, want 3 per slide", got)
+	}
+	if len(fake.Statuses()) != 1 {
+		t.Errorf("posted %d statuses, want 1", len(fake.Statuses()))
+	}
+}
+
+func TestPublishGivesUpOnSlowMedia(t *testing.T) {
+	publisher, fake, _ := newTestPublisher(t, fakemastodon.Options{ProcessingPolls: 1000})
+	publisher.SetMediaPolling(20*time.Millisecond, time.Millisecond)
+
+	_, err := publisher.Publish(context.Background(), testPost(t, 1))
+	if err == nil || !strings.Contains(err.Error(), "still processing") {
+		t.Fatalf("Publish error = %v, want media still processing", err)
+	}
+	if len(fake.Statuses()) != 0 {
+		t.Errorf("posted %d statuses, want none", len(fake.Statuses()))
+	}
+}
+
+func TestPublishReplaysLostStatus(t *testing.T) {
+	publisher, fake, rec := newTestPublisher(t, fakemastodon.Options{})
+	rec.lost["POST /api/v1/statuses"] = 2
+
+	remoteID, err := publisher.Publish(context.Background(), testPost(t, 1))
+	if err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	// Every attempt reached the instance, but the Idempotency-Key made the
+	// retries return the status the first one posted
+	if got := rec.count("POST /api/v1/statuses"); got != 3 {
+		t.Errorf("status attempts = %d, want 3", got)
+	}
+	statuses := fake.Statuses()
+	if len(statuses) != 1 {
+		t.Fatalf("posted %d statuses, want 1", len(statuses))
+	}
+	if string(remoteID) != statuses[0].ID {
+		t.Errorf("remote ID = %s, want the first status %s", remoteID, statuses[0].ID)
+	}
+}
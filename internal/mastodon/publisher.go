@@ -0,0 +1,187 @@
+package mastodon
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nitin737/GoAutoPosts/internal/platform"
+)
+
+// Limits of a stock Mastodon instance, used when the instance does not
+// report its own
+const (
+	DefaultMaxCharacters       = 500
+	DefaultMaxMediaAttachments = 4
+	DefaultCharactersPerURL    = 23
+)
+
+// Media processing polling defaults
+const (
+	DefaultMediaTimeout  = 2 * time.Minute
+	DefaultMediaInterval = 2 * time.Second
+)
+
+// Publisher posts carousels to Mastodon as a status with the slides attached
+type Publisher struct {
+	client        *Client
+	visibility    string
+	mediaTimeout  time.Duration
+	mediaInterval time.Duration
+}
+
+// NewPublisher creates a publisher posting with the given visibility
+// (public, unlisted, private or direct; empty uses the account default)
+func NewPublisher(client *Client, visibility string) *Publisher {
+	return &Publisher{
+		client:        client,
+		visibility:    visibility,
+		mediaTimeout:  DefaultMediaTimeout,
+		mediaInterval: DefaultMediaInterval,
+	}
+}
+
+// SetMediaPolling sets how long to wait for the instance to process each
+// upload and how often to check
+func (p *Publisher) SetMediaPolling(timeout, interval time.Duration) {
+	p.mediaTimeout = timeout
+	p.mediaInterval = interval
+}
+
+// Name returns platform.Mastodon
+func (p *Publisher) Name() string {
+	return platform.Mastodon
+}
+
+// Publish uploads as many slides as the instance allows per status, each
+// with its alt text, and posts the caption trimmed to the instance's
+// character limit
+func (p *Publisher) Publish(ctx context.Context, post platform.Post) (platform.RemoteID, error) {
+	limits, err := p.limits(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	slides := post.Slides
+	if len(slides) > limits.maxMedia {
+		slides = slides[:limits.maxMedia]
+	}
+
+	var mediaIDs []string
+	for i, path := range slides {
+		var altText string
+		if i < len(post.AltText) {
+			altText = post.AltText[i]
+		}
+
+		attachment, err := p.client.UploadMedia(ctx, path, altText)
+		if err != nil {
+			return "", err
+		}
+		if attachment.URL == "" {
+			if err := p.client.WaitForMedia(ctx, attachment.ID, p.mediaTimeout, p.mediaInterval); err != nil {
+				return "", err
+			}
+		}
+		mediaIDs = append(mediaIDs, attachment.ID)
+	}
+
+	text := FitCaption(post.Caption, limits.maxCharacters, limits.charactersPerURL)
+	status, err := p.client.PostStatus(ctx, text, mediaIDs, p.visibility, post.ID)
+	if err != nil {
+		return "", err
+	}
+	return platform.RemoteID(status.ID), nil
+}
+
+type limits struct {
+	maxCharacters    int
+	maxMedia         int
+	charactersPerURL int
+}
+
+// limits reads the instance's limits, falling back to the stock ones for
+// instances without the v2 instance endpoint
+func (p *Publisher) limits(ctx context.Context) (limits, error) {
+	l := limits{
+		maxCharacters:    DefaultMaxCharacters,
+		maxMedia:         DefaultMaxMediaAttachments,
+		charactersPerURL: DefaultCharactersPerURL,
+	}
+
+	instance, err := p.client.Instance(ctx)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return l, nil
+		}
+		return l, err
+	}
+
+	statuses := instance.Configuration.Statuses
+	if statuses.MaxCharacters > 0 {
+		l.maxCharacters = statuses.MaxCharacters
+	}
+	if statuses.MaxMediaAttachments > 0 {
+		l.maxMedia = statuses.MaxMediaAttachments
+	}
+	if statuses.CharactersReservedPerURL > 0 {
+		l.charactersPerURL = statuses.CharactersReservedPerURL
+	}
+	return l, nil
+}
+
+// urlPattern matches the links Mastodon counts at a fixed length
+var urlPattern = regexp.MustCompile(`https?://\S+`)
+
+// Length counts text the way Mastodon does: every link counts as
+// charactersPerURL characters, whatever its real length
+func Length(text string, charactersPerURL int) int {
+	n := utf8.RuneCountInString(text)
+	for _, link := range urlPattern.FindAllString(text, -1) {
+		n += charactersPerURL - utf8.RuneCountInString(link)
+	}
+	return n
+}
+
+// FitCaption returns caption unchanged if it fits in maxCharacters, and
+// otherwise cuts it at the last word that fits and appends an ellipsis.
+// Words are never split, so links and hashtags stay intact.
+func FitCaption(caption string, maxCharacters, charactersPerURL int) string {
+	caption = strings.TrimSpace(caption)
+	if Length(caption, charactersPerURL) <= maxCharacters {
+		return caption
+	}
+
+	const ellipsis = "…"
+	budget := maxCharacters - utf8.RuneCountInString(ellipsis)
+
+	// Keep the longest prefix ending at a word boundary that fits
+	fitted := ""
+	for i, r := range caption {
+		if r != ' ' && r != '\n' {
+			continue
+		}
+		prefix := strings.TrimRight(caption[:i], " \n")
+		if Length(prefix, charactersPerURL) > budget {
+			break
+		}
+		fitted = prefix
+	}
+
+	if fitted == "" {
+		// A single word longer than the limit; cut it. A link counts longer
+		// than it is, so it can be over the limit with fewer runes than
+		// budget.
+		runes := []rune(caption)
+		if budget < 0 {
+			budget = 0
+		}
+		fitted = string(runes[:min(budget, len(runes))])
+	}
+	return fitted + ellipsis
+}
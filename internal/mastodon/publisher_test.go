@@ -0,0 +1,98 @@
+package mastodon
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLength(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"plain", "hello world", 11},
+		{"multi-byte runes", "héllo wörld ✨", 13},
+		{"short link", "see https://go.dev", 4 + 23},
+		{"long link", "see https://github.com/nitin737/GoAutoPosts/tree/main/internal", 4 + 23},
+		{"two links", "https://a.io and https://b.io", 23 + 5 + 23},
+		{"not a link", "ftp://example.com", 17},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Length(tt.text, 23); got != tt.want {
+				t.Errorf("Length(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFitCaption(t *testing.T) {
+	tests := []struct {
+		name             string
+		caption          string
+		maxCharacters    int
+		charactersPerURL int
+		want             string
+	}{
+		{
+			name:             "fits",
+			caption:          "  short caption \n",
+			maxCharacters:    500,
+			charactersPerURL: 23,
+			want:             "short caption",
+		},
+		{
+			name:             "cut at a word boundary",
+			caption:          "one two three four",
+			maxCharacters:    12,
+			charactersPerURL: 23,
+			want:             "one two…",
+		},
+		{
+			name:             "cut at a line break",
+			caption:          "first line\nsecond line",
+			maxCharacters:    15,
+			charactersPerURL: 23,
+			want:             "first line…",
+		},
+		{
+			name:             "link counts at its fixed length",
+			caption:          "read https://example.com/a/very/long/path/to/the/docs today",
+			maxCharacters:    30,
+			charactersPerURL: 23,
+			want:             "read https://example.com/a/very/long/path/to/the/docs…",
+		},
+		{
+			name:             "link kept whole",
+			caption:          "read https://example.com/docs today",
+			maxCharacters:    20,
+			charactersPerURL: 23,
+			want:             "read…",
+		},
+		{
+			name:             "no spaces",
+			caption:          strings.Repeat("a", 30),
+			maxCharacters:    10,
+			charactersPerURL: 23,
+			want:             strings.Repeat("a", 9) + "…",
+		},
+		{
+			name:             "no spaces, link weighted past its runes",
+			caption:          "https://a.io",
+			maxCharacters:    50,
+			charactersPerURL: 100,
+			want:             "https://a.io…",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FitCaption(tt.caption, tt.maxCharacters, tt.charactersPerURL)
+			if got != tt.want {
+				t.Errorf("FitCaption = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
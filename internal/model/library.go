@@ -1,22 +1,96 @@
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
 
 // Library represents a Go library to be featured
 type Library struct {
-	Name        string   `json:"name"`
-	Description string   `json:"description"`
-	URL         string   `json:"url"`
-	Category    string   `json:"category"`
-	Tags        []string `json:"tags"`
-	Stars       int      `json:"stars,omitempty"`
-	Author      string   `json:"author,omitempty"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	URL         string    `json:"url"`
+	Category    string    `json:"category"`
+	Tags        []string  `json:"tags"`
+	Stars       int       `json:"stars,omitempty"`
+	Author      string    `json:"author,omitempty"`
+	Enabled     bool      `json:"enabled"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
-// PostedLibrary represents a library that has been posted
-type PostedLibrary struct {
-	Library   Library   `json:"library"`
-	PostedAt  time.Time `json:"posted_at"`
-	PostID    string    `json:"post_id,omitempty"`
-	ImagePath string    `json:"image_path,omitempty"`
+// libraryJSON keeps catalog files tidy: libraries are enabled unless marked
+// otherwise, and zero timestamps are left out
+type libraryJSON struct {
+	*libraryAlias
+	Enabled   *bool      `json:"enabled,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+type libraryAlias Library
+
+// MarshalJSON implements json.Marshaler
+func (l Library) MarshalJSON() ([]byte, error) {
+	out := libraryJSON{libraryAlias: (*libraryAlias)(&l)}
+	if !l.Enabled {
+		out.Enabled = &l.Enabled
+	}
+	if !l.CreatedAt.IsZero() {
+		out.CreatedAt = &l.CreatedAt
+	}
+	if !l.UpdatedAt.IsZero() {
+		out.UpdatedAt = &l.UpdatedAt
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (l *Library) UnmarshalJSON(data []byte) error {
+	in := libraryJSON{libraryAlias: (*libraryAlias)(l)}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	l.Enabled = in.Enabled == nil || *in.Enabled
+	if in.CreatedAt != nil {
+		l.CreatedAt = *in.CreatedAt
+	}
+	if in.UpdatedAt != nil {
+		l.UpdatedAt = *in.UpdatedAt
+	}
+	return nil
+}
+
+// HasTag reports whether the library carries tag, ignoring case
+func (l *Library) HasTag(tag string) bool {
+	for _, t := range l.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that the library has every field needed to build a post
+func (l *Library) Validate() error {
+	var errs []error
+	if l.Name == "" {
+		errs = append(errs, errors.New("missing name"))
+	}
+	if l.Description == "" {
+		errs = append(errs, errors.New("missing description"))
+	}
+	if l.URL == "" {
+		errs = append(errs, errors.New("missing URL"))
+	}
+	if l.Category == "" {
+		errs = append(errs, errors.New("missing category"))
+	}
+	if len(l.Tags) == 0 {
+		errs = append(errs, errors.New("no tags"))
+	}
+	return errors.Join(errs...)
 }
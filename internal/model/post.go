@@ -0,0 +1,171 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// PostStatus is a step in the lifecycle of a post
+type PostStatus string
+
+const (
+	PostStatusSelected          PostStatus = "selected"
+	PostStatusRendered          PostStatus = "rendered"
+	PostStatusContainersCreated PostStatus = "containers_created"
+	PostStatusPublished         PostStatus = "published"
+	PostStatusFailed            PostStatus = "failed"
+)
+
+// PostFormat is the kind of Instagram media a post is published as
+type PostFormat string
+
+const (
+	PostFormatCarousel PostFormat = "carousel"
+	PostFormatReel     PostFormat = "reel"
+)
+
+// ParsePostFormat validates a post format name
+func ParsePostFormat(name string) (PostFormat, error) {
+	switch format := PostFormat(name); format {
+	case PostFormatCarousel, PostFormatReel:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown post format %q (want carousel or reel)", name)
+	}
+}
+
+// PlatformInstagram is the primary platform, whose post ID is also kept in
+// PostedLibrary.PostID
+const PlatformInstagram = "instagram"
+
+// postSteps lists the successful states in the order a post goes through them
+var postSteps = []PostStatus{
+	PostStatusSelected,
+	PostStatusRendered,
+	PostStatusContainersCreated,
+	PostStatusPublished,
+}
+
+// PostedLibrary represents a post of a library, from selection to publication.
+// Records written before post states existed have no status and count as published.
+type PostedLibrary struct {
+	ID        string    `json:"id,omitempty"`
+	Library   Library   `json:"library"`
+	PostedAt  time.Time `json:"posted_at"`
+	PostID    string    `json:"post_id,omitempty"`
+	ImagePath string    `json:"image_path,omitempty"`
+
+	Status PostStatus `json:"status,omitempty"`
+	// LastStep is the last step that completed before the post failed
+	LastStep    PostStatus `json:"last_step,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	Caption     string     `json:"caption,omitempty"`
+	ImagePaths  []string   `json:"image_paths,omitempty"`
+	ChildIDs    []string   `json:"child_ids,omitempty"`
+	ContainerID string     `json:"container_id,omitempty"`
+	// Format is how the post is published; empty means carousel
+	Format    PostFormat `json:"format,omitempty"`
+	VideoPath string     `json:"video_path,omitempty"`
+	// StoryID is the Story teaser published after the post, if any
+	StoryID string `json:"story_id,omitempty"`
+	// RemoteIDs maps each platform the post went out on to its ID there
+	RemoteIDs map[string]string `json:"remote_ids,omitempty"`
+}
+
+// NewPost starts a post record for a freshly selected library
+func NewPost(lib *Library, now time.Time) *PostedLibrary {
+	return &PostedLibrary{
+		ID:       fmt.Sprintf("%s-%s", now.UTC().Format("20060102T150405"), lib.Name),
+		Library:  *lib,
+		PostedAt: now,
+		Status:   PostStatusSelected,
+	}
+}
+
+// IsReel reports whether the post is published as a reel
+func (p *PostedLibrary) IsReel() bool {
+	return p.Format == PostFormatReel
+}
+
+// MediaPaths returns the files the post is published from: the slides, plus
+// the video for reels
+func (p *PostedLibrary) MediaPaths() []string {
+	if p.IsReel() {
+		return append(append([]string(nil), p.ImagePaths...), p.VideoPath)
+	}
+	return p.ImagePaths
+}
+
+// RemoteID returns the ID of the post on platform, or "" if it was not
+// published there. Records written before cross-posting only carry the
+// Instagram ID in PostID.
+func (p *PostedLibrary) RemoteID(platform string) string {
+	if id := p.RemoteIDs[platform]; id != "" {
+		return id
+	}
+	if platform == PlatformInstagram {
+		return p.PostID
+	}
+	return ""
+}
+
+// SetRemoteID records the ID of the post on platform
+func (p *PostedLibrary) SetRemoteID(platform, id string) {
+	if p.RemoteIDs == nil {
+		p.RemoteIDs = make(map[string]string)
+	}
+	p.RemoteIDs[platform] = id
+	if platform == PlatformInstagram {
+		p.PostID = id
+	}
+}
+
+// IsPublished reports whether the post went live
+func (p *PostedLibrary) IsPublished() bool {
+	return p.Status == "" || p.Status == PostStatusPublished
+}
+
+// ReachedPlatform reports whether the post went live on any platform, even if
+// a later step failed
+func (p *PostedLibrary) ReachedPlatform() bool {
+	return p.PostID != "" || len(p.RemoteIDs) > 0
+}
+
+// CompletedStep returns the last step that completed successfully
+func (p *PostedLibrary) CompletedStep() PostStatus {
+	if p.Status == PostStatusFailed {
+		return p.LastStep
+	}
+	if p.Status == "" {
+		return PostStatusPublished
+	}
+	return p.Status
+}
+
+// Completed reports whether step has already completed
+func (p *PostedLibrary) Completed(step PostStatus) bool {
+	return stepIndex(p.CompletedStep()) >= stepIndex(step)
+}
+
+// Advance records that step completed
+func (p *PostedLibrary) Advance(step PostStatus) {
+	p.Status = step
+	p.LastStep = ""
+	p.Error = ""
+}
+
+// Fail marks the post as failed after its last completed step
+func (p *PostedLibrary) Fail(err error) {
+	p.LastStep = p.CompletedStep()
+	p.Status = PostStatusFailed
+	p.Error = err.Error()
+}
+
+func stepIndex(step PostStatus) int {
+	for i, s := range postSteps {
+		if s == step {
+			return i
+		}
+	}
+	return -1
+}
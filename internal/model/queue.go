@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// QueueDateFormat is the layout of QueueEntry.Date
+const QueueDateFormat = "2006-01-02"
+
+// QueueEntry is an editorial pick waiting in the queue
+type QueueEntry struct {
+	Library string    `json:"library"`
+	Date    string    `json:"date,omitempty"`
+	Note    string    `json:"note,omitempty"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+// DueOn reports whether the entry may be published on the UTC day of t,
+// the day the one-post-per-day guard counts. Entries without a date are due
+// on any day.
+func (e *QueueEntry) DueOn(t time.Time) bool {
+	return e.Date == "" || e.Date == t.UTC().Format(QueueDateFormat)
+}
+
+// Overdue reports whether the entry is pinned to a UTC day before the day of
+// t, for example because that day's run failed. It will never be published.
+func (e *QueueEntry) Overdue(t time.Time) bool {
+	return e.Date != "" && e.Date < t.UTC().Format(QueueDateFormat)
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// AccessToken is a stored Graph API access token
+type AccessToken struct {
+	Token string `json:"access_token"`
+	// ExpiresAt is zero when the expiry is unknown or the token never expires
+	ExpiresAt   time.Time `json:"expires_at"`
+	RefreshedAt time.Time `json:"refreshed_at"`
+	Scopes      []string  `json:"scopes,omitempty"`
+	// SeedHash is the TokenHash of the INSTAGRAM_ACCESS_TOKEN this token was
+	// exchanged or refreshed from, so a new one in the environment replaces it
+	SeedHash string `json:"seed_hash,omitempty"`
+}
+
+// TokenHash identifies a token without keeping the token itself
+func TokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Expires reports whether the token has a known expiry
+func (t *AccessToken) Expires() bool {
+	return !t.ExpiresAt.IsZero()
+}
+
+// Expired reports whether the token has a known expiry that has passed
+func (t *AccessToken) Expired(now time.Time) bool {
+	return t.Expires() && !t.ExpiresAt.After(now)
+}
+
+// ExpiresWithin reports whether the token expires less than d after now
+func (t *AccessToken) ExpiresWithin(d time.Duration, now time.Time) bool {
+	return t.Expires() && t.ExpiresAt.Sub(now) < d
+}
@@ -0,0 +1,80 @@
+// Package platform defines what a social network needs to implement for the
+// publisher to post there. Instagram is the primary platform; the others
+// cross-post the same rendered post.
+package platform
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nitin737/GoAutoPosts/internal/model"
+)
+
+// Platform names accepted by the PLATFORMS setting and used as keys of the
+// remote IDs in post records
+const (
+	Instagram = model.PlatformInstagram
+	Mastodon  = "mastodon"
+	Bluesky   = "bluesky"
+)
+
+// RemoteID is the ID a platform assigned to a published post
+type RemoteID string
+
+// Post is a rendered post, ready to be published on any platform
+type Post struct {
+	// ID is the post record ID; platforms that support idempotent requests
+	// use it so a retried publish does not post twice
+	ID       string
+	Library  *model.Library
+	Caption  string
+	Hashtags []string
+	// Slides are the carousel images, in order, and AltText describes each
+	Slides  []string
+	AltText []string
+	// Video is the rendered reel, empty for carousels
+	Video string
+}
+
+// Publisher publishes posts to one platform
+type Publisher interface {
+	// Name identifies the platform in configuration and post records
+	Name() string
+	// Publish posts post and returns the ID the platform gave it
+	Publish(ctx context.Context, post Post) (RemoteID, error)
+}
+
+// ParseNames splits a comma-separated platform list, rejecting unknown and
+// repeated names. Instagram, when listed, comes first: the other platforms
+// cross-post it.
+func ParseNames(list string) ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		switch name {
+		case Instagram, Mastodon, Bluesky:
+		default:
+			return nil, fmt.Errorf("unknown platform %q (want %s, %s or %s)", name, Instagram, Mastodon, Bluesky)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("platform %s is listed twice", name)
+		}
+		seen[name] = true
+		if name == Instagram {
+			names = append([]string{name}, names...)
+		} else {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no platforms configured")
+	}
+	return names, nil
+}
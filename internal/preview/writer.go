@@ -0,0 +1,141 @@
+package preview
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/model"
+)
+
+const (
+	captionFile  = "caption.txt"
+	hashtagsFile = "hashtags.txt"
+	manifestFile = "manifest.json"
+)
+
+// Manifest describes a previewed post
+type Manifest struct {
+	Library     model.Library `json:"library"`
+	Caption     string        `json:"caption"`
+	Hashtags    []string      `json:"hashtags"`
+	Slides      []string      `json:"slides"`
+	Video       string        `json:"video,omitempty"`
+	Story       string        `json:"story,omitempty"`
+	GeneratedAt time.Time     `json:"generated_at"`
+}
+
+// Media lists the rendered files of a post; Video and Story are empty when
+// the post has none
+type Media struct {
+	Slides []string
+	Video  string
+	Story  string
+}
+
+// Writer writes post previews to a directory for review
+type Writer struct {
+	dir string
+}
+
+// NewWriter creates a new preview writer
+func NewWriter(dir string) *Writer {
+	return &Writer{
+		dir: dir,
+	}
+}
+
+// Clear removes the previous preview from the directory: the files listed
+// in its manifest, then the caption, hashtags and manifest themselves. Other
+// files are left alone, so pointing the writer at a shared directory is safe.
+func (w *Writer) Clear() error {
+	data, err := os.ReadFile(filepath.Join(w.dir, manifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read previous manifest: %w", err)
+	}
+
+	var previous Manifest
+	if err := json.Unmarshal(data, &previous); err != nil {
+		return fmt.Errorf("failed to parse previous manifest: %w", err)
+	}
+
+	files := append(previous.Slides, previous.Video, previous.Story, captionFile, hashtagsFile, manifestFile)
+	for _, file := range files {
+		// Only paths the writer made relative lie inside the directory
+		if file == "" || filepath.IsAbs(file) || strings.HasPrefix(file, "..") {
+			continue
+		}
+		if err := os.Remove(filepath.Join(w.dir, file)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove previous preview: %w", err)
+		}
+	}
+	return nil
+}
+
+// Write stores the caption, hashtags and a JSON manifest next to the slides.
+// Media paths are recorded relative to the preview directory when possible.
+// It returns the path of the manifest.
+func (w *Writer) Write(lib *model.Library, caption string, hashtags []string, media Media) (string, error) {
+	if err := os.MkdirAll(w.dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to ensure preview dir: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(w.dir, captionFile), []byte(caption), 0644); err != nil {
+		return "", fmt.Errorf("failed to write caption: %w", err)
+	}
+
+	tags := make([]string, len(hashtags))
+	for i, tag := range hashtags {
+		tags[i] = "#" + tag
+	}
+	if err := os.WriteFile(filepath.Join(w.dir, hashtagsFile), []byte(strings.Join(tags, "\n")+"\n"), 0644); err != nil {
+		return "", fmt.Errorf("failed to write hashtags: %w", err)
+	}
+
+	manifest := Manifest{
+		Library:     *lib,
+		Caption:     caption,
+		Hashtags:    hashtags,
+		Slides:      w.relativePaths(media.Slides),
+		Video:       w.relativePath(media.Video),
+		Story:       w.relativePath(media.Story),
+		GeneratedAt: time.Now(),
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	manifestPath := filepath.Join(w.dir, manifestFile)
+	if err := os.WriteFile(manifestPath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	return manifestPath, nil
+}
+
+func (w *Writer) relativePaths(paths []string) []string {
+	rel := make([]string, 0, len(paths))
+	for _, path := range paths {
+		rel = append(rel, w.relativePath(path))
+	}
+	return rel
+}
+
+func (w *Writer) relativePath(path string) string {
+	if path == "" {
+		return ""
+	}
+	if r, err := filepath.Rel(w.dir, path); err == nil && !strings.HasPrefix(r, "..") {
+		return r
+	}
+	return path
+}
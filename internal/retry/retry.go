@@ -0,0 +1,107 @@
+// Package retry repeats failed API calls with exponential backoff. The
+// platform clients decide which of their errors are worth repeating.
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Policy controls how often and how patiently a failed call is repeated
+type Policy struct {
+	// MaxRetries is the number of retries after the first attempt; 0 disables retries
+	MaxRetries int
+	// BaseDelay is the delay before the first retry; it doubles on every retry
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between retries
+	MaxDelay time.Duration
+}
+
+// DefaultPolicy retries three times, waiting about 1s, 2s and 4s
+var DefaultPolicy = Policy{
+	MaxRetries: 3,
+	BaseDelay:  time.Second,
+	MaxDelay:   30 * time.Second,
+}
+
+// RateLimitFactor stretches the delay after a rate limit error, which takes
+// longer to clear than a flaky 5xx
+const RateLimitFactor = 5
+
+// RateLimitError is implemented by API errors that can be rate limits
+type RateLimitError interface {
+	error
+	RateLimited() bool
+}
+
+// IsRateLimited reports whether err, or an error it wraps, is a rate limit
+func IsRateLimited(err error) bool {
+	var rateErr RateLimitError
+	return errors.As(err, &rateErr) && rateErr.RateLimited()
+}
+
+// TemporaryStatus reports whether a response with the HTTP status code is
+// worth repeating: a rate limit or a server error
+func TemporaryStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// IsNetworkError reports whether err is a failure to reach the server or
+// read its response, such as a timeout or a refused connection
+func IsNetworkError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// Delay returns the wait before retry number attempt (starting at 0) after
+// err: an exponential backoff with jitter, so parallel runs do not retry in
+// step
+func (p Policy) Delay(attempt int, err error) time.Duration {
+	backoff := p.BaseDelay << attempt
+	if IsRateLimited(err) {
+		backoff *= RateLimitFactor
+	}
+	if backoff <= 0 || backoff > p.MaxDelay {
+		backoff = p.MaxDelay
+	}
+
+	// Somewhere between half and all of the backoff
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// Do calls fn until it succeeds, fails with an error retryable rejects, ctx
+// is done or p.MaxRetries retries have failed, and returns the last error.
+// Only idempotent calls belong here.
+func Do(ctx context.Context, p Policy, retryable func(error) bool, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		// A cancelled call stops here rather than retrying its own cancellation
+		if attempt >= p.MaxRetries || ctx.Err() != nil || !retryable(err) {
+			return err
+		}
+		if err := Sleep(ctx, p.Delay(attempt, err)); err != nil {
+			return err
+		}
+	}
+}
+
+// Sleep waits for d or until ctx is done
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,122 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+var testPolicy = Policy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+var (
+	errTemporary = errors.New("temporary")
+	errPermanent = errors.New("permanent")
+)
+
+func isTemporary(err error) bool {
+	return errors.Is(err, errTemporary)
+}
+
+// failing returns a call that fails with the given errors in turn, then
+// succeeds, and counts its calls
+func failing(calls *int, errs ...error) func() error {
+	return func() error {
+		*calls++
+		if *calls <= len(errs) {
+			return errs[*calls-1]
+		}
+		return nil
+	}
+}
+
+func TestDo(t *testing.T) {
+	tests := []struct {
+		name      string
+		errs      []error
+		wantErr   error
+		wantCalls int
+	}{
+		{"first attempt", nil, nil, 1},
+		{"retried until success", []error{errTemporary, errTemporary}, nil, 3},
+		{"gives up after max retries", []error{errTemporary, errTemporary, errTemporary, errTemporary, errTemporary}, errTemporary, 4},
+		{"permanent error not retried", []error{errTemporary, errPermanent}, errPermanent, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := Do(context.Background(), testPolicy, isTemporary, failing(&calls, tt.errs...))
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("Do = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestDoStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	slow := Policy{MaxRetries: 3, BaseDelay: time.Hour, MaxDelay: time.Hour}
+
+	calls := 0
+	time.AfterFunc(20*time.Millisecond, cancel)
+	err := Do(ctx, slow, isTemporary, failing(&calls, errTemporary, errTemporary))
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Errorf("Do = %v after %d calls, want cancelled after 1", err, calls)
+	}
+}
+
+// rateLimitError is a RateLimitError for tests
+type rateLimitError struct{ limited bool }
+
+func (e *rateLimitError) Error() string     { return "rate limited" }
+func (e *rateLimitError) RateLimited() bool { return e.limited }
+
+func TestDelay(t *testing.T) {
+	p := Policy{BaseDelay: 100 * time.Millisecond, MaxDelay: 10 * time.Second}
+
+	tests := []struct {
+		name    string
+		attempt int
+		err     error
+		backoff time.Duration
+	}{
+		{"first retry", 0, errTemporary, 100 * time.Millisecond},
+		{"doubles", 3, errTemporary, 800 * time.Millisecond},
+		{"capped", 10, errTemporary, 10 * time.Second},
+		{"rate limit", 1, fmt.Errorf("publish: %w", &rateLimitError{limited: true}), RateLimitFactor * 200 * time.Millisecond},
+		{"not a rate limit", 1, &rateLimitError{}, 200 * time.Millisecond},
+		{"overflow capped", 70, errTemporary, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				if d := p.Delay(tt.attempt, tt.err); d < tt.backoff/2 || d > tt.backoff {
+					t.Fatalf("Delay(%d) = %s, want between %s and %s", tt.attempt, d, tt.backoff/2, tt.backoff)
+				}
+			}
+		})
+	}
+}
+
+func TestClassifiers(t *testing.T) {
+	for code, want := range map[int]bool{200: false, 400: false, 401: false, 429: true, 500: true, 503: true} {
+		if got := TemporaryStatus(code); got != want {
+			t.Errorf("TemporaryStatus(%d) = %v, want %v", code, got, want)
+		}
+	}
+
+	netErr := &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+	if !IsNetworkError(fmt.Errorf("upload failed: %w", netErr)) {
+		t.Error("IsNetworkError missed a wrapped dial error")
+	}
+	if IsNetworkError(errPermanent) {
+		t.Error("IsNetworkError matched a plain error")
+	}
+}
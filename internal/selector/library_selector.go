@@ -1,103 +1,214 @@
 package selector
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"math/rand"
-	"os"
 	"time"
 
 	"github.com/nitin737/GoAutoPosts/internal/model"
+	"github.com/nitin737/GoAutoPosts/internal/store"
 )
 
+// ErrNotEligible is returned when the selection rules exclude a requested library
+var ErrNotEligible = errors.New("library is not eligible")
+
+// LibrarySource provides the catalog of libraries to choose from
+type LibrarySource interface {
+	// GetAll retrieves every library in the catalog
+	GetAll(ctx context.Context) ([]model.Library, error)
+}
+
+// Options configures a LibrarySelector
+type Options struct {
+	// Strategy picks from the available pool; nil picks uniformly at random
+	Strategy Strategy
+
+	// Rules filter the pool before the strategy runs
+	Rules Rules
+
+	// Seed makes selection reproducible; 0 seeds from the clock
+	Seed int64
+
+	// SeedFromDate derives a fresh seed from each selection date (combined
+	// with Seed), so the same date and inputs always pick the same library
+	SeedFromDate bool
+}
+
 // LibrarySelector handles selection of libraries
 type LibrarySelector struct {
-	librariesPath string
-	postedPath    string
-	rand          *rand.Rand
+	libraries    LibrarySource
+	history      store.Repository
+	strategy     Strategy
+	rules        Rules
+	seed         int64
+	seedFromDate bool
+	seeded       bool
+	rand         *rand.Rand
+	// now is the clock selections are made by; tests replace it
+	now func() time.Time
 }
 
-// NewLibrarySelector creates a new library selector
-func NewLibrarySelector(librariesPath, postedPath string) *LibrarySelector {
+// NewLibrarySelector creates a new library selector that reads the catalog
+// from libraries and the posted history from history
+func NewLibrarySelector(libraries LibrarySource, history store.Repository, opts Options) *LibrarySelector {
+	strategy := opts.Strategy
+	if strategy == nil {
+		strategy = UniformStrategy{}
+	}
+
+	seed := opts.Seed
+	if seed == 0 && !opts.SeedFromDate {
+		seed = time.Now().UnixNano()
+	}
+
 	return &LibrarySelector{
-		librariesPath: librariesPath,
-		postedPath:    postedPath,
-		rand:          rand.New(rand.NewSource(time.Now().UnixNano())),
+		libraries:    libraries,
+		history:      history,
+		strategy:     strategy,
+		rules:        opts.Rules,
+		seed:         seed,
+		seedFromDate: opts.SeedFromDate,
+		seeded:       opts.Seed != 0,
+		rand:         rand.New(rand.NewSource(seed)),
+		now:          time.Now,
 	}
 }
 
-// SelectRandom selects a random library that hasn't been posted recently
-func (s *LibrarySelector) SelectRandom() (*model.Library, error) {
+// SelectRandom selects a library that hasn't been posted recently using the
+// configured strategy
+func (s *LibrarySelector) SelectRandom(ctx context.Context) (*model.Library, error) {
 	// Load all libraries
-	libraries, err := s.loadLibraries()
+	libraries, err := s.loadLibraries(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load libraries: %w", err)
 	}
 
 	// Load posted history
-	posted, err := s.loadPostedHistory()
+	posted, err := s.loadPostedHistory(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load posted history: %w", err)
 	}
 
-	// Filter out recently posted libraries
-	available := s.filterAvailable(libraries, posted)
+	return s.pick(libraries, posted, s.now())
+}
+
+// pick filters the libraries by the rules at now and lets the strategy choose
+func (s *LibrarySelector) pick(libraries []model.Library, posted []model.PostedLibrary, now time.Time) (*model.Library, error) {
+	// Filter out libraries excluded by the rules
+	available, excluded := s.filterAvailable(libraries, posted, now)
 	if len(available) == 0 {
-		return nil, fmt.Errorf("no available libraries to post")
+		return nil, &NoAvailableError{Exclusions: excluded}
 	}
 
-	// Select random library
-	selected := available[s.rand.Intn(len(available))]
+	// Let the strategy choose
+	selected := s.strategy.Pick(available, posted, s.randFor(now))
 	return &selected, nil
 }
 
-func (s *LibrarySelector) loadLibraries() ([]model.Library, error) {
-	data, err := os.ReadFile(s.librariesPath)
+// randFor returns the random source for a selection made at now. With a
+// seed every selection starts a fresh source, as a daily run is a fresh
+// process, so Plan draws exactly what publish will on each date.
+func (s *LibrarySelector) randFor(now time.Time) *rand.Rand {
+	switch {
+	case s.seedFromDate:
+		return rand.New(rand.NewSource(s.seed ^ dateSeed(now)))
+	case s.seeded:
+		return rand.New(rand.NewSource(s.seed))
+	default:
+		return s.rand
+	}
+}
+
+// FindByName returns the library with the given name, ignoring posted history
+func (s *LibrarySelector) FindByName(ctx context.Context, name string) (*model.Library, error) {
+	libraries, err := s.loadLibraries(ctx)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to load libraries: %w", err)
 	}
 
-	var libraries []model.Library
-	if err := json.Unmarshal(data, &libraries); err != nil {
+	for _, lib := range libraries {
+		if lib.Name == name {
+			return &lib, nil
+		}
+	}
+
+	return nil, fmt.Errorf("library not found: %s", name)
+}
+
+// SelectByName selects a specific library, refusing it with ErrNotEligible
+// if the selection rules exclude it
+func (s *LibrarySelector) SelectByName(ctx context.Context, name string) (*model.Library, error) {
+	library, err := s.FindByName(ctx, name)
+	if err != nil {
 		return nil, err
 	}
 
-	return libraries, nil
+	posted, err := s.loadPostedHistory(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load posted history: %w", err)
+	}
+
+	if ex, excluded := s.rules.check(*library, newPostIndex(posted), s.now()); excluded {
+		return nil, fmt.Errorf("%w: %s excluded by %s (%s)", ErrNotEligible, name, ex.Rule, ex.Reason)
+	}
+
+	return library, nil
+}
+
+func (s *LibrarySelector) loadLibraries(ctx context.Context) ([]model.Library, error) {
+	return s.libraries.GetAll(ctx)
 }
 
-func (s *LibrarySelector) loadPostedHistory() ([]model.PostedLibrary, error) {
-	data, err := os.ReadFile(s.postedPath)
+// loadPostedHistory returns the posts that went live, including failed posts
+// that reached a platform; selected, in-flight and other failed posts do not
+// count towards the rules
+func (s *LibrarySelector) loadPostedHistory(ctx context.Context) ([]model.PostedLibrary, error) {
+	records, err := s.history.GetAll(ctx)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return []model.PostedLibrary{}, nil
-		}
 		return nil, err
 	}
 
 	var posted []model.PostedLibrary
-	if err := json.Unmarshal(data, &posted); err != nil {
-		return nil, err
+	for _, record := range records {
+		if record.IsPublished() || record.ReachedPlatform() {
+			posted = append(posted, record)
+		}
 	}
 
 	return posted, nil
 }
 
-func (s *LibrarySelector) filterAvailable(libraries []model.Library, posted []model.PostedLibrary) []model.Library {
-	// Create a map of posted library names
-	postedMap := make(map[string]time.Time)
-	for _, p := range posted {
-		postedMap[p.Library.Name] = p.PostedAt
-	}
+func (s *LibrarySelector) filterAvailable(libraries []model.Library, posted []model.PostedLibrary, now time.Time) ([]model.Library, []Exclusion) {
+	h := newPostIndex(posted)
 
-	// Filter libraries that haven't been posted in the last 30 days
-	cutoff := time.Now().AddDate(0, 0, -30)
 	var available []model.Library
-
+	var excluded []Exclusion
 	for _, lib := range libraries {
-		if postedAt, exists := postedMap[lib.Name]; !exists || postedAt.Before(cutoff) {
-			available = append(available, lib)
+		if ex, ok := s.rules.check(lib, h, now); ok {
+			excluded = append(excluded, ex)
+			continue
+		}
+		available = append(available, lib)
+	}
+
+	return available, excluded
+}
+
+// lastPostedAt maps each library name to its most recent post time
+func lastPostedAt(posted []model.PostedLibrary) map[string]time.Time {
+	postedMap := make(map[string]time.Time)
+	for _, p := range posted {
+		if last, exists := postedMap[p.Library.Name]; !exists || p.PostedAt.After(last) {
+			postedMap[p.Library.Name] = p.PostedAt
 		}
 	}
+	return postedMap
+}
 
-	return available
+// dateSeed turns the UTC calendar date of t into a number such as 20260117
+func dateSeed(t time.Time) int64 {
+	y, m, d := t.UTC().Date()
+	return int64(y*10000 + int(m)*100 + d)
 }
@@ -0,0 +1,232 @@
+package selector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/model"
+)
+
+// memoryCatalog is a LibrarySource over a fixed list
+type memoryCatalog []model.Library
+
+func (c memoryCatalog) GetAll(ctx context.Context) ([]model.Library, error) {
+	return append([]model.Library(nil), c...), nil
+}
+
+// memoryHistory is a store.Repository kept in memory
+type memoryHistory struct {
+	records []model.PostedLibrary
+}
+
+func (h *memoryHistory) Save(ctx context.Context, posted *model.PostedLibrary) error {
+	h.records = append(h.records, *posted)
+	return nil
+}
+
+func (h *memoryHistory) SaveIf(ctx context.Context, posted *model.PostedLibrary, check func([]model.PostedLibrary) error) error {
+	if err := check(h.records); err != nil {
+		return err
+	}
+	return h.Save(ctx, posted)
+}
+
+func (h *memoryHistory) Update(ctx context.Context, posted *model.PostedLibrary) error {
+	for i := range h.records {
+		if h.records[i].ID == posted.ID {
+			h.records[i] = *posted
+			return nil
+		}
+	}
+	return fmt.Errorf("post not found: %s", posted.ID)
+}
+
+func (h *memoryHistory) GetAll(ctx context.Context) ([]model.PostedLibrary, error) {
+	return append([]model.PostedLibrary(nil), h.records...), nil
+}
+
+func (h *memoryHistory) GetByName(ctx context.Context, name string) (*model.PostedLibrary, error) {
+	for i := len(h.records) - 1; i >= 0; i-- {
+		if h.records[i].Library.Name == name {
+			return &h.records[i], nil
+		}
+	}
+	return nil, fmt.Errorf("library not found: %s", name)
+}
+
+func (h *memoryHistory) DeleteByName(ctx context.Context, name string) (int, error) {
+	var kept []model.PostedLibrary
+	for _, record := range h.records {
+		if record.Library.Name != name {
+			kept = append(kept, record)
+		}
+	}
+	removed := len(h.records) - len(kept)
+	h.records = kept
+	return removed, nil
+}
+
+func (h *memoryHistory) Close() error {
+	return nil
+}
+
+func TestSelectRandomReportsEveryExclusion(t *testing.T) {
+	gin, echo, old := testLibrary("gin", "web"), testLibrary("echo", "web"), testLibrary("old", "cli")
+	old.Enabled = false
+
+	now := time.Now()
+	history := &memoryHistory{records: []model.PostedLibrary{
+		{Library: gin, PostedAt: now.AddDate(0, 0, -2)},
+	}}
+	s := NewLibrarySelector(memoryCatalog{gin, echo, old}, history, Options{
+		Rules: Rules{CooldownDays: 30, CategoryGapDays: 3},
+	})
+
+	_, err := s.SelectRandom(context.Background())
+
+	var noAvailable *NoAvailableError
+	if !errors.As(err, &noAvailable) {
+		t.Fatalf("SelectRandom error = %v, want a NoAvailableError", err)
+	}
+	var rules []string
+	for _, ex := range noAvailable.Exclusions {
+		rules = append(rules, ex.Library.Name+":"+ex.Rule)
+	}
+	if got, want := strings.Join(rules, ","), "gin:cooldown,echo:category-gap,old:disabled"; got != want {
+		t.Errorf("exclusions = %s, want %s", got, want)
+	}
+	for _, text := range []string{"gin excluded by cooldown", "echo excluded by category-gap", "old excluded by disabled"} {
+		if !strings.Contains(err.Error(), text) {
+			t.Errorf("error %q does not say %q", err, text)
+		}
+	}
+}
+
+func TestSelectRandomIgnoresPostsThatNeverWentLive(t *testing.T) {
+	gin := testLibrary("gin", "web")
+
+	failed := model.NewPost(&gin, time.Now())
+	failed.Fail(errors.New("render failed"))
+	inFlight := model.NewPost(&gin, time.Now())
+	inFlight.Advance(model.PostStatusContainersCreated)
+
+	history := &memoryHistory{records: []model.PostedLibrary{*failed, *inFlight}}
+	s := NewLibrarySelector(memoryCatalog{gin}, history, Options{Rules: Rules{CooldownDays: 30}})
+
+	lib, err := s.SelectRandom(context.Background())
+	if err != nil || lib.Name != "gin" {
+		t.Fatalf("SelectRandom = %v, %v, want gin", lib, err)
+	}
+
+	// Once a failed post reached a platform it counts
+	failed.SetRemoteID(model.PlatformInstagram, "17890")
+	history.records[0] = *failed
+	if _, err := s.SelectRandom(context.Background()); err == nil {
+		t.Error("SelectRandom picked gin inside the cooldown of a post that went live")
+	}
+}
+
+func TestSelectByNameRefusesExcludedLibrary(t *testing.T) {
+	gin := testLibrary("gin", "web")
+	history := &memoryHistory{records: []model.PostedLibrary{{Library: gin, PostedAt: time.Now().AddDate(0, 0, -1)}}}
+	s := NewLibrarySelector(memoryCatalog{gin}, history, Options{Rules: Rules{CooldownDays: 7}})
+
+	_, err := s.SelectByName(context.Background(), "gin")
+	if !errors.Is(err, ErrNotEligible) || !strings.Contains(err.Error(), "gin excluded by cooldown") {
+		t.Errorf("SelectByName error = %v, want gin not eligible because of the cooldown", err)
+	}
+
+	if _, err := s.FindByName(context.Background(), "gin"); err != nil {
+		t.Errorf("FindByName: %v", err)
+	}
+	if _, err := s.SelectByName(context.Background(), "missing"); err == nil || errors.Is(err, ErrNotEligible) {
+		t.Errorf("SelectByName of a missing library = %v, want not found", err)
+	}
+}
+
+// testCatalog returns n enabled libraries spread over three categories
+func testCatalog(n int) memoryCatalog {
+	categories := []string{"web", "database", "cli"}
+	var catalog memoryCatalog
+	for i := 0; i < n; i++ {
+		lib := testLibrary(fmt.Sprintf("lib-%02d", i), categories[i%len(categories)])
+		lib.Stars = i * 100
+		catalog = append(catalog, lib)
+	}
+	return catalog
+}
+
+// selectAt runs SelectRandom with a fresh selector, as a daily run does, at now
+func selectAt(t *testing.T, catalog memoryCatalog, history *memoryHistory, opts Options, now time.Time) string {
+	t.Helper()
+
+	s := NewLibrarySelector(catalog, history, opts)
+	s.now = func() time.Time { return now }
+	lib, err := s.SelectRandom(context.Background())
+	if err != nil {
+		t.Fatalf("SelectRandom at %s: %v", now.Format(model.QueueDateFormat), err)
+	}
+	return lib.Name
+}
+
+func TestSeededSelectionRepeats(t *testing.T) {
+	catalog := testCatalog(20)
+	history := &memoryHistory{}
+
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"fixed seed", Options{Seed: 42, Strategy: WeightedStarsStrategy{}}},
+		{"date seed", Options{SeedFromDate: true}},
+		{"date seed with fixed seed", Options{Seed: 42, SeedFromDate: true, Strategy: CategoryRoundRobinStrategy{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := selectAt(t, catalog, history, tt.opts, testNow)
+			for run := 0; run < 5; run++ {
+				// A later run on the same day, in another process
+				if got := selectAt(t, catalog, history, tt.opts, testNow.Add(time.Duration(run)*time.Hour)); got != first {
+					t.Fatalf("run %d picked %s, want %s like the first", run, got, first)
+				}
+			}
+
+			// The same selector picks the same again too
+			s := NewLibrarySelector(catalog, history, tt.opts)
+			s.now = func() time.Time { return testNow }
+			for run := 0; run < 3; run++ {
+				if lib, err := s.SelectRandom(context.Background()); err != nil || lib.Name != first {
+					t.Fatalf("repeat %d picked %v, %v, want %s", run, lib, err, first)
+				}
+			}
+		})
+	}
+}
+
+func TestDateSeedVariesByDate(t *testing.T) {
+	catalog := testCatalog(20)
+	opts := Options{SeedFromDate: true}
+
+	picks := make(map[string]bool)
+	for day := 0; day < 30; day++ {
+		picks[selectAt(t, catalog, &memoryHistory{}, opts, testNow.AddDate(0, 0, day))] = true
+	}
+	if len(picks) < 5 {
+		t.Errorf("30 dates picked only %v; the date does not change the seed", picks)
+	}
+
+	other := Options{Seed: 7, SeedFromDate: true}
+	differs := false
+	for day := 0; day < 10 && !differs; day++ {
+		date := testNow.AddDate(0, 0, day)
+		differs = selectAt(t, catalog, &memoryHistory{}, opts, date) != selectAt(t, catalog, &memoryHistory{}, other, date)
+	}
+	if !differs {
+		t.Error("Seed does not change the date-derived picks")
+	}
+}
@@ -0,0 +1,75 @@
+package selector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/model"
+)
+
+// PlanEntry is one simulated day of selection
+type PlanEntry struct {
+	Date    time.Time
+	Library *model.Library
+	// Queued is set when the library came from the editorial queue
+	Queued bool
+	// Err is set when no library could be selected for the day
+	Err error
+}
+
+// Plan simulates one selection per day for the given number of days starting
+// at start, taking due queue entries first. Each simulated pick is added to an
+// in-memory copy of the history (and removed from a copy of the queue) so
+// later days see it; nothing is written.
+func (s *LibrarySelector) Plan(ctx context.Context, start time.Time, days int, queue []model.QueueEntry) ([]PlanEntry, error) {
+	libraries, err := s.loadLibraries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load libraries: %w", err)
+	}
+
+	posted, err := s.loadPostedHistory(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load posted history: %w", err)
+	}
+
+	simulated := make([]model.PostedLibrary, len(posted), len(posted)+days)
+	copy(simulated, posted)
+
+	pending := append([]model.QueueEntry(nil), queue...)
+
+	entries := make([]PlanEntry, 0, days)
+	for day := 0; day < days; day++ {
+		date := start.AddDate(0, 0, day)
+
+		var entry PlanEntry
+		if queued, _ := s.pickQueued(libraries, simulated, pending, date); queued != nil {
+			entry = PlanEntry{Date: date, Library: queued.Library, Queued: true}
+			pending = removeQueueEntry(pending, queued.Entry)
+		} else {
+			library, err := s.pick(libraries, simulated, date)
+			entry = PlanEntry{Date: date, Library: library, Err: err}
+		}
+
+		entries = append(entries, entry)
+		if entry.Err != nil {
+			continue
+		}
+
+		simulated = append(simulated, model.PostedLibrary{
+			Library:  *entry.Library,
+			PostedAt: date,
+		})
+	}
+
+	return entries, nil
+}
+
+func removeQueueEntry(queue []model.QueueEntry, entry model.QueueEntry) []model.QueueEntry {
+	for i, e := range queue {
+		if e.Library == entry.Library && e.Date == entry.Date {
+			return append(queue[:i:i], queue[i+1:]...)
+		}
+	}
+	return queue
+}
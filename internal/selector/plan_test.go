@@ -0,0 +1,105 @@
+package selector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/model"
+)
+
+// publishDaily does what a daily publish run does on each of the given days,
+// each in a fresh selector: take a due queue entry first, otherwise a random
+// pick, and record the post
+func publishDaily(t *testing.T, catalog memoryCatalog, history *memoryHistory, opts Options, queue []model.QueueEntry, start time.Time, days int) []string {
+	t.Helper()
+	ctx := context.Background()
+
+	var picks []string
+	for day := 0; day < days; day++ {
+		date := start.AddDate(0, 0, day)
+		s := NewLibrarySelector(catalog, history, opts)
+		s.now = func() time.Time { return date }
+
+		queued, _, err := s.SelectQueued(ctx, queue, date)
+		if err != nil {
+			t.Fatalf("SelectQueued: %v", err)
+		}
+		var lib *model.Library
+		if queued != nil {
+			lib = queued.Library
+			queue = removeQueueEntry(queue, queued.Entry)
+		} else if lib, err = s.SelectRandom(ctx); err != nil {
+			t.Fatalf("SelectRandom on day %d: %v", day, err)
+		}
+
+		post := model.NewPost(lib, date)
+		post.Advance(model.PostStatusPublished)
+		if err := history.Save(ctx, post); err != nil {
+			t.Fatal(err)
+		}
+		picks = append(picks, lib.Name)
+	}
+	return picks
+}
+
+func TestPlanPredictsPublish(t *testing.T) {
+	catalog := testCatalog(12)
+	rules := Rules{CooldownDays: 7, CategoryMaxPosts: 4, CategoryWindowDays: 7}
+
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"fixed seed", Options{Seed: 99, Rules: rules}},
+		{"date seed", Options{SeedFromDate: true, Rules: rules, Strategy: WeightedStarsStrategy{}}},
+		{"least recent", Options{Seed: 5, SeedFromDate: true, Rules: rules, Strategy: LeastRecentStrategy{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := testNow
+			queue := []model.QueueEntry{
+				{Library: "lib-10", Date: start.AddDate(0, 0, 1).Format(model.QueueDateFormat)},
+				{Library: "lib-04"},
+			}
+			past := []model.PostedLibrary{postedDaysAgo(catalog[0], 2), postedDaysAgo(catalog[1], 1)}
+
+			planner := NewLibrarySelector(catalog, &memoryHistory{records: past}, tt.opts)
+			plan, err := planner.Plan(context.Background(), start, 10, queue)
+			if err != nil {
+				t.Fatalf("Plan: %v", err)
+			}
+
+			history := &memoryHistory{records: append([]model.PostedLibrary(nil), past...)}
+			published := publishDaily(t, catalog, history, tt.opts, queue, start, 10)
+
+			for day, entry := range plan {
+				if entry.Err != nil {
+					t.Fatalf("plan for day %d failed: %v", day, entry.Err)
+				}
+				if entry.Library.Name != published[day] {
+					t.Errorf("day %d: plan picked %s, publish picked %s", day, entry.Library.Name, published[day])
+				}
+			}
+			if !plan[0].Queued || plan[0].Library.Name != "lib-04" {
+				t.Errorf("day 0 planned %s (queued %v), want the undated queue entry lib-04", plan[0].Library.Name, plan[0].Queued)
+			}
+			if !plan[1].Queued || plan[1].Library.Name != "lib-10" {
+				t.Errorf("day 1 planned %s (queued %v), want the pinned queue entry lib-10", plan[1].Library.Name, plan[1].Queued)
+			}
+		})
+	}
+}
+
+func TestPlanDoesNotWrite(t *testing.T) {
+	history := &memoryHistory{}
+	s := NewLibrarySelector(testCatalog(5), history, Options{Seed: 1})
+
+	if _, err := s.Plan(context.Background(), testNow, 5, nil); err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if len(history.records) != 0 {
+		t.Errorf("Plan wrote %d records", len(history.records))
+	}
+}
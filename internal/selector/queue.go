@@ -0,0 +1,68 @@
+package selector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/model"
+)
+
+// QueuedPick is a library taken from the editorial queue
+type QueuedPick struct {
+	Library *model.Library
+	Entry   model.QueueEntry
+}
+
+// SelectQueued returns the first queue entry due at now whose library is in
+// the catalog and passes the rules. Entries pinned to the day are tried before
+// undated ones. Due entries that are rejected are returned as skipped so the
+// caller can report them. A nil pick means the caller should fall back to
+// SelectRandom.
+func (s *LibrarySelector) SelectQueued(ctx context.Context, queue []model.QueueEntry, now time.Time) (*QueuedPick, []error, error) {
+	libraries, err := s.loadLibraries(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load libraries: %w", err)
+	}
+
+	posted, err := s.loadPostedHistory(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load posted history: %w", err)
+	}
+
+	pick, skipped := s.pickQueued(libraries, posted, queue, now)
+	return pick, skipped, nil
+}
+
+func (s *LibrarySelector) pickQueued(libraries []model.Library, posted []model.PostedLibrary, queue []model.QueueEntry, now time.Time) (*QueuedPick, []error) {
+	catalog := make(map[string]model.Library, len(libraries))
+	for _, lib := range libraries {
+		catalog[lib.Name] = lib
+	}
+
+	h := newPostIndex(posted)
+	var skipped []error
+
+	for _, pinned := range []bool{true, false} {
+		for _, entry := range queue {
+			if !entry.DueOn(now) || (entry.Date != "") != pinned {
+				continue
+			}
+
+			lib, exists := catalog[entry.Library]
+			if !exists {
+				skipped = append(skipped, fmt.Errorf("queued library not found: %s", entry.Library))
+				continue
+			}
+
+			if ex, excluded := s.rules.check(lib, h, now); excluded {
+				skipped = append(skipped, fmt.Errorf("%w: %s excluded by %s (%s)", ErrNotEligible, lib.Name, ex.Rule, ex.Reason))
+				continue
+			}
+
+			return &QueuedPick{Library: &lib, Entry: entry}, skipped
+		}
+	}
+
+	return nil, skipped
+}
@@ -0,0 +1,137 @@
+package selector
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/model"
+)
+
+// Rule names used in exclusions
+const (
+	RuleDisabled      = "disabled"
+	RuleCooldown      = "cooldown"
+	RuleCategoryGap   = "category-gap"
+	RuleAuthorGap     = "author-gap"
+	RuleCategoryLimit = "category-limit"
+)
+
+// Rules decides which libraries are eligible for selection.
+// A zero value disables the corresponding rule.
+type Rules struct {
+	// CooldownDays keeps a library out of rotation after it is posted
+	CooldownDays int
+
+	// CategoryGapDays is the minimum gap between two posts of the same category
+	CategoryGapDays int
+
+	// AuthorGapDays is the minimum gap between two posts by the same author
+	AuthorGapDays int
+
+	// CategoryMaxPosts caps the posts per category within CategoryWindowDays
+	CategoryMaxPosts   int
+	CategoryWindowDays int
+}
+
+// Exclusion records why a library was left out of the pool
+type Exclusion struct {
+	Library model.Library
+	Rule    string
+	Reason  string
+}
+
+// NoAvailableError is returned when the rules exclude every library
+type NoAvailableError struct {
+	Exclusions []Exclusion
+}
+
+func (e *NoAvailableError) Error() string {
+	if len(e.Exclusions) == 0 {
+		return "no available libraries to post: the catalog is empty"
+	}
+
+	reasons := make([]string, len(e.Exclusions))
+	for i, ex := range e.Exclusions {
+		reasons[i] = fmt.Sprintf("%s excluded by %s (%s)", ex.Library.Name, ex.Rule, ex.Reason)
+	}
+	return "no available libraries to post: " + strings.Join(reasons, "; ")
+}
+
+// postIndex indexes posted records for rule evaluation
+type postIndex struct {
+	byName     map[string]time.Time
+	byCategory map[string]model.PostedLibrary
+	byAuthor   map[string]model.PostedLibrary
+	posted     []model.PostedLibrary
+}
+
+func newPostIndex(posted []model.PostedLibrary) *postIndex {
+	h := &postIndex{
+		byName:     lastPostedAt(posted),
+		byCategory: make(map[string]model.PostedLibrary),
+		byAuthor:   make(map[string]model.PostedLibrary),
+		posted:     posted,
+	}
+
+	for _, p := range posted {
+		if last, exists := h.byCategory[p.Library.Category]; !exists || p.PostedAt.After(last.PostedAt) {
+			h.byCategory[p.Library.Category] = p
+		}
+		if p.Library.Author == "" {
+			continue
+		}
+		if last, exists := h.byAuthor[p.Library.Author]; !exists || p.PostedAt.After(last.PostedAt) {
+			h.byAuthor[p.Library.Author] = p
+		}
+	}
+
+	return h
+}
+
+// check returns the first rule that excludes lib at now, if any
+func (r Rules) check(lib model.Library, h *postIndex, now time.Time) (Exclusion, bool) {
+	if !lib.Enabled {
+		return Exclusion{lib, RuleDisabled, "disabled in the catalog"}, true
+	}
+
+	if r.CooldownDays > 0 {
+		if at, exists := h.byName[lib.Name]; exists && at.After(daysBefore(now, r.CooldownDays)) {
+			return Exclusion{lib, RuleCooldown, fmt.Sprintf("posted %s, cooldown is %d days", at.Format("2006-01-02"), r.CooldownDays)}, true
+		}
+	}
+
+	if r.CategoryGapDays > 0 {
+		if last, exists := h.byCategory[lib.Category]; exists && last.PostedAt.After(daysBefore(now, r.CategoryGapDays)) {
+			return Exclusion{lib, RuleCategoryGap, fmt.Sprintf("category %q last posted %s with %s, gap is %d days",
+				lib.Category, last.PostedAt.Format("2006-01-02"), last.Library.Name, r.CategoryGapDays)}, true
+		}
+	}
+
+	if r.AuthorGapDays > 0 && lib.Author != "" {
+		if last, exists := h.byAuthor[lib.Author]; exists && last.PostedAt.After(daysBefore(now, r.AuthorGapDays)) {
+			return Exclusion{lib, RuleAuthorGap, fmt.Sprintf("author %q last posted %s with %s, gap is %d days",
+				lib.Author, last.PostedAt.Format("2006-01-02"), last.Library.Name, r.AuthorGapDays)}, true
+		}
+	}
+
+	if r.CategoryMaxPosts > 0 && r.CategoryWindowDays > 0 {
+		since := daysBefore(now, r.CategoryWindowDays)
+		count := 0
+		for _, p := range h.posted {
+			if p.Library.Category == lib.Category && p.PostedAt.After(since) {
+				count++
+			}
+		}
+		if count >= r.CategoryMaxPosts {
+			return Exclusion{lib, RuleCategoryLimit, fmt.Sprintf("category %q has %d posts in the last %d days, limit is %d",
+				lib.Category, count, r.CategoryWindowDays, r.CategoryMaxPosts)}, true
+		}
+	}
+
+	return Exclusion{}, false
+}
+
+func daysBefore(t time.Time, days int) time.Time {
+	return t.AddDate(0, 0, -days)
+}
@@ -0,0 +1,162 @@
+package selector
+
+import (
+	"testing"
+
+	"github.com/nitin737/GoAutoPosts/internal/model"
+)
+
+func TestRulesCheck(t *testing.T) {
+	gin := testLibrary("gin", "web")
+	gin.Author = "gin-gonic"
+	echo := testLibrary("echo", "web")
+	echo.Author = "labstack"
+	gorm := testLibrary("gorm", "database")
+	gorm.Author = "go-gorm"
+	ginSwagger := testLibrary("gin-swagger", "docs")
+	ginSwagger.Author = "gin-gonic"
+	cobra := testLibrary("cobra", "cli")
+	disabled := testLibrary("old", "cli")
+	disabled.Enabled = false
+
+	tests := []struct {
+		name   string
+		rules  Rules
+		lib    model.Library
+		posted []model.PostedLibrary
+		rule   string
+		reason string
+	}{
+		{
+			name: "disabled",
+			lib:  disabled,
+			rule: RuleDisabled, reason: "disabled in the catalog",
+		},
+		{
+			name:   "cooldown",
+			rules:  Rules{CooldownDays: 30},
+			lib:    gin,
+			posted: []model.PostedLibrary{postedDaysAgo(gin, 10)},
+			rule:   RuleCooldown, reason: "posted 2026-03-05, cooldown is 30 days",
+		},
+		{
+			name:   "cooldown over",
+			rules:  Rules{CooldownDays: 30},
+			lib:    gin,
+			posted: []model.PostedLibrary{postedDaysAgo(gin, 31)},
+		},
+		{
+			name:   "cooldown counts the latest post",
+			rules:  Rules{CooldownDays: 30},
+			lib:    gin,
+			posted: []model.PostedLibrary{postedDaysAgo(gin, 5), postedDaysAgo(gin, 60)},
+			rule:   RuleCooldown, reason: "posted 2026-03-10, cooldown is 30 days",
+		},
+		{
+			name:   "category gap",
+			rules:  Rules{CategoryGapDays: 3},
+			lib:    echo,
+			posted: []model.PostedLibrary{postedDaysAgo(gin, 1)},
+			rule:   RuleCategoryGap, reason: `category "web" last posted 2026-03-14 with gin, gap is 3 days`,
+		},
+		{
+			name:   "category gap over",
+			rules:  Rules{CategoryGapDays: 3},
+			lib:    echo,
+			posted: []model.PostedLibrary{postedDaysAgo(gin, 4)},
+		},
+		{
+			name:   "category gap in another category",
+			rules:  Rules{CategoryGapDays: 3},
+			lib:    gorm,
+			posted: []model.PostedLibrary{postedDaysAgo(gin, 1)},
+		},
+		{
+			name:   "author gap",
+			rules:  Rules{AuthorGapDays: 7},
+			lib:    ginSwagger,
+			posted: []model.PostedLibrary{postedDaysAgo(gin, 2)},
+			rule:   RuleAuthorGap, reason: `author "gin-gonic" last posted 2026-03-13 with gin, gap is 7 days`,
+		},
+		{
+			name:   "author gap over",
+			rules:  Rules{AuthorGapDays: 7},
+			lib:    ginSwagger,
+			posted: []model.PostedLibrary{postedDaysAgo(gin, 8)},
+		},
+		{
+			name:   "author gap without an author",
+			rules:  Rules{AuthorGapDays: 7},
+			lib:    cobra,
+			posted: []model.PostedLibrary{postedDaysAgo(testLibrary("viper", "config"), 1)},
+		},
+		{
+			name:   "category limit",
+			rules:  Rules{CategoryMaxPosts: 2, CategoryWindowDays: 14},
+			lib:    echo,
+			posted: []model.PostedLibrary{postedDaysAgo(gin, 2), postedDaysAgo(testLibrary("chi", "web"), 9)},
+			rule:   RuleCategoryLimit, reason: `category "web" has 2 posts in the last 14 days, limit is 2`,
+		},
+		{
+			name:   "category limit outside the window",
+			rules:  Rules{CategoryMaxPosts: 2, CategoryWindowDays: 14},
+			lib:    echo,
+			posted: []model.PostedLibrary{postedDaysAgo(gin, 2), postedDaysAgo(testLibrary("chi", "web"), 20)},
+		},
+		{
+			name:   "category limit needs a window",
+			rules:  Rules{CategoryMaxPosts: 1},
+			lib:    echo,
+			posted: []model.PostedLibrary{postedDaysAgo(gin, 2)},
+		},
+		{
+			name:   "first rule wins",
+			rules:  Rules{CooldownDays: 30, CategoryGapDays: 3},
+			lib:    gin,
+			posted: []model.PostedLibrary{postedDaysAgo(gin, 1)},
+			rule:   RuleCooldown, reason: "posted 2026-03-14, cooldown is 30 days",
+		},
+		{
+			name:   "zero rules",
+			lib:    gin,
+			posted: []model.PostedLibrary{postedDaysAgo(gin, 0)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex, excluded := tt.rules.check(tt.lib, newPostIndex(tt.posted), testNow)
+			if tt.rule == "" {
+				if excluded {
+					t.Fatalf("excluded by %s (%s), want eligible", ex.Rule, ex.Reason)
+				}
+				return
+			}
+
+			if !excluded {
+				t.Fatalf("eligible, want excluded by %s", tt.rule)
+			}
+			if ex.Rule != tt.rule || ex.Reason != tt.reason || ex.Library.Name != tt.lib.Name {
+				t.Errorf("excluded %s by %s (%s), want %s by %s (%s)", ex.Library.Name, ex.Rule, ex.Reason, tt.lib.Name, tt.rule, tt.reason)
+			}
+		})
+	}
+}
+
+func TestNoAvailableErrorListsExclusions(t *testing.T) {
+	err := &NoAvailableError{Exclusions: []Exclusion{
+		{Library: testLibrary("gin", "web"), Rule: RuleCooldown, Reason: "posted 2026-03-10, cooldown is 30 days"},
+		{Library: testLibrary("old", "cli"), Rule: RuleDisabled, Reason: "disabled in the catalog"},
+	}}
+
+	want := "no available libraries to post: gin excluded by cooldown (posted 2026-03-10, cooldown is 30 days); " +
+		"old excluded by disabled (disabled in the catalog)"
+	if got := err.Error(); got != want {
+		t.Errorf("Error() =\n%s\nwant\n%s", got, want)
+	}
+
+	empty := &NoAvailableError{}
+	if got := empty.Error(); got != "no available libraries to post: the catalog is empty" {
+		t.Errorf("Error() with no exclusions = %s", got)
+	}
+}
@@ -0,0 +1,161 @@
+package selector
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/model"
+)
+
+// Strategy picks one library from the pool that survived filtering
+type Strategy interface {
+	// Pick chooses a library from available, which is never empty.
+	// posted is the full posting history.
+	Pick(available []model.Library, posted []model.PostedLibrary, r *rand.Rand) model.Library
+}
+
+// Strategy names accepted by StrategyByName
+const (
+	StrategyRandom             = "random"
+	StrategyWeightedStars      = "weighted-stars"
+	StrategyLeastRecent        = "least-recent"
+	StrategyCategoryRoundRobin = "category-round-robin"
+	StrategyNeverPostedFirst   = "never-posted-first"
+)
+
+// StrategyByName returns the built-in strategy with the given name
+func StrategyByName(name string) (Strategy, error) {
+	switch name {
+	case "", StrategyRandom:
+		return UniformStrategy{}, nil
+	case StrategyWeightedStars:
+		return WeightedStarsStrategy{}, nil
+	case StrategyLeastRecent:
+		return LeastRecentStrategy{}, nil
+	case StrategyCategoryRoundRobin:
+		return CategoryRoundRobinStrategy{}, nil
+	case StrategyNeverPostedFirst:
+		return NeverPostedFirstStrategy{}, nil
+	default:
+		return nil, fmt.Errorf("unknown selection strategy: %s", name)
+	}
+}
+
+// UniformStrategy picks any available library with equal probability
+type UniformStrategy struct{}
+
+// Pick implements Strategy
+func (UniformStrategy) Pick(available []model.Library, _ []model.PostedLibrary, r *rand.Rand) model.Library {
+	return available[r.Intn(len(available))]
+}
+
+// WeightedStarsStrategy favours popular libraries in proportion to their stars.
+// Every library gets one extra point so those without stars can still be picked.
+type WeightedStarsStrategy struct{}
+
+// Pick implements Strategy
+func (WeightedStarsStrategy) Pick(available []model.Library, _ []model.PostedLibrary, r *rand.Rand) model.Library {
+	total := 0
+	for _, lib := range available {
+		total += starWeight(lib)
+	}
+
+	n := r.Intn(total)
+	for _, lib := range available {
+		n -= starWeight(lib)
+		if n < 0 {
+			return lib
+		}
+	}
+
+	return available[len(available)-1]
+}
+
+func starWeight(lib model.Library) int {
+	if lib.Stars < 0 {
+		return 1
+	}
+	return lib.Stars + 1
+}
+
+// LeastRecentStrategy picks the library whose last post is the oldest.
+// Libraries that were never posted count as oldest; ties are broken randomly.
+type LeastRecentStrategy struct{}
+
+// Pick implements Strategy
+func (LeastRecentStrategy) Pick(available []model.Library, posted []model.PostedLibrary, r *rand.Rand) model.Library {
+	last := lastPostedAt(posted)
+
+	var oldest []model.Library
+	var oldestAt time.Time
+	for _, lib := range available {
+		at := last[lib.Name]
+		switch {
+		case len(oldest) == 0 || at.Before(oldestAt):
+			oldest = []model.Library{lib}
+			oldestAt = at
+		case at.Equal(oldestAt):
+			oldest = append(oldest, lib)
+		}
+	}
+
+	return oldest[r.Intn(len(oldest))]
+}
+
+// CategoryRoundRobinStrategy rotates through categories, taking the category
+// that was posted least recently and picking randomly within it
+type CategoryRoundRobinStrategy struct{}
+
+// Pick implements Strategy
+func (CategoryRoundRobinStrategy) Pick(available []model.Library, posted []model.PostedLibrary, r *rand.Rand) model.Library {
+	lastByCategory := make(map[string]time.Time)
+	for _, p := range posted {
+		if at, exists := lastByCategory[p.Library.Category]; !exists || p.PostedAt.After(at) {
+			lastByCategory[p.Library.Category] = p.PostedAt
+		}
+	}
+
+	byCategory := make(map[string][]model.Library)
+	var categories []string
+	for _, lib := range available {
+		if _, exists := byCategory[lib.Category]; !exists {
+			categories = append(categories, lib.Category)
+		}
+		byCategory[lib.Category] = append(byCategory[lib.Category], lib)
+	}
+
+	// Oldest category first, alphabetical among equals so the rotation is stable
+	sort.Slice(categories, func(i, j int) bool {
+		ai, aj := lastByCategory[categories[i]], lastByCategory[categories[j]]
+		if !ai.Equal(aj) {
+			return ai.Before(aj)
+		}
+		return categories[i] < categories[j]
+	})
+
+	pool := byCategory[categories[0]]
+	return pool[r.Intn(len(pool))]
+}
+
+// NeverPostedFirstStrategy picks randomly among libraries that have never been
+// posted, falling back to the whole pool once every library has had a turn
+type NeverPostedFirstStrategy struct{}
+
+// Pick implements Strategy
+func (NeverPostedFirstStrategy) Pick(available []model.Library, posted []model.PostedLibrary, r *rand.Rand) model.Library {
+	last := lastPostedAt(posted)
+
+	var fresh []model.Library
+	for _, lib := range available {
+		if _, exists := last[lib.Name]; !exists {
+			fresh = append(fresh, lib)
+		}
+	}
+
+	if len(fresh) == 0 {
+		fresh = available
+	}
+	return fresh[r.Intn(len(fresh))]
+}
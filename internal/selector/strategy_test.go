@@ -0,0 +1,182 @@
+package selector
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/model"
+)
+
+var testNow = time.Date(2026, 3, 15, 9, 0, 0, 0, time.UTC)
+
+func testLibrary(name, category string) model.Library {
+	return model.Library{Name: name, Category: category, Enabled: true}
+}
+
+// postedDaysAgo records lib as posted the given number of days before testNow
+func postedDaysAgo(lib model.Library, days int) model.PostedLibrary {
+	return model.PostedLibrary{Library: lib, PostedAt: testNow.AddDate(0, 0, -days)}
+}
+
+// pickCounts runs strategy n times with a seeded source and counts the picks
+func pickCounts(strategy Strategy, available []model.Library, posted []model.PostedLibrary, n int) map[string]int {
+	r := rand.New(rand.NewSource(1))
+	counts := make(map[string]int)
+	for i := 0; i < n; i++ {
+		counts[strategy.Pick(available, posted, r).Name]++
+	}
+	return counts
+}
+
+func TestStrategyByName(t *testing.T) {
+	tests := []struct {
+		name string
+		want Strategy
+	}{
+		{"", UniformStrategy{}},
+		{StrategyRandom, UniformStrategy{}},
+		{StrategyWeightedStars, WeightedStarsStrategy{}},
+		{StrategyLeastRecent, LeastRecentStrategy{}},
+		{StrategyCategoryRoundRobin, CategoryRoundRobinStrategy{}},
+		{StrategyNeverPostedFirst, NeverPostedFirstStrategy{}},
+	}
+
+	for _, tt := range tests {
+		got, err := StrategyByName(tt.name)
+		if err != nil || got != tt.want {
+			t.Errorf("StrategyByName(%q) = %T, %v, want %T", tt.name, got, err, tt.want)
+		}
+	}
+
+	if _, err := StrategyByName("alphabetical"); err == nil {
+		t.Error("StrategyByName accepted an unknown strategy")
+	}
+}
+
+func TestWeightedStarsStrategyFavoursStars(t *testing.T) {
+	popular := testLibrary("popular", "web")
+	popular.Stars = 9
+	obscure := testLibrary("obscure", "web")
+	negative := testLibrary("negative", "web")
+	negative.Stars = -5
+
+	counts := pickCounts(WeightedStarsStrategy{}, []model.Library{popular, obscure, negative}, nil, 12000)
+
+	// Weights are 10, 1 and 1
+	if got := counts["popular"]; got < 9000 || got > 11000 {
+		t.Errorf("popular picked %d of 12000 times, want about 10000", got)
+	}
+	for _, name := range []string{"obscure", "negative"} {
+		if got := counts[name]; got < 600 || got > 1400 {
+			t.Errorf("%s picked %d of 12000 times, want about 1000", name, got)
+		}
+	}
+}
+
+func TestLeastRecentStrategy(t *testing.T) {
+	a, b, c, d := testLibrary("a", "web"), testLibrary("b", "web"), testLibrary("c", "web"), testLibrary("d", "web")
+
+	tests := []struct {
+		name      string
+		available []model.Library
+		posted    []model.PostedLibrary
+		want      []string
+	}{
+		{
+			name:      "oldest post",
+			available: []model.Library{a, b, c},
+			posted:    []model.PostedLibrary{postedDaysAgo(a, 3), postedDaysAgo(b, 30), postedDaysAgo(c, 10)},
+			want:      []string{"b"},
+		},
+		{
+			name:      "latest post of each library counts",
+			available: []model.Library{a, b},
+			posted:    []model.PostedLibrary{postedDaysAgo(a, 90), postedDaysAgo(a, 1), postedDaysAgo(b, 20)},
+			want:      []string{"b"},
+		},
+		{
+			name:      "never posted is oldest",
+			available: []model.Library{a, b, c},
+			posted:    []model.PostedLibrary{postedDaysAgo(a, 300), postedDaysAgo(b, 200)},
+			want:      []string{"c"},
+		},
+		{
+			name:      "ties broken randomly",
+			available: []model.Library{a, b, c, d},
+			posted:    []model.PostedLibrary{postedDaysAgo(a, 5), postedDaysAgo(b, 5)},
+			want:      []string{"c", "d"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertPicks(t, pickCounts(LeastRecentStrategy{}, tt.available, tt.posted, 200), tt.want)
+		})
+	}
+}
+
+func TestCategoryRoundRobinStrategy(t *testing.T) {
+	web1, web2 := testLibrary("web1", "web"), testLibrary("web2", "web")
+	db1, db2 := testLibrary("db1", "database"), testLibrary("db2", "database")
+	cli := testLibrary("cli", "cli")
+
+	tests := []struct {
+		name      string
+		available []model.Library
+		posted    []model.PostedLibrary
+		want      []string
+	}{
+		{
+			name:      "least recently posted category",
+			available: []model.Library{web1, web2, db1, db2},
+			posted:    []model.PostedLibrary{postedDaysAgo(web1, 1), postedDaysAgo(db1, 4)},
+			want:      []string{"db1", "db2"},
+		},
+		{
+			name:      "never posted category first",
+			available: []model.Library{web1, db1, cli},
+			posted:    []model.PostedLibrary{postedDaysAgo(web2, 9), postedDaysAgo(db2, 20)},
+			want:      []string{"cli"},
+		},
+		{
+			name:      "alphabetical among equals",
+			available: []model.Library{web1, db1, cli},
+			want:      []string{"cli"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertPicks(t, pickCounts(CategoryRoundRobinStrategy{}, tt.available, tt.posted, 200), tt.want)
+		})
+	}
+}
+
+func TestNeverPostedFirstStrategy(t *testing.T) {
+	a, b, c := testLibrary("a", "web"), testLibrary("b", "web"), testLibrary("c", "web")
+
+	t.Run("never posted only", func(t *testing.T) {
+		posted := []model.PostedLibrary{postedDaysAgo(a, 400)}
+		assertPicks(t, pickCounts(NeverPostedFirstStrategy{}, []model.Library{a, b, c}, posted, 200), []string{"b", "c"})
+	})
+
+	t.Run("whole pool once all posted", func(t *testing.T) {
+		posted := []model.PostedLibrary{postedDaysAgo(a, 1), postedDaysAgo(b, 2), postedDaysAgo(c, 3)}
+		assertPicks(t, pickCounts(NeverPostedFirstStrategy{}, []model.Library{a, b, c}, posted, 200), []string{"a", "b", "c"})
+	})
+}
+
+// assertPicks checks that every library in want was picked and nothing else
+func assertPicks(t *testing.T, counts map[string]int, want []string) {
+	t.Helper()
+
+	for _, name := range want {
+		if counts[name] == 0 {
+			t.Errorf("%s was never picked; picks %v", name, counts)
+		}
+	}
+	if len(counts) != len(want) {
+		t.Errorf("picked %v, want only %v", counts, want)
+	}
+}
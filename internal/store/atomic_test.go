@@ -0,0 +1,93 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "posted.json")
+
+	for _, content := range []string{"first", "second"} {
+		if err := writeFileAtomic(path, []byte(content), 0600); err != nil {
+			t.Fatalf("writeFileAtomic: %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || string(data) != content {
+			t.Fatalf("file = %q, %v, want %q", data, err, content)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("file mode = %v, want 0600", perm)
+	}
+	assertFiles(t, dir, "posted.json")
+}
+
+func TestWriteFileAtomicLeavesNoTempFileOnFailure(t *testing.T) {
+	dir := t.TempDir()
+
+	// Renaming over a non-empty directory fails after the temp file is written
+	path := filepath.Join(dir, "posted.json")
+	if err := os.MkdirAll(filepath.Join(path, "child"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFileAtomic(path, []byte("data"), 0644); err == nil {
+		t.Fatal("writeFileAtomic over a directory succeeded")
+	}
+	assertFiles(t, dir, "posted.json")
+}
+
+func TestRotateBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "posted.json")
+
+	if err := rotateBackups(path, backupCount); err != nil {
+		t.Fatalf("rotateBackups of a missing file: %v", err)
+	}
+	assertFiles(t, dir)
+
+	for i := 1; i <= 5; i++ {
+		if err := writeFileAtomic(path, []byte(fmt.Sprintf("version %d", i)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := rotateBackups(path, backupCount); err != nil {
+			t.Fatalf("rotateBackups: %v", err)
+		}
+	}
+
+	assertFiles(t, dir, "posted.json", "posted.json.bak", "posted.json.bak.1", "posted.json.bak.2")
+	for n, want := range []string{"version 5", "version 4", "version 3"} {
+		data, err := os.ReadFile(backupName(path, n))
+		if err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v, want %q", backupName(path, n), data, err, want)
+		}
+	}
+}
+
+// assertFiles checks that dir holds exactly the named files
+func assertFiles(t *testing.T, dir string, want ...string) {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, entry := range entries {
+		got = append(got, entry.Name())
+	}
+	sort.Strings(want)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+}
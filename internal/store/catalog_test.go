@@ -0,0 +1,218 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nitin737/GoAutoPosts/internal/model"
+)
+
+// catalogBackends opens an empty catalog of each kind
+var catalogBackends = []struct {
+	name string
+	open func(t *testing.T) LibraryCatalog
+}{
+	{"json", func(t *testing.T) LibraryCatalog {
+		path := filepath.Join(t.TempDir(), "libraries.json")
+		if err := os.WriteFile(path, []byte("[]\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return NewJSONCatalog(path)
+	}},
+	{"sqlite", func(t *testing.T) LibraryCatalog {
+		c, err := NewSQLiteCatalog(filepath.Join(t.TempDir(), "catalog.db"))
+		if err != nil {
+			t.Fatalf("NewSQLiteCatalog: %v", err)
+		}
+		t.Cleanup(func() { c.Close() })
+		return c
+	}},
+}
+
+func testLibrary(name, category string, enabled bool, tags ...string) *model.Library {
+	return &model.Library{
+		Name:        name,
+		Description: name + " for Go.",
+		URL:         "https://github.com/example/" + name,
+		Category:    category,
+		Tags:        tags,
+		Enabled:     enabled,
+	}
+}
+
+// newTestCatalog opens a catalog and fills it with a few libraries
+func newTestCatalog(t *testing.T, open func(t *testing.T) LibraryCatalog) LibraryCatalog {
+	t.Helper()
+
+	c := open(t)
+	for _, lib := range []*model.Library{
+		testLibrary("gin", "Web", true, "http", "router"),
+		testLibrary("cobra", "cli", true, "CLI"),
+		testLibrary("echo", "web", false, "HTTP"),
+		testLibrary("chi", "web", true, "router"),
+	} {
+		if err := c.Add(context.Background(), lib); err != nil {
+			t.Fatalf("Add %s: %v", lib.Name, err)
+		}
+	}
+	return c
+}
+
+func libraryNames(libraries []model.Library) string {
+	var names []string
+	for _, lib := range libraries {
+		names = append(names, lib.Name)
+	}
+	return strings.Join(names, " ")
+}
+
+func TestCatalogAdd(t *testing.T) {
+	for _, backend := range catalogBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := newTestCatalog(t, backend.open)
+
+			if err := c.Add(ctx, testLibrary("gin", "web", true, "http")); err == nil || !strings.Contains(err.Error(), "already exists") {
+				t.Errorf("Add duplicate error = %v, want already exists", err)
+			}
+			if err := c.Add(ctx, &model.Library{Name: "bare"}); err == nil || !strings.Contains(err.Error(), "invalid library") {
+				t.Errorf("Add invalid error = %v, want invalid library", err)
+			}
+
+			all, err := c.GetAll(ctx)
+			if err != nil {
+				t.Fatalf("GetAll: %v", err)
+			}
+			if got, want := libraryNames(all), "gin cobra echo chi"; got != want {
+				t.Errorf("GetAll = %s, want %s", got, want)
+			}
+
+			lib, err := c.GetByName(ctx, "gin")
+			if err != nil {
+				t.Fatalf("GetByName: %v", err)
+			}
+			if lib.Category != "Web" || strings.Join(lib.Tags, ",") != "http,router" || !lib.Enabled || lib.CreatedAt.IsZero() {
+				t.Errorf("GetByName = %+v, want gin as added", lib)
+			}
+		})
+	}
+}
+
+func TestCatalogMissingLibrary(t *testing.T) {
+	changes := []struct {
+		name   string
+		change func(ctx context.Context, c LibraryCatalog) error
+	}{
+		{"GetByName", func(ctx context.Context, c LibraryCatalog) error {
+			_, err := c.GetByName(ctx, "missing")
+			return err
+		}},
+		{"Update", func(ctx context.Context, c LibraryCatalog) error {
+			return c.Update(ctx, testLibrary("missing", "web", true, "http"))
+		}},
+		{"SetEnabled", func(ctx context.Context, c LibraryCatalog) error {
+			return c.SetEnabled(ctx, "missing", false)
+		}},
+		{"Remove", func(ctx context.Context, c LibraryCatalog) error {
+			return c.Remove(ctx, "missing")
+		}},
+	}
+
+	for _, backend := range catalogBackends {
+		for _, tt := range changes {
+			t.Run(backend.name+"/"+tt.name, func(t *testing.T) {
+				ctx := context.Background()
+				c := newTestCatalog(t, backend.open)
+
+				if err := tt.change(ctx, c); err == nil || err.Error() != "library not found: missing" {
+					t.Errorf("%s error = %v, want library not found", tt.name, err)
+				}
+				all, err := c.GetAll(ctx)
+				if err != nil {
+					t.Fatalf("GetAll: %v", err)
+				}
+				if got, want := libraryNames(all), "gin cobra echo chi"; got != want {
+					t.Errorf("catalog = %s after a failed %s, want %s", got, tt.name, want)
+				}
+			})
+		}
+	}
+}
+
+func TestCatalogChanges(t *testing.T) {
+	for _, backend := range catalogBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := newTestCatalog(t, backend.open)
+
+			before, err := c.GetByName(ctx, "gin")
+			if err != nil {
+				t.Fatal(err)
+			}
+			updated := testLibrary("gin", "web", true, "http", "middleware")
+			updated.Stars = 80000
+			if err := c.Update(ctx, updated); err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+			if err := c.SetEnabled(ctx, "cobra", false); err != nil {
+				t.Fatalf("SetEnabled: %v", err)
+			}
+			if err := c.Remove(ctx, "chi"); err != nil {
+				t.Fatalf("Remove: %v", err)
+			}
+
+			gin, err := c.GetByName(ctx, "gin")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gin.Stars != 80000 || !gin.HasTag("middleware") || !gin.CreatedAt.Equal(before.CreatedAt) {
+				t.Errorf("updated gin = %+v, want the new fields and the original creation time", gin)
+			}
+			enabled, err := c.Query(ctx, LibraryQuery{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := libraryNames(enabled), "gin"; got != want {
+				t.Errorf("enabled libraries = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestCatalogQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query LibraryQuery
+		want  string
+	}{
+		{"enabled", LibraryQuery{}, "gin cobra chi"},
+		{"all", LibraryQuery{IncludeDisabled: true}, "gin cobra echo chi"},
+		{"category ignores case", LibraryQuery{Category: "WEB"}, "gin chi"},
+		{"category with disabled", LibraryQuery{Category: "web", IncludeDisabled: true}, "gin echo chi"},
+		{"tag ignores case", LibraryQuery{Tag: "cli"}, "cobra"},
+		{"tag with disabled", LibraryQuery{Tag: "http", IncludeDisabled: true}, "gin echo"},
+		{"category and tag", LibraryQuery{Category: "web", Tag: "router"}, "gin chi"},
+		{"no match", LibraryQuery{Category: "database"}, ""},
+	}
+
+	for _, backend := range catalogBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			c := newTestCatalog(t, backend.open)
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					libraries, err := c.Query(context.Background(), tt.query)
+					if err != nil {
+						t.Fatalf("Query: %v", err)
+					}
+					if got := libraryNames(libraries); got != tt.want {
+						t.Errorf("Query(%+v) = %q, want %q", tt.query, got, tt.want)
+					}
+				})
+			}
+		})
+	}
+}
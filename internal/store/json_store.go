@@ -1,6 +1,7 @@
 package store
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -9,7 +10,10 @@ import (
 	"github.com/nitin737/GoAutoPosts/internal/model"
 )
 
-// JSONStore implements Repository using a JSON file
+// JSONStore implements Repository using a JSON file.
+// Writes replace the file atomically, keep rotating .bak copies and are
+// serialised across processes with an advisory lock on a sidecar .lock file.
+// Reads need no lock because the file is never partially written.
 type JSONStore struct {
 	filePath string
 	mu       sync.RWMutex
@@ -23,15 +27,30 @@ func NewJSONStore(filePath string) *JSONStore {
 }
 
 // Save saves a posted library record
-func (s *JSONStore) Save(posted *model.PostedLibrary) error {
+func (s *JSONStore) Save(ctx context.Context, posted *model.PostedLibrary) error {
+	return s.SaveIf(ctx, posted, func([]model.PostedLibrary) error { return nil })
+}
+
+// SaveIf saves a posted library record unless check rejects the stored
+// records. The file stays locked from the read to the write.
+func (s *JSONStore) SaveIf(ctx context.Context, posted *model.PostedLibrary, check func([]model.PostedLibrary) error) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	lock, err := lockFile(ctx, s.filePath)
+	if err != nil {
+		return err
+	}
+	defer lock.unlock()
+
 	// Load existing records
-	records, err := s.loadRecords()
+	records, err := s.loadRecords(ctx)
 	if err != nil {
 		return err
 	}
+	if err := check(records); err != nil {
+		return err
+	}
 
 	// Append new record
 	records = append(records, *posted)
@@ -40,20 +59,50 @@ func (s *JSONStore) Save(posted *model.PostedLibrary) error {
 	return s.saveRecords(records)
 }
 
+// Update replaces the record with the same ID
+func (s *JSONStore) Update(ctx context.Context, posted *model.PostedLibrary) error {
+	if posted.ID == "" {
+		return fmt.Errorf("cannot update a record without an ID")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lock, err := lockFile(ctx, s.filePath)
+	if err != nil {
+		return err
+	}
+	defer lock.unlock()
+
+	records, err := s.loadRecords(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range records {
+		if records[i].ID == posted.ID {
+			records[i] = *posted
+			return s.saveRecords(records)
+		}
+	}
+
+	return fmt.Errorf("post not found: %s", posted.ID)
+}
+
 // GetAll retrieves all posted library records
-func (s *JSONStore) GetAll() ([]model.PostedLibrary, error) {
+func (s *JSONStore) GetAll(ctx context.Context) ([]model.PostedLibrary, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	return s.loadRecords()
+	return s.loadRecords(ctx)
 }
 
 // GetByName retrieves a posted library by name
-func (s *JSONStore) GetByName(name string) (*model.PostedLibrary, error) {
+func (s *JSONStore) GetByName(ctx context.Context, name string) (*model.PostedLibrary, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	records, err := s.loadRecords()
+	records, err := s.loadRecords(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -67,7 +116,47 @@ func (s *JSONStore) GetByName(name string) (*model.PostedLibrary, error) {
 	return nil, fmt.Errorf("library not found: %s", name)
 }
 
-func (s *JSONStore) loadRecords() ([]model.PostedLibrary, error) {
+// DeleteByName removes every record for a library and returns how many were removed
+func (s *JSONStore) DeleteByName(ctx context.Context, name string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lock, err := lockFile(ctx, s.filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer lock.unlock()
+
+	records, err := s.loadRecords(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	kept := records[:0]
+	for _, record := range records {
+		if record.Library.Name != name {
+			kept = append(kept, record)
+		}
+	}
+
+	removed := len(records) - len(kept)
+	if removed == 0 {
+		return 0, fmt.Errorf("library not found: %s", name)
+	}
+
+	return removed, s.saveRecords(kept)
+}
+
+// Close is a no-op; the file is only open while it is read or written
+func (s *JSONStore) Close() error {
+	return nil
+}
+
+func (s *JSONStore) loadRecords(ctx context.Context) ([]model.PostedLibrary, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	data, err := os.ReadFile(s.filePath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -90,5 +179,9 @@ func (s *JSONStore) saveRecords(records []model.PostedLibrary) error {
 		return err
 	}
 
-	return os.WriteFile(s.filePath, data, 0644)
+	if err := rotateBackups(s.filePath, backupCount); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", s.filePath, err)
+	}
+
+	return writeFileAtomic(s.filePath, data, 0644)
 }
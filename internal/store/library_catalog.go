@@ -0,0 +1,289 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/model"
+)
+
+// LibraryCatalog defines the interface for managing the library catalog
+type LibraryCatalog interface {
+	// GetAll retrieves every library in the catalog, enabled or not
+	GetAll(ctx context.Context) ([]model.Library, error)
+
+	// GetByName retrieves a library by name
+	GetByName(ctx context.Context, name string) (*model.Library, error)
+
+	// Query retrieves the libraries matching q
+	Query(ctx context.Context, q LibraryQuery) ([]model.Library, error)
+
+	// Add adds a new library
+	Add(ctx context.Context, lib *model.Library) error
+
+	// Update replaces an existing library with the same name
+	Update(ctx context.Context, lib *model.Library) error
+
+	// SetEnabled enables or disables a library for selection
+	SetEnabled(ctx context.Context, name string, enabled bool) error
+
+	// Remove deletes a library from the catalog
+	Remove(ctx context.Context, name string) error
+
+	// Close releases any resources held by the catalog
+	Close() error
+}
+
+// LibraryQuery filters catalog queries. Empty fields match everything.
+type LibraryQuery struct {
+	Category        string
+	Tag             string
+	IncludeDisabled bool
+}
+
+// Matches reports whether lib satisfies the query
+func (q LibraryQuery) Matches(lib *model.Library) bool {
+	if !q.IncludeDisabled && !lib.Enabled {
+		return false
+	}
+	if q.Category != "" && !strings.EqualFold(lib.Category, q.Category) {
+		return false
+	}
+	if q.Tag != "" && !lib.HasTag(q.Tag) {
+		return false
+	}
+	return true
+}
+
+// OpenCatalog returns the library catalog for the given backend
+func OpenCatalog(backend, jsonPath, sqlitePath string) (LibraryCatalog, error) {
+	switch backend {
+	case "", BackendJSON:
+		return NewJSONCatalog(jsonPath), nil
+	case BackendSQLite:
+		return NewSQLiteCatalog(sqlitePath)
+	default:
+		return nil, fmt.Errorf("unknown catalog backend: %s", backend)
+	}
+}
+
+// JSONCatalog implements LibraryCatalog using a JSON file. Changes hold the
+// same file lock as JSONStore, so two edits made at once cannot undo each other.
+type JSONCatalog struct {
+	filePath string
+	mu       sync.RWMutex
+}
+
+// NewJSONCatalog creates a new JSON-based library catalog
+func NewJSONCatalog(filePath string) *JSONCatalog {
+	return &JSONCatalog{
+		filePath: filePath,
+	}
+}
+
+// GetAll retrieves every library in the catalog, enabled or not
+func (c *JSONCatalog) GetAll(ctx context.Context) ([]model.Library, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.loadLibraries(ctx)
+}
+
+// GetByName retrieves a library by name
+func (c *JSONCatalog) GetByName(ctx context.Context, name string) (*model.Library, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	libraries, err := c.loadLibraries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if i := indexOfLibrary(libraries, name); i >= 0 {
+		return &libraries[i], nil
+	}
+
+	return nil, fmt.Errorf("library not found: %s", name)
+}
+
+// Query retrieves the libraries matching q
+func (c *JSONCatalog) Query(ctx context.Context, q LibraryQuery) ([]model.Library, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	libraries, err := c.loadLibraries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []model.Library
+	for i := range libraries {
+		if q.Matches(&libraries[i]) {
+			matched = append(matched, libraries[i])
+		}
+	}
+
+	return matched, nil
+}
+
+// Add adds a new library
+func (c *JSONCatalog) Add(ctx context.Context, lib *model.Library) error {
+	if err := lib.Validate(); err != nil {
+		return fmt.Errorf("invalid library %s: %w", lib.Name, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	lock, err := lockFile(ctx, c.filePath)
+	if err != nil {
+		return err
+	}
+	defer lock.unlock()
+
+	libraries, err := c.loadLibraries(ctx)
+	if err != nil {
+		return err
+	}
+
+	if indexOfLibrary(libraries, lib.Name) >= 0 {
+		return fmt.Errorf("library already exists: %s", lib.Name)
+	}
+
+	now := time.Now()
+	if lib.CreatedAt.IsZero() {
+		lib.CreatedAt = now
+	}
+	lib.UpdatedAt = now
+
+	return c.saveLibraries(append(libraries, *lib))
+}
+
+// Update replaces an existing library with the same name
+func (c *JSONCatalog) Update(ctx context.Context, lib *model.Library) error {
+	if err := lib.Validate(); err != nil {
+		return fmt.Errorf("invalid library %s: %w", lib.Name, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	lock, err := lockFile(ctx, c.filePath)
+	if err != nil {
+		return err
+	}
+	defer lock.unlock()
+
+	libraries, err := c.loadLibraries(ctx)
+	if err != nil {
+		return err
+	}
+
+	i := indexOfLibrary(libraries, lib.Name)
+	if i < 0 {
+		return fmt.Errorf("library not found: %s", lib.Name)
+	}
+
+	lib.CreatedAt = libraries[i].CreatedAt
+	lib.UpdatedAt = time.Now()
+	libraries[i] = *lib
+
+	return c.saveLibraries(libraries)
+}
+
+// SetEnabled enables or disables a library for selection
+func (c *JSONCatalog) SetEnabled(ctx context.Context, name string, enabled bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	lock, err := lockFile(ctx, c.filePath)
+	if err != nil {
+		return err
+	}
+	defer lock.unlock()
+
+	libraries, err := c.loadLibraries(ctx)
+	if err != nil {
+		return err
+	}
+
+	i := indexOfLibrary(libraries, name)
+	if i < 0 {
+		return fmt.Errorf("library not found: %s", name)
+	}
+
+	libraries[i].Enabled = enabled
+	libraries[i].UpdatedAt = time.Now()
+
+	return c.saveLibraries(libraries)
+}
+
+// Remove deletes a library from the catalog
+func (c *JSONCatalog) Remove(ctx context.Context, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	lock, err := lockFile(ctx, c.filePath)
+	if err != nil {
+		return err
+	}
+	defer lock.unlock()
+
+	libraries, err := c.loadLibraries(ctx)
+	if err != nil {
+		return err
+	}
+
+	i := indexOfLibrary(libraries, name)
+	if i < 0 {
+		return fmt.Errorf("library not found: %s", name)
+	}
+
+	return c.saveLibraries(append(libraries[:i], libraries[i+1:]...))
+}
+
+// Close is a no-op; the file is only open while it is read or written
+func (c *JSONCatalog) Close() error {
+	return nil
+}
+
+func (c *JSONCatalog) loadLibraries(ctx context.Context) ([]model.Library, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(c.filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var libraries []model.Library
+	if err := json.Unmarshal(data, &libraries); err != nil {
+		return nil, err
+	}
+
+	return libraries, nil
+}
+
+func (c *JSONCatalog) saveLibraries(libraries []model.Library) error {
+	data, err := json.MarshalIndent(libraries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(c.filePath, append(data, '\n'), 0644)
+}
+
+func indexOfLibrary(libraries []model.Library, name string) int {
+	for i, lib := range libraries {
+		if lib.Name == name {
+			return i
+		}
+	}
+	return -1
+}
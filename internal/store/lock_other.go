@@ -0,0 +1,17 @@
+//go:build !unix
+
+package store
+
+import "context"
+
+// fileLock is a no-op on platforms without flock; the in-process mutex is
+// the only protection there
+type fileLock struct{}
+
+func lockFile(ctx context.Context, path string) (*fileLock, error) {
+	return &fileLock{}, nil
+}
+
+func (l *fileLock) unlock() error {
+	return nil
+}
@@ -0,0 +1,56 @@
+//go:build unix
+
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// lockTimeout bounds how long a process waits for another one to finish
+const lockTimeout = 30 * time.Second
+
+// fileLock is an advisory lock on path.lock, shared by every process that
+// goes through a JSON store
+type fileLock struct {
+	f *os.File
+}
+
+// lockFile takes an exclusive lock on path.lock, waiting up to lockTimeout,
+// or until ctx is done, for other processes to release it. Only writers lock;
+// readers never see a partial file.
+func lockFile(ctx context.Context, path string) (*fileLock, error) {
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			return &fileLock{f: f}, nil
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) || time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-ctx.Done():
+			f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", path, ctx.Err())
+		}
+	}
+}
+
+// unlock releases the lock
+func (l *fileLock) unlock() error {
+	defer l.f.Close()
+	return syscall.Flock(int(l.f.Fd()), syscall.LOCK_UN)
+}
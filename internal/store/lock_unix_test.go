@@ -0,0 +1,72 @@
+//go:build unix
+
+package store
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockFileTimesOutWhileHeld(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "posted.json")
+
+	held, err := lockFile(context.Background(), path)
+	if err != nil {
+		t.Fatalf("lockFile: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	if lock, err := lockFile(ctx, path); !errors.Is(err, context.DeadlineExceeded) {
+		if lock != nil {
+			lock.unlock()
+		}
+		t.Fatalf("second lockFile = %v, want it to time out while the first lock is held", err)
+	}
+
+	if err := held.unlock(); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	lock, err := lockFile(context.Background(), path)
+	if err != nil {
+		t.Fatalf("lockFile after unlock: %v", err)
+	}
+	lock.unlock()
+}
+
+func TestLockFileWaitsForRelease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "posted.json")
+
+	held, err := lockFile(context.Background(), path)
+	if err != nil {
+		t.Fatalf("lockFile: %v", err)
+	}
+
+	acquired := make(chan error, 1)
+	go func() {
+		lock, err := lockFile(context.Background(), path)
+		if err == nil {
+			lock.unlock()
+		}
+		acquired <- err
+	}()
+
+	select {
+	case err := <-acquired:
+		t.Fatalf("second lockFile returned %v while the first lock was held", err)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	held.unlock()
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Fatalf("second lockFile: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("second lockFile still waiting after the first lock was released")
+	}
+}
@@ -0,0 +1,198 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// migration is a versioned schema change for the SQLite database
+type migration struct {
+	version     int
+	description string
+	query       string
+}
+
+// migrations are applied in order and recorded in schema_migrations.
+// Never edit a released migration; append a new one instead.
+var migrations = []migration{
+	{
+		version:     1,
+		description: "allow many posts per library",
+		// Databases created before migrations have posted_libraries with a
+		// UNIQUE name; fresh ones get that table first so both paths rebuild
+		// it the same way.
+		query: `
+		CREATE TABLE IF NOT EXISTS posted_libraries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			library_data TEXT NOT NULL,
+			posted_at DATETIME NOT NULL,
+			post_id TEXT,
+			image_path TEXT
+		);
+		CREATE TABLE posted_libraries_v1 (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			library_data TEXT NOT NULL,
+			posted_at DATETIME NOT NULL,
+			post_id TEXT,
+			image_path TEXT
+		);
+		INSERT INTO posted_libraries_v1 (id, name, library_data, posted_at, post_id, image_path)
+			SELECT id, name, library_data, posted_at, post_id, image_path FROM posted_libraries;
+		DROP TABLE posted_libraries;
+		ALTER TABLE posted_libraries_v1 RENAME TO posted_libraries;
+		CREATE INDEX idx_posted_at ON posted_libraries(posted_at);
+		CREATE INDEX idx_name ON posted_libraries(name);
+		`,
+	},
+	{
+		version:     2,
+		description: "create library catalog",
+		query: `
+		CREATE TABLE IF NOT EXISTS libraries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			description TEXT NOT NULL,
+			url TEXT NOT NULL,
+			category TEXT NOT NULL,
+			tags TEXT NOT NULL,
+			stars INTEGER NOT NULL DEFAULT 0,
+			author TEXT NOT NULL DEFAULT '',
+			enabled BOOLEAN NOT NULL DEFAULT 1,
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_libraries_category ON libraries(category);
+		`,
+	},
+	{
+		version:     3,
+		description: "track post lifecycle",
+		query: `
+		ALTER TABLE posted_libraries ADD COLUMN record_id TEXT;
+		ALTER TABLE posted_libraries ADD COLUMN status TEXT NOT NULL DEFAULT 'published';
+		ALTER TABLE posted_libraries ADD COLUMN last_step TEXT NOT NULL DEFAULT '';
+		ALTER TABLE posted_libraries ADD COLUMN error TEXT NOT NULL DEFAULT '';
+		ALTER TABLE posted_libraries ADD COLUMN caption TEXT NOT NULL DEFAULT '';
+		ALTER TABLE posted_libraries ADD COLUMN image_paths TEXT NOT NULL DEFAULT '[]';
+		ALTER TABLE posted_libraries ADD COLUMN child_ids TEXT NOT NULL DEFAULT '[]';
+		ALTER TABLE posted_libraries ADD COLUMN container_id TEXT NOT NULL DEFAULT '';
+		CREATE UNIQUE INDEX idx_record_id ON posted_libraries(record_id);
+		`,
+	},
+	{
+		version:     4,
+		description: "add post format",
+		query: `
+		ALTER TABLE posted_libraries ADD COLUMN format TEXT NOT NULL DEFAULT '';
+		ALTER TABLE posted_libraries ADD COLUMN video_path TEXT NOT NULL DEFAULT '';
+		`,
+	},
+	{
+		version:     5,
+		description: "add story id",
+		query: `
+		ALTER TABLE posted_libraries ADD COLUMN story_id TEXT NOT NULL DEFAULT '';
+		`,
+	},
+	{
+		version:     6,
+		description: "store access token",
+		query: `
+		CREATE TABLE IF NOT EXISTS access_tokens (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			token TEXT NOT NULL,
+			expires_at DATETIME,
+			refreshed_at DATETIME NOT NULL,
+			scopes TEXT NOT NULL DEFAULT '[]'
+		);
+		`,
+	},
+	{
+		version:     7,
+		description: "record the seed of the access token",
+		query: `
+		ALTER TABLE access_tokens ADD COLUMN seed_hash TEXT NOT NULL DEFAULT '';
+		`,
+	},
+	{
+		version:     8,
+		description: "add remote ids per platform",
+		query: `
+		ALTER TABLE posted_libraries ADD COLUMN remote_ids TEXT NOT NULL DEFAULT '{}';
+		`,
+	},
+}
+
+// migrate applies every migration that has not been recorded yet, each in
+// its own transaction
+func migrate(db *sql.DB) error {
+	_, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		description TEXT NOT NULL,
+		applied_at DATETIME NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if applied[m.version] {
+			continue
+		}
+		if err := applyMigration(db, m); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.version, m.description, err)
+		}
+	}
+
+	return nil
+}
+
+func appliedVersions(db *sql.DB) (map[int]bool, error) {
+	rows, err := db.Query(`SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+
+	return applied, rows.Err()
+}
+
+func applyMigration(db *sql.DB, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.query); err != nil {
+		return err
+	}
+
+	// The primary key makes a concurrent run of the same migration fail here
+	// instead of applying it twice
+	_, err = tx.Exec(`INSERT INTO schema_migrations (version, description, applied_at) VALUES (?, ?, ?)`,
+		m.version, m.description, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
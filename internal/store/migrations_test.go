@@ -0,0 +1,108 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/model"
+)
+
+// createLegacyDatabase writes the schema used before migrations, where each
+// library could only be posted once, with one post of gin in it
+func createLegacyDatabase(t *testing.T, path string) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`
+	CREATE TABLE posted_libraries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		library_data TEXT NOT NULL,
+		posted_at DATETIME NOT NULL,
+		post_id TEXT,
+		image_path TEXT
+	);
+	INSERT INTO posted_libraries (name, library_data, posted_at, post_id, image_path)
+	VALUES ('gin', '{"name":"gin","category":"web"}', '2025-01-10 09:00:00+00:00', '17841', 'output/gin.png');
+	`)
+	if err != nil {
+		t.Fatalf("failed to create legacy database: %v", err)
+	}
+}
+
+func TestMigrateLegacyDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "posted.db")
+	createLegacyDatabase(t, path)
+
+	s, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	defer s.Close()
+
+	applied, err := appliedVersions(s.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range migrations {
+		if !applied[m.version] {
+			t.Errorf("migration %d (%s) not recorded", m.version, m.description)
+		}
+	}
+
+	ctx := context.Background()
+	gin := model.Library{Name: "gin", Category: "web"}
+	for _, now := range []time.Time{
+		time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC),
+		time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC),
+	} {
+		if err := s.Save(ctx, model.NewPost(&gin, now)); err != nil {
+			t.Fatalf("Save of another gin post: %v", err)
+		}
+	}
+
+	posts, err := s.GetAll(ctx)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if len(posts) != 3 {
+		t.Fatalf("GetAll returned %d posts, want the legacy one and 2 new", len(posts))
+	}
+	legacy := posts[2] // newest first
+	if legacy.PostID != "17841" || legacy.Status != model.PostStatusPublished || legacy.ID != "" {
+		t.Errorf("legacy post = %+v, want published 17841 without a record ID", legacy)
+	}
+
+	latest, err := s.GetByName(ctx, "gin")
+	if err != nil {
+		t.Fatalf("GetByName: %v", err)
+	}
+	if !latest.PostedAt.Equal(time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("GetByName returned the post of %s, want the latest", latest.PostedAt)
+	}
+}
+
+func TestMigrateTwice(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "posted.db")
+	createLegacyDatabase(t, path)
+
+	for run := 0; run < 2; run++ {
+		s, err := NewSQLiteStore(path)
+		if err != nil {
+			t.Fatalf("run %d: NewSQLiteStore: %v", run, err)
+		}
+		posts, err := s.GetAll(context.Background())
+		s.Close()
+		if err != nil || len(posts) != 1 {
+			t.Fatalf("run %d: GetAll = %d posts, %v, want the legacy one", run, len(posts), err)
+		}
+	}
+}
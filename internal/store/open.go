@@ -0,0 +1,21 @@
+package store
+
+import "fmt"
+
+// Storage backends accepted by Open
+const (
+	BackendJSON   = "json"
+	BackendSQLite = "sqlite"
+)
+
+// Open returns the posted history repository for the given backend
+func Open(backend, jsonPath, sqlitePath string) (Repository, error) {
+	switch backend {
+	case "", BackendJSON:
+		return NewJSONStore(jsonPath), nil
+	case BackendSQLite:
+		return NewSQLiteStore(sqlitePath)
+	default:
+		return nil, fmt.Errorf("unknown store backend: %s", backend)
+	}
+}
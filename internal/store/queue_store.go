@@ -0,0 +1,135 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/nitin737/GoAutoPosts/internal/model"
+)
+
+// JSONQueueStore keeps the editorial queue in a JSON file, in queue order.
+// Changes hold the same file lock as JSONStore, so a publish removing its
+// entry cannot lose an entry added by hand at the same time.
+type JSONQueueStore struct {
+	filePath string
+	mu       sync.RWMutex
+}
+
+// NewJSONQueueStore creates a new JSON-based queue store
+func NewJSONQueueStore(filePath string) *JSONQueueStore {
+	return &JSONQueueStore{
+		filePath: filePath,
+	}
+}
+
+// List returns the queue in order
+func (s *JSONQueueStore) List(ctx context.Context) ([]model.QueueEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.loadEntries(ctx)
+}
+
+// Add appends an entry to the end of the queue
+func (s *JSONQueueStore) Add(ctx context.Context, entry *model.QueueEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lock, err := lockFile(ctx, s.filePath)
+	if err != nil {
+		return err
+	}
+	defer lock.unlock()
+
+	entries, err := s.loadEntries(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.saveEntries(append(entries, *entry))
+}
+
+// RemoveEntry removes the first entry with the same library and date
+func (s *JSONQueueStore) RemoveEntry(ctx context.Context, entry *model.QueueEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lock, err := lockFile(ctx, s.filePath)
+	if err != nil {
+		return err
+	}
+	defer lock.unlock()
+
+	entries, err := s.loadEntries(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i, e := range entries {
+		if e.Library == entry.Library && e.Date == entry.Date {
+			return s.saveEntries(append(entries[:i], entries[i+1:]...))
+		}
+	}
+
+	return fmt.Errorf("queue entry not found: %s %s", entry.Library, entry.Date)
+}
+
+// Move moves the entry at position from to position to (both 1-based)
+func (s *JSONQueueStore) Move(ctx context.Context, from, to int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lock, err := lockFile(ctx, s.filePath)
+	if err != nil {
+		return err
+	}
+	defer lock.unlock()
+
+	entries, err := s.loadEntries(ctx)
+	if err != nil {
+		return err
+	}
+
+	if from < 1 || from > len(entries) || to < 1 || to > len(entries) {
+		return fmt.Errorf("position out of range: queue has %d entries", len(entries))
+	}
+
+	entry := entries[from-1]
+	entries = append(entries[:from-1], entries[from:]...)
+	entries = append(entries[:to-1], append([]model.QueueEntry{entry}, entries[to-1:]...)...)
+
+	return s.saveEntries(entries)
+}
+
+func (s *JSONQueueStore) loadEntries(ctx context.Context) ([]model.QueueEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(s.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []model.QueueEntry{}, nil
+		}
+		return nil, err
+	}
+
+	var entries []model.QueueEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (s *JSONQueueStore) saveEntries(entries []model.QueueEntry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.filePath, data, 0644)
+}
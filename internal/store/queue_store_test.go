@@ -0,0 +1,149 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nitin737/GoAutoPosts/internal/model"
+)
+
+// queueOrder returns the entries of q as library@date, in order
+func queueOrder(t *testing.T, q *JSONQueueStore) string {
+	t.Helper()
+
+	entries, err := q.List(context.Background())
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Library+"@"+e.Date)
+	}
+	return strings.Join(names, " ")
+}
+
+func newTestQueue(t *testing.T, entries ...model.QueueEntry) *JSONQueueStore {
+	t.Helper()
+
+	q := NewJSONQueueStore(filepath.Join(t.TempDir(), "queue.json"))
+	for i := range entries {
+		if err := q.Add(context.Background(), &entries[i]); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	return q
+}
+
+func TestQueueAddKeepsOrder(t *testing.T) {
+	q := newTestQueue(t)
+	if got := queueOrder(t, q); got != "" {
+		t.Fatalf("new queue = %q, want empty", got)
+	}
+
+	for _, e := range []model.QueueEntry{{Library: "gin", Date: "2026-03-20"}, {Library: "cobra"}, {Library: "gin"}} {
+		if err := q.Add(context.Background(), &e); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+	if got, want := queueOrder(t, q), "gin@2026-03-20 cobra@ gin@"; got != want {
+		t.Errorf("queue = %q, want %q", got, want)
+	}
+
+	// A second store on the same file sees the same queue
+	if got, want := queueOrder(t, NewJSONQueueStore(q.filePath)), "gin@2026-03-20 cobra@ gin@"; got != want {
+		t.Errorf("queue reopened = %q, want %q", got, want)
+	}
+}
+
+func TestQueueRemoveEntry(t *testing.T) {
+	tests := []struct {
+		name    string
+		remove  model.QueueEntry
+		want    string
+		wantErr bool
+	}{
+		{"pinned entry", model.QueueEntry{Library: "gin", Date: "2026-03-20"}, "cobra@ gin@ cobra@", false},
+		{"undated entry", model.QueueEntry{Library: "gin"}, "gin@2026-03-20 cobra@ cobra@", false},
+		{"first of duplicates", model.QueueEntry{Library: "cobra"}, "gin@2026-03-20 gin@ cobra@", false},
+		{"date must match", model.QueueEntry{Library: "gin", Date: "2026-03-21"}, "gin@2026-03-20 cobra@ gin@ cobra@", true},
+		{"missing library", model.QueueEntry{Library: "echo"}, "gin@2026-03-20 cobra@ gin@ cobra@", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newTestQueue(t,
+				model.QueueEntry{Library: "gin", Date: "2026-03-20"},
+				model.QueueEntry{Library: "cobra"},
+				model.QueueEntry{Library: "gin"},
+				model.QueueEntry{Library: "cobra"},
+			)
+
+			err := q.RemoveEntry(context.Background(), &tt.remove)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RemoveEntry error = %v, want error %v", err, tt.wantErr)
+			}
+			if got := queueOrder(t, q); got != tt.want {
+				t.Errorf("queue = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueueMove(t *testing.T) {
+	tests := []struct {
+		from, to int
+		want     string
+		wantErr  bool
+	}{
+		{1, 3, "b@ c@ a@ d@", false},
+		{4, 1, "d@ a@ b@ c@", false},
+		{2, 2, "a@ b@ c@ d@", false},
+		{0, 1, "a@ b@ c@ d@", true},
+		{1, 5, "a@ b@ c@ d@", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d to %d", tt.from, tt.to), func(t *testing.T) {
+			q := newTestQueue(t, model.QueueEntry{Library: "a"}, model.QueueEntry{Library: "b"}, model.QueueEntry{Library: "c"}, model.QueueEntry{Library: "d"})
+
+			err := q.Move(context.Background(), tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Move error = %v, want error %v", err, tt.wantErr)
+			}
+			if got := queueOrder(t, q); got != tt.want {
+				t.Errorf("queue = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueueConcurrentWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+
+	// Separate stores share only the file, as separate processes do
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			entry := model.QueueEntry{Library: fmt.Sprintf("lib-%02d", i)}
+			if err := NewJSONQueueStore(path).Add(context.Background(), &entry); err != nil {
+				t.Errorf("Add: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	entries, err := NewJSONQueueStore(path).List(context.Background())
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(entries) != 20 {
+		t.Errorf("queue has %d entries after 20 concurrent adds; some were lost", len(entries))
+	}
+	assertFiles(t, filepath.Dir(path), "queue.json", "queue.json.lock")
+}
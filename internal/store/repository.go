@@ -1,15 +1,33 @@
 package store
 
-import "github.com/nitin737/GoAutoPosts/internal/model"
+import (
+	"context"
+
+	"github.com/nitin737/GoAutoPosts/internal/model"
+)
 
 // Repository defines the interface for storing posted library history
 type Repository interface {
 	// Save saves a posted library record
-	Save(posted *model.PostedLibrary) error
+	Save(ctx context.Context, posted *model.PostedLibrary) error
+
+	// SaveIf saves a posted library record unless check, given every record
+	// already stored, returns an error. No other writer can save between the
+	// check and the save.
+	SaveIf(ctx context.Context, posted *model.PostedLibrary, check func([]model.PostedLibrary) error) error
+
+	// Update replaces the record with the same ID
+	Update(ctx context.Context, posted *model.PostedLibrary) error
 
 	// GetAll retrieves all posted library records
-	GetAll() ([]model.PostedLibrary, error)
+	GetAll(ctx context.Context) ([]model.PostedLibrary, error)
 
 	// GetByName retrieves a posted library by name
-	GetByName(name string) (*model.PostedLibrary, error)
+	GetByName(ctx context.Context, name string) (*model.PostedLibrary, error)
+
+	// DeleteByName removes every record for a library and returns how many were removed
+	DeleteByName(ctx context.Context, name string) (int, error)
+
+	// Close releases any resources held by the repository
+	Close() error
 }
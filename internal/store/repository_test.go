@@ -0,0 +1,109 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/model"
+)
+
+var errAlreadySaved = errors.New("already saved")
+
+// rejectLibrary is a SaveIf check that fails when name is already stored
+func rejectLibrary(name string) func([]model.PostedLibrary) error {
+	return func(records []model.PostedLibrary) error {
+		for _, record := range records {
+			if record.Library.Name == name {
+				return fmt.Errorf("%w: %s", errAlreadySaved, name)
+			}
+		}
+		return nil
+	}
+}
+
+func TestSaveIf(t *testing.T) {
+	for _, backend := range []string{BackendJSON, BackendSQLite} {
+		t.Run(backend, func(t *testing.T) {
+			ctx := context.Background()
+			dir := t.TempDir()
+			s, err := Open(backend, filepath.Join(dir, "posted.json"), filepath.Join(dir, "posted.db"))
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			defer s.Close()
+
+			now := time.Date(2026, 3, 15, 9, 0, 0, 0, time.UTC)
+			gin := &model.Library{Name: "gin"}
+			if err := s.SaveIf(ctx, model.NewPost(gin, now), rejectLibrary("gin")); err != nil {
+				t.Fatalf("SaveIf on an empty store: %v", err)
+			}
+			if err := s.SaveIf(ctx, model.NewPost(gin, now.Add(time.Hour)), rejectLibrary("gin")); !errors.Is(err, errAlreadySaved) {
+				t.Fatalf("SaveIf error = %v, want the check's error", err)
+			}
+			if err := s.SaveIf(ctx, model.NewPost(&model.Library{Name: "echo"}, now.Add(2*time.Hour)), rejectLibrary("echo")); err != nil {
+				t.Fatalf("SaveIf of another library: %v", err)
+			}
+
+			records, err := s.GetAll(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != 2 {
+				t.Errorf("stored %d records, want 2 without the rejected one", len(records))
+			}
+		})
+	}
+}
+
+func TestSaveIfConcurrentWriters(t *testing.T) {
+	for _, backend := range []string{BackendJSON, BackendSQLite} {
+		t.Run(backend, func(t *testing.T) {
+			dir := t.TempDir()
+
+			// Separate stores share only the file, as separate processes do
+			stores := make([]Repository, 10)
+			for i := range stores {
+				s, err := Open(backend, filepath.Join(dir, "posted.json"), filepath.Join(dir, "posted.db"))
+				if err != nil {
+					t.Fatalf("Open: %v", err)
+				}
+				defer s.Close()
+				stores[i] = s
+			}
+
+			var wg sync.WaitGroup
+			errs := make([]error, len(stores))
+			for i, s := range stores {
+				wg.Add(1)
+				go func(i int, s Repository) {
+					defer wg.Done()
+					posted := model.NewPost(&model.Library{Name: "gin"}, time.Now().Add(time.Duration(i)*time.Second))
+					errs[i] = s.SaveIf(context.Background(), posted, rejectLibrary("gin"))
+				}(i, s)
+			}
+			wg.Wait()
+
+			saved := 0
+			for _, err := range errs {
+				switch {
+				case err == nil:
+					saved++
+				case !errors.Is(err, errAlreadySaved):
+					t.Errorf("SaveIf error = %v, want %v", err, errAlreadySaved)
+				}
+			}
+			records, err := stores[0].GetAll(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if saved != 1 || len(records) != 1 {
+				t.Errorf("%d writers saved and %d records stored, want exactly 1", saved, len(records))
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/nitin737/GoAutoPosts/internal/model"
+)
+
+// SQLiteCatalog implements LibraryCatalog using SQLite
+type SQLiteCatalog struct {
+	db *sql.DB
+}
+
+// NewSQLiteCatalog creates a new SQLite-based library catalog
+func NewSQLiteCatalog(dbPath string) (*SQLiteCatalog, error) {
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := &SQLiteCatalog{db: db}
+	if err := catalog.initialize(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return catalog, nil
+}
+
+func (c *SQLiteCatalog) initialize() error {
+	return migrate(c.db)
+}
+
+const libraryColumns = `name, description, url, category, tags, stars, author, enabled, created_at, updated_at`
+
+// GetAll retrieves every library in the catalog, enabled or not
+func (c *SQLiteCatalog) GetAll(ctx context.Context) ([]model.Library, error) {
+	return c.queryLibraries(ctx, `SELECT `+libraryColumns+` FROM libraries ORDER BY id`, LibraryQuery{IncludeDisabled: true})
+}
+
+// GetByName retrieves a library by name
+func (c *SQLiteCatalog) GetByName(ctx context.Context, name string) (*model.Library, error) {
+	libraries, err := c.queryLibraries(ctx, `SELECT `+libraryColumns+` FROM libraries WHERE name = ?`, LibraryQuery{IncludeDisabled: true}, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(libraries) == 0 {
+		return nil, fmt.Errorf("library not found: %s", name)
+	}
+
+	return &libraries[0], nil
+}
+
+// Query retrieves the libraries matching q
+func (c *SQLiteCatalog) Query(ctx context.Context, q LibraryQuery) ([]model.Library, error) {
+	if q.Category != "" {
+		return c.queryLibraries(ctx, `SELECT `+libraryColumns+` FROM libraries WHERE category = ? COLLATE NOCASE ORDER BY id`, q, q.Category)
+	}
+	return c.queryLibraries(ctx, `SELECT `+libraryColumns+` FROM libraries ORDER BY id`, q)
+}
+
+// Add adds a new library
+func (c *SQLiteCatalog) Add(ctx context.Context, lib *model.Library) error {
+	if err := lib.Validate(); err != nil {
+		return fmt.Errorf("invalid library %s: %w", lib.Name, err)
+	}
+
+	tags, err := json.Marshal(lib.Tags)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if lib.CreatedAt.IsZero() {
+		lib.CreatedAt = now
+	}
+	lib.UpdatedAt = now
+
+	query := `
+	INSERT INTO libraries (` + libraryColumns + `)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(name) DO NOTHING
+	`
+
+	result, err := c.db.ExecContext(ctx, query,
+		lib.Name,
+		lib.Description,
+		lib.URL,
+		lib.Category,
+		string(tags),
+		lib.Stars,
+		lib.Author,
+		lib.Enabled,
+		lib.CreatedAt,
+		lib.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return expectOneRow(result, "library already exists: %s", lib.Name)
+}
+
+// Update replaces an existing library with the same name
+func (c *SQLiteCatalog) Update(ctx context.Context, lib *model.Library) error {
+	if err := lib.Validate(); err != nil {
+		return fmt.Errorf("invalid library %s: %w", lib.Name, err)
+	}
+
+	tags, err := json.Marshal(lib.Tags)
+	if err != nil {
+		return err
+	}
+
+	lib.UpdatedAt = time.Now()
+
+	query := `
+	UPDATE libraries
+	SET description = ?, url = ?, category = ?, tags = ?, stars = ?, author = ?, enabled = ?, updated_at = ?
+	WHERE name = ?
+	`
+
+	result, err := c.db.ExecContext(ctx, query,
+		lib.Description,
+		lib.URL,
+		lib.Category,
+		string(tags),
+		lib.Stars,
+		lib.Author,
+		lib.Enabled,
+		lib.UpdatedAt,
+		lib.Name,
+	)
+	if err != nil {
+		return err
+	}
+
+	return expectOneRow(result, "library not found: %s", lib.Name)
+}
+
+// SetEnabled enables or disables a library for selection
+func (c *SQLiteCatalog) SetEnabled(ctx context.Context, name string, enabled bool) error {
+	result, err := c.db.ExecContext(ctx, `UPDATE libraries SET enabled = ?, updated_at = ? WHERE name = ?`, enabled, time.Now(), name)
+	if err != nil {
+		return err
+	}
+
+	return expectOneRow(result, "library not found: %s", name)
+}
+
+// Remove deletes a library from the catalog
+func (c *SQLiteCatalog) Remove(ctx context.Context, name string) error {
+	result, err := c.db.ExecContext(ctx, `DELETE FROM libraries WHERE name = ?`, name)
+	if err != nil {
+		return err
+	}
+
+	return expectOneRow(result, "library not found: %s", name)
+}
+
+// Close closes the database connection
+func (c *SQLiteCatalog) Close() error {
+	return c.db.Close()
+}
+
+// queryLibraries runs query and keeps the rows that match q
+func (c *SQLiteCatalog) queryLibraries(ctx context.Context, query string, q LibraryQuery, args ...interface{}) ([]model.Library, error) {
+	rows, err := c.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var libraries []model.Library
+	for rows.Next() {
+		var lib model.Library
+		var tags string
+
+		if err := rows.Scan(
+			&lib.Name,
+			&lib.Description,
+			&lib.URL,
+			&lib.Category,
+			&tags,
+			&lib.Stars,
+			&lib.Author,
+			&lib.Enabled,
+			&lib.CreatedAt,
+			&lib.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal([]byte(tags), &lib.Tags); err != nil {
+			return nil, err
+		}
+
+		if q.Matches(&lib) {
+			libraries = append(libraries, lib)
+		}
+	}
+
+	return libraries, rows.Err()
+}
+
+// expectOneRow turns a statement that touched no rows into an error
+func expectOneRow(result sql.Result, format, name string) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf(format, name)
+	}
+	return nil
+}
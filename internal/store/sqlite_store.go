@@ -1,6 +1,7 @@
 package store
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -23,6 +24,7 @@ func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
 
 	store := &SQLiteStore{db: db}
 	if err := store.initialize(); err != nil {
+		db.Close()
 		return nil, err
 	}
 
@@ -30,51 +32,144 @@ func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
 }
 
 func (s *SQLiteStore) initialize() error {
+	return migrate(s.db)
+}
+
+const postColumns = `record_id, library_data, posted_at, post_id, image_path, status, last_step, error, caption, image_paths, child_ids, container_id, format, video_path, story_id, remote_ids`
+
+// selectPosts reads the record_id column through COALESCE because rows
+// written before post states existed have none
+const selectPosts = `SELECT COALESCE(record_id, ''), library_data, posted_at, COALESCE(post_id, ''), COALESCE(image_path, ''),
+	status, last_step, error, caption, image_paths, child_ids, container_id, format, video_path, story_id, remote_ids FROM posted_libraries`
+
+// Save saves a posted library record
+func (s *SQLiteStore) Save(ctx context.Context, posted *model.PostedLibrary) error {
+	return insertPost(ctx, s.db, posted)
+}
+
+// SaveIf saves a posted library record unless check rejects the stored
+// records. BEGIN IMMEDIATE takes the write lock before the read, so a second
+// writer waits until the record is saved and then sees it.
+func (s *SQLiteStore) SaveIf(ctx context.Context, posted *model.PostedLibrary, check func([]model.PostedLibrary) error) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `BEGIN IMMEDIATE`); err != nil {
+		return err
+	}
+	// Like sql.Tx.Rollback, this fails harmlessly once committed
+	defer conn.ExecContext(context.WithoutCancel(ctx), `ROLLBACK`)
+
+	records, err := queryPosts(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if err := check(records); err != nil {
+		return err
+	}
+	if err := insertPost(ctx, conn, posted); err != nil {
+		return err
+	}
+
+	_, err = conn.ExecContext(ctx, `COMMIT`)
+	return err
+}
+
+// execQuerier is satisfied by both *sql.DB and *sql.Conn
+type execQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func insertPost(ctx context.Context, db execQuerier, posted *model.PostedLibrary) error {
+	libraryData, imagePaths, childIDs, remoteIDs, err := marshalPost(posted)
+	if err != nil {
+		return err
+	}
+
 	query := `
-	CREATE TABLE IF NOT EXISTS posted_libraries (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT NOT NULL UNIQUE,
-		library_data TEXT NOT NULL,
-		posted_at DATETIME NOT NULL,
-		post_id TEXT,
-		image_path TEXT
-	);
-	CREATE INDEX IF NOT EXISTS idx_posted_at ON posted_libraries(posted_at);
-	CREATE INDEX IF NOT EXISTS idx_name ON posted_libraries(name);
+	INSERT INTO posted_libraries (name, ` + postColumns + `)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := s.db.Exec(query)
+	_, err = db.ExecContext(ctx, query,
+		posted.Library.Name,
+		nullableID(posted.ID),
+		libraryData,
+		posted.PostedAt.UTC(),
+		posted.PostID,
+		posted.ImagePath,
+		postStatus(posted),
+		posted.LastStep,
+		posted.Error,
+		posted.Caption,
+		imagePaths,
+		childIDs,
+		posted.ContainerID,
+		posted.Format,
+		posted.VideoPath,
+		posted.StoryID,
+		remoteIDs,
+	)
+
 	return err
 }
 
-// Save saves a posted library record
-func (s *SQLiteStore) Save(posted *model.PostedLibrary) error {
-	libraryData, err := json.Marshal(posted.Library)
+// Update replaces the record with the same ID
+func (s *SQLiteStore) Update(ctx context.Context, posted *model.PostedLibrary) error {
+	if posted.ID == "" {
+		return fmt.Errorf("cannot update a record without an ID")
+	}
+
+	libraryData, imagePaths, childIDs, remoteIDs, err := marshalPost(posted)
 	if err != nil {
 		return err
 	}
 
 	query := `
-	INSERT INTO posted_libraries (name, library_data, posted_at, post_id, image_path)
-	VALUES (?, ?, ?, ?, ?)
+	UPDATE posted_libraries
+	SET name = ?, library_data = ?, posted_at = ?, post_id = ?, image_path = ?, status = ?,
+		last_step = ?, error = ?, caption = ?, image_paths = ?, child_ids = ?, container_id = ?,
+		format = ?, video_path = ?, story_id = ?, remote_ids = ?
+	WHERE record_id = ?
 	`
 
-	_, err = s.db.Exec(query,
+	result, err := s.db.ExecContext(ctx, query,
 		posted.Library.Name,
-		string(libraryData),
-		posted.PostedAt,
+		libraryData,
+		posted.PostedAt.UTC(),
 		posted.PostID,
 		posted.ImagePath,
+		postStatus(posted),
+		posted.LastStep,
+		posted.Error,
+		posted.Caption,
+		imagePaths,
+		childIDs,
+		posted.ContainerID,
+		posted.Format,
+		posted.VideoPath,
+		posted.StoryID,
+		remoteIDs,
+		posted.ID,
 	)
+	if err != nil {
+		return err
+	}
 
-	return err
+	return expectOneRow(result, "post not found: %s", posted.ID)
 }
 
 // GetAll retrieves all posted library records
-func (s *SQLiteStore) GetAll() ([]model.PostedLibrary, error) {
-	query := `SELECT library_data, posted_at, post_id, image_path FROM posted_libraries ORDER BY posted_at DESC`
+func (s *SQLiteStore) GetAll(ctx context.Context) ([]model.PostedLibrary, error) {
+	return queryPosts(ctx, s.db)
+}
 
-	rows, err := s.db.Query(query)
+func queryPosts(ctx context.Context, db execQuerier) ([]model.PostedLibrary, error) {
+	rows, err := db.QueryContext(ctx, selectPosts+` ORDER BY posted_at DESC`)
 	if err != nil {
 		return nil, err
 	}
@@ -82,45 +177,140 @@ func (s *SQLiteStore) GetAll() ([]model.PostedLibrary, error) {
 
 	var records []model.PostedLibrary
 	for rows.Next() {
-		var libraryData string
-		var posted model.PostedLibrary
-
-		if err := rows.Scan(&libraryData, &posted.PostedAt, &posted.PostID, &posted.ImagePath); err != nil {
+		posted, err := scanPost(rows)
+		if err != nil {
 			return nil, err
 		}
+		records = append(records, *posted)
+	}
 
-		if err := json.Unmarshal([]byte(libraryData), &posted.Library); err != nil {
-			return nil, err
-		}
+	return records, rows.Err()
+}
 
-		records = append(records, posted)
+// GetByName retrieves the most recent post of a library by name
+func (s *SQLiteStore) GetByName(ctx context.Context, name string) (*model.PostedLibrary, error) {
+	posted, err := scanPost(s.db.QueryRowContext(ctx, selectPosts+` WHERE name = ? ORDER BY posted_at DESC LIMIT 1`, name))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("library not found: %s", name)
+		}
+		return nil, err
 	}
 
-	return records, rows.Err()
+	return posted, nil
 }
 
-// GetByName retrieves a posted library by name
-func (s *SQLiteStore) GetByName(name string) (*model.PostedLibrary, error) {
-	query := `SELECT library_data, posted_at, post_id, image_path FROM posted_libraries WHERE name = ?`
+// scanner is satisfied by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
 
-	var libraryData string
+func scanPost(row scanner) (*model.PostedLibrary, error) {
 	var posted model.PostedLibrary
+	var libraryData, imagePaths, childIDs, remoteIDs string
+	var status, lastStep, format string
 
-	err := s.db.QueryRow(query, name).Scan(&libraryData, &posted.PostedAt, &posted.PostID, &posted.ImagePath)
+	err := row.Scan(
+		&posted.ID,
+		&libraryData,
+		&posted.PostedAt,
+		&posted.PostID,
+		&posted.ImagePath,
+		&status,
+		&lastStep,
+		&posted.Error,
+		&posted.Caption,
+		&imagePaths,
+		&childIDs,
+		&posted.ContainerID,
+		&format,
+		&posted.VideoPath,
+		&posted.StoryID,
+		&remoteIDs,
+	)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("library not found: %s", name)
-		}
 		return nil, err
 	}
 
+	posted.Status = model.PostStatus(status)
+	posted.LastStep = model.PostStatus(lastStep)
+	posted.Format = model.PostFormat(format)
+
 	if err := json.Unmarshal([]byte(libraryData), &posted.Library); err != nil {
 		return nil, err
 	}
+	if err := json.Unmarshal([]byte(imagePaths), &posted.ImagePaths); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(childIDs), &posted.ChildIDs); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(remoteIDs), &posted.RemoteIDs); err != nil {
+		return nil, err
+	}
 
 	return &posted, nil
 }
 
+// marshalPost encodes the JSON columns of a record
+func marshalPost(posted *model.PostedLibrary) (libraryData, imagePaths, childIDs, remoteIDs string, err error) {
+	data, err := json.Marshal(posted.Library)
+	if err != nil {
+		return "", "", "", "", err
+	}
+	paths, err := json.Marshal(posted.ImagePaths)
+	if err != nil {
+		return "", "", "", "", err
+	}
+	children, err := json.Marshal(posted.ChildIDs)
+	if err != nil {
+		return "", "", "", "", err
+	}
+	remote := []byte("{}")
+	if len(posted.RemoteIDs) > 0 {
+		if remote, err = json.Marshal(posted.RemoteIDs); err != nil {
+			return "", "", "", "", err
+		}
+	}
+
+	return string(data), string(paths), string(children), string(remote), nil
+}
+
+// postStatus stores records without a status as published, matching the
+// column default for rows written before post states existed
+func postStatus(posted *model.PostedLibrary) model.PostStatus {
+	if posted.Status == "" {
+		return model.PostStatusPublished
+	}
+	return posted.Status
+}
+
+// nullableID stores a missing ID as NULL so the unique index ignores it
+func nullableID(id string) interface{} {
+	if id == "" {
+		return nil
+	}
+	return id
+}
+
+// DeleteByName removes every record for a library and returns how many were removed
+func (s *SQLiteStore) DeleteByName(ctx context.Context, name string) (int, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM posted_libraries WHERE name = ?`, name)
+	if err != nil {
+		return 0, err
+	}
+
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if removed == 0 {
+		return 0, fmt.Errorf("library not found: %s", name)
+	}
+
+	return int(removed), nil
+}
+
 // Close closes the database connection
 func (s *SQLiteStore) Close() error {
 	return s.db.Close()
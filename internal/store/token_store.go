@@ -0,0 +1,169 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/nitin737/GoAutoPosts/internal/model"
+)
+
+// TokenStore keeps the Graph API access token between runs
+type TokenStore interface {
+	// Load returns the stored token, or nil when none is stored
+	Load(ctx context.Context) (*model.AccessToken, error)
+	Save(ctx context.Context, token *model.AccessToken) error
+	Close() error
+}
+
+// OpenTokenStore returns the token store for the given backend
+func OpenTokenStore(backend, jsonPath, sqlitePath string) (TokenStore, error) {
+	switch backend {
+	case "", BackendJSON:
+		return NewJSONTokenStore(jsonPath), nil
+	case BackendSQLite:
+		return NewSQLiteTokenStore(sqlitePath)
+	default:
+		return nil, fmt.Errorf("unknown token store backend: %s", backend)
+	}
+}
+
+// JSONTokenStore keeps the token in a JSON file readable only by its owner
+type JSONTokenStore struct {
+	filePath string
+	mu       sync.RWMutex
+}
+
+// NewJSONTokenStore creates a new JSON-based token store
+func NewJSONTokenStore(filePath string) *JSONTokenStore {
+	return &JSONTokenStore{
+		filePath: filePath,
+	}
+}
+
+// Load returns the stored token, or nil when the file does not exist
+func (s *JSONTokenStore) Load(ctx context.Context) (*model.AccessToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(s.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var token model.AccessToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// Save replaces the stored token
+func (s *JSONTokenStore) Save(ctx context.Context, token *model.AccessToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(token, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.filePath, data, 0600)
+}
+
+// Close is a no-op for the JSON store
+func (s *JSONTokenStore) Close() error {
+	return nil
+}
+
+// SQLiteTokenStore keeps the token in the single row of the access_tokens table
+type SQLiteTokenStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteTokenStore creates a new SQLite-based token store
+func NewSQLiteTokenStore(dbPath string) (*SQLiteTokenStore, error) {
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteTokenStore{db: db}, nil
+}
+
+// Load returns the stored token, or nil when none is stored
+func (s *SQLiteTokenStore) Load(ctx context.Context) (*model.AccessToken, error) {
+	var token model.AccessToken
+	var expiresAt sql.NullTime
+	var scopes string
+
+	err := s.db.QueryRowContext(ctx, `SELECT token, expires_at, refreshed_at, scopes, seed_hash FROM access_tokens WHERE id = 1`).
+		Scan(&token.Token, &expiresAt, &token.RefreshedAt, &scopes, &token.SeedHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if expiresAt.Valid {
+		token.ExpiresAt = expiresAt.Time
+	}
+	if err := json.Unmarshal([]byte(scopes), &token.Scopes); err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// Save replaces the stored token
+func (s *SQLiteTokenStore) Save(ctx context.Context, token *model.AccessToken) error {
+	scopes, err := json.Marshal(token.Scopes)
+	if err != nil {
+		return err
+	}
+
+	var expiresAt interface{}
+	if token.Expires() {
+		expiresAt = token.ExpiresAt.UTC()
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+	INSERT INTO access_tokens (id, token, expires_at, refreshed_at, scopes, seed_hash)
+	VALUES (1, ?, ?, ?, ?, ?)
+	ON CONFLICT(id) DO UPDATE SET
+		token = excluded.token,
+		expires_at = excluded.expires_at,
+		refreshed_at = excluded.refreshed_at,
+		scopes = excluded.scopes,
+		seed_hash = excluded.seed_hash
+	`, token.Token, expiresAt, token.RefreshedAt.UTC(), string(scopes), token.SeedHash)
+
+	return err
+}
+
+// Close closes the database connection
+func (s *SQLiteTokenStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/model"
+)
+
+func TestTokenStoreRoundTrip(t *testing.T) {
+	refreshedAt := time.Date(2026, 3, 15, 9, 30, 0, 0, time.UTC)
+	tokens := []*model.AccessToken{
+		{
+			Token:       "long-lived",
+			ExpiresAt:   refreshedAt.Add(60 * 24 * time.Hour),
+			RefreshedAt: refreshedAt,
+			Scopes:      []string{"instagram_basic", "instagram_content_publish"},
+			SeedHash:    model.TokenHash("short-lived"),
+		},
+		// A token that never expires and was not seeded replaces the first
+		{
+			Token:       "never-expires",
+			RefreshedAt: refreshedAt.Add(time.Hour),
+		},
+	}
+
+	for _, backend := range []string{BackendJSON, BackendSQLite} {
+		t.Run(backend, func(t *testing.T) {
+			ctx := context.Background()
+			dir := t.TempDir()
+			open := func() TokenStore {
+				s, err := OpenTokenStore(backend, filepath.Join(dir, "token.json"), filepath.Join(dir, "token.db"))
+				if err != nil {
+					t.Fatalf("OpenTokenStore: %v", err)
+				}
+				t.Cleanup(func() { s.Close() })
+				return s
+			}
+
+			if token, err := open().Load(ctx); token != nil || err != nil {
+				t.Fatalf("Load from an empty store = %+v, %v, want nil", token, err)
+			}
+
+			for _, want := range tokens {
+				if err := open().Save(ctx, want); err != nil {
+					t.Fatalf("Save: %v", err)
+				}
+
+				// A new store sees what the last run saved
+				got, err := open().Load(ctx)
+				if err != nil {
+					t.Fatalf("Load: %v", err)
+				}
+				if got.Token != want.Token || !got.ExpiresAt.Equal(want.ExpiresAt) || !got.RefreshedAt.Equal(want.RefreshedAt) ||
+					got.SeedHash != want.SeedHash || !reflect.DeepEqual(got.Scopes, want.Scopes) {
+					t.Errorf("Load = %+v, want %+v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestJSONTokenStoreIsPrivate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token.json")
+	if err := NewJSONTokenStore(path).Save(context.Background(), &model.AccessToken{Token: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("token file mode = %o, want 600", perm)
+	}
+}
@@ -31,6 +31,21 @@ func NewRenderer() (*Renderer, error) {
 
 // RenderCaption renders the Instagram caption for a library
 func (r *Renderer) RenderCaption(lib *model.Library, hashtags []string) (string, error) {
+	return r.render("caption.tmpl", lib, hashtags)
+}
+
+// RenderPlatformCaption renders the caption for another platform from
+// caption_<platform>.tmpl, falling back to the Instagram caption when the
+// platform has no template of its own
+func (r *Renderer) RenderPlatformCaption(platform string, lib *model.Library, hashtags []string) (string, error) {
+	name := "caption_" + platform + ".tmpl"
+	if r.templates.Lookup(name) == nil {
+		name = "caption.tmpl"
+	}
+	return r.render(name, lib, hashtags)
+}
+
+func (r *Renderer) render(name string, lib *model.Library, hashtags []string) (string, error) {
 	var buf bytes.Buffer
 
 	data := map[string]interface{}{
@@ -38,7 +53,7 @@ func (r *Renderer) RenderCaption(lib *model.Library, hashtags []string) (string,
 		"Hashtags": hashtags,
 	}
 
-	if err := r.templates.ExecuteTemplate(&buf, "caption.tmpl", data); err != nil {
+	if err := r.templates.ExecuteTemplate(&buf, name, data); err != nil {
 		return "", fmt.Errorf("failed to render caption: %w", err)
 	}
 
@@ -0,0 +1,170 @@
+// Package video turns rendered slides into MP4 slideshows for Reels. It
+// shells out to ffmpeg, which must be installed.
+package video
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nitin737/GoAutoPosts/internal/image"
+)
+
+// Reels dimensions (9:16); square slides are centred on the background colour
+const (
+	Width  = 1080
+	Height = 1920
+	FPS    = 30
+)
+
+// Reels length limits accepted by the Graph API
+const (
+	MinDuration = 3 * time.Second
+	MaxDuration = 90 * time.Second
+)
+
+// TransitionNone cuts from slide to slide without a transition
+const TransitionNone = "none"
+
+// DefaultTransitionDuration is how long a transition between slides takes
+const DefaultTransitionDuration = 500 * time.Millisecond
+
+// Options controls how a slideshow is rendered
+type Options struct {
+	// SlideDuration is how long each slide is on screen, transitions included
+	SlideDuration time.Duration
+	// Transition is an ffmpeg xfade transition such as fade, slideleft or
+	// wipeleft, or TransitionNone
+	Transition string
+	// TransitionDuration is how long each transition takes
+	TransitionDuration time.Duration
+	// AudioPath is an optional audio track, looped or cut to the video length
+	AudioPath string
+}
+
+// Duration returns the length of a slideshow of n slides
+func (o Options) Duration(n int) time.Duration {
+	if n == 0 {
+		return 0
+	}
+	return time.Duration(n)*o.SlideDuration - time.Duration(n-1)*o.transitionDuration()
+}
+
+func (o Options) transitionDuration() time.Duration {
+	if o.Transition == "" || o.Transition == TransitionNone {
+		return 0
+	}
+	return o.TransitionDuration
+}
+
+// Renderer renders slideshows with ffmpeg
+type Renderer struct {
+	ffmpegPath string
+}
+
+// NewRenderer creates a renderer that runs the given ffmpeg binary
+func NewRenderer(ffmpegPath string) *Renderer {
+	return &Renderer{
+		ffmpegPath: ffmpegPath,
+	}
+}
+
+// Slideshow renders slidePaths, in order, into an H.264/AAC MP4 at outputPath
+func (r *Renderer) Slideshow(ctx context.Context, slidePaths []string, outputPath string, opts Options) error {
+	args, err := slideshowArgs(slidePaths, outputPath, opts)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to ensure output dir: %w", err)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, r.ffmpegPath, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg failed: %w: %s", err, lastLines(stderr.String(), 5))
+	}
+
+	return nil
+}
+
+// slideshowArgs builds the ffmpeg command line: every slide is looped for
+// SlideDuration, padded to 9:16 and chained to the next with xfade
+func slideshowArgs(slidePaths []string, outputPath string, opts Options) ([]string, error) {
+	if len(slidePaths) == 0 {
+		return nil, fmt.Errorf("no slides to render")
+	}
+	if opts.SlideDuration <= opts.transitionDuration() {
+		return nil, fmt.Errorf("slide duration %s must be longer than the transition %s", opts.SlideDuration, opts.transitionDuration())
+	}
+
+	total := opts.Duration(len(slidePaths))
+	if total < MinDuration || total > MaxDuration {
+		return nil, fmt.Errorf("slideshow of %d slides lasts %s, Reels must be between %s and %s", len(slidePaths), total, MinDuration, MaxDuration)
+	}
+
+	args := []string{"-y", "-loglevel", "error"}
+	for _, path := range slidePaths {
+		args = append(args, "-loop", "1", "-framerate", fmt.Sprint(FPS), "-t", seconds(opts.SlideDuration), "-i", path)
+	}
+	if opts.AudioPath != "" {
+		args = append(args, "-stream_loop", "-1", "-i", opts.AudioPath)
+	}
+
+	bg := image.ColorBackgroundStart
+	var filters []string
+	for i := range slidePaths {
+		filters = append(filters, fmt.Sprintf(
+			"[%d:v]scale=%d:-2,pad=%d:%d:(ow-iw)/2:(oh-ih)/2:color=0x%02X%02X%02X,setsar=1,fps=%d,format=yuv420p[v%d]",
+			i, Width, Width, Height, bg.R, bg.G, bg.B, FPS, i))
+	}
+
+	last := "v0"
+	if transition := opts.transitionDuration(); transition > 0 {
+		for i := 1; i < len(slidePaths); i++ {
+			offset := time.Duration(i) * (opts.SlideDuration - transition)
+			out := fmt.Sprintf("x%d", i)
+			filters = append(filters, fmt.Sprintf("[%s][v%d]xfade=transition=%s:duration=%s:offset=%s[%s]",
+				last, i, opts.Transition, seconds(transition), seconds(offset), out))
+			last = out
+		}
+	} else if len(slidePaths) > 1 {
+		var inputs string
+		for i := range slidePaths {
+			inputs += fmt.Sprintf("[v%d]", i)
+		}
+		filters = append(filters, fmt.Sprintf("%sconcat=n=%d:v=1:a=0[cat]", inputs, len(slidePaths)))
+		last = "cat"
+	}
+
+	args = append(args, "-filter_complex", strings.Join(filters, ";"), "-map", "["+last+"]")
+	if opts.AudioPath != "" {
+		args = append(args, "-map", fmt.Sprintf("%d:a", len(slidePaths)), "-c:a", "aac", "-b:a", "128k")
+	}
+	args = append(args,
+		"-c:v", "libx264", "-preset", "medium", "-profile:v", "high", "-pix_fmt", "yuv420p",
+		"-r", fmt.Sprint(FPS), "-t", seconds(total), "-movflags", "+faststart",
+		outputPath,
+	)
+
+	return args, nil
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "; ")
+}
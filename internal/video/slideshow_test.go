@@ -0,0 +1,196 @@
+package video
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// argAfter returns the argument following the first occurrence of flag at
+// or after index from, and its index
+func argAfter(args []string, flag string, from int) (string, int) {
+	for i := from; i < len(args)-1; i++ {
+		if args[i] == flag {
+			return args[i+1], i + 1
+		}
+	}
+	return "", -1
+}
+
+// slides returns n slide paths
+func slides(n int) []string {
+	var paths []string
+	for i := 1; i <= n; i++ {
+		paths = append(paths, fmt.Sprintf("slide-%d.png", i))
+	}
+	return paths
+}
+
+func TestSlideshowArgs(t *testing.T) {
+	fade := Options{SlideDuration: 4 * time.Second, Transition: "fade", TransitionDuration: 500 * time.Millisecond}
+	cut := Options{SlideDuration: 4 * time.Second, Transition: TransitionNone, TransitionDuration: 500 * time.Millisecond}
+	withAudio := fade
+	withAudio.AudioPath = "music.m4a"
+
+	tests := []struct {
+		name   string
+		slides int
+		opts   Options
+		// graph lists the filters after the per-slide scaling, in order
+		graph    []string
+		output   string
+		duration string
+		audio    string
+	}{
+		{
+			name:     "one slide",
+			slides:   1,
+			opts:     fade,
+			output:   "[v0]",
+			duration: "4.000",
+		},
+		{
+			name:   "slides with transitions",
+			slides: 3,
+			opts:   fade,
+			graph: []string{
+				"[v0][v1]xfade=transition=fade:duration=0.500:offset=3.500[x1]",
+				"[x1][v2]xfade=transition=fade:duration=0.500:offset=7.000[x2]",
+			},
+			output:   "[x2]",
+			duration: "11.000",
+		},
+		{
+			name:     "slides cut together",
+			slides:   3,
+			opts:     cut,
+			graph:    []string{"[v0][v1][v2]concat=n=3:v=1:a=0[cat]"},
+			output:   "[cat]",
+			duration: "12.000",
+		},
+		{
+			name:     "empty transition cuts",
+			slides:   2,
+			opts:     Options{SlideDuration: 3 * time.Second, TransitionDuration: time.Second},
+			graph:    []string{"[v0][v1]concat=n=2:v=1:a=0[cat]"},
+			output:   "[cat]",
+			duration: "6.000",
+		},
+		{
+			name:   "audio",
+			slides: 2,
+			opts:   withAudio,
+			graph: []string{
+				"[v0][v1]xfade=transition=fade:duration=0.500:offset=3.500[x1]",
+			},
+			output:   "[x1]",
+			duration: "7.500",
+			audio:    "2:a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := slideshowArgs(slides(tt.slides), "out/reel.mp4", tt.opts)
+			if err != nil {
+				t.Fatalf("slideshowArgs: %v", err)
+			}
+
+			// Every slide is an input looped for the slide duration, then the audio
+			var inputs []string
+			for i := 0; i < len(args)-1; i++ {
+				if args[i] == "-i" {
+					inputs = append(inputs, args[i+1])
+				}
+			}
+			wantInputs := slides(tt.slides)
+			if tt.opts.AudioPath != "" {
+				wantInputs = append(wantInputs, tt.opts.AudioPath)
+			}
+			if strings.Join(inputs, " ") != strings.Join(wantInputs, " ") {
+				t.Errorf("inputs = %v, want %v", inputs, wantInputs)
+			}
+			loop := fmt.Sprintf("-loop 1 -framerate 30 -t %s -i", seconds(tt.opts.SlideDuration))
+			if got := strings.Count(strings.Join(args, " "), loop); got != tt.slides {
+				t.Errorf("%d inputs looped with %q, want %d", got, loop, tt.slides)
+			}
+
+			graph, _ := argAfter(args, "-filter_complex", 0)
+			filters := strings.Split(graph, ";")
+			if len(filters) != tt.slides+len(tt.graph) {
+				t.Fatalf("filter graph has %d filters, want %d: %s", len(filters), tt.slides+len(tt.graph), graph)
+			}
+			for i := 0; i < tt.slides; i++ {
+				if want := fmt.Sprintf("[%d:v]scale=1080:-2,pad=1080:1920:", i); !strings.HasPrefix(filters[i], want) || !strings.HasSuffix(filters[i], fmt.Sprintf("[v%d]", i)) {
+					t.Errorf("filter %d = %s, want slide %d padded to 1080x1920 as [v%d]", i, filters[i], i, i)
+				}
+			}
+			for i, want := range tt.graph {
+				if got := filters[tt.slides+i]; got != want {
+					t.Errorf("filter %d = %s, want %s", tt.slides+i, got, want)
+				}
+			}
+
+			video, at := argAfter(args, "-map", 0)
+			if video != tt.output {
+				t.Errorf("video map = %s, want %s", video, tt.output)
+			}
+			audio, _ := argAfter(args, "-map", at)
+			if audio != tt.audio {
+				t.Errorf("audio map = %q, want %q", audio, tt.audio)
+			}
+			if codec, _ := argAfter(args, "-c:a", 0); (codec != "") != (tt.audio != "") {
+				t.Errorf("audio codec = %q with audio map %q", codec, tt.audio)
+			}
+
+			if got, _ := argAfter(args, "-r", 0); got != "30" {
+				t.Errorf("frame rate = %s, want 30", got)
+			}
+			// The command ends with -t <duration> -movflags +faststart <output>
+			if got := args[len(args)-4]; got != tt.duration {
+				t.Errorf("output duration = %s, want %s", got, tt.duration)
+			}
+			if got := args[len(args)-1]; got != "out/reel.mp4" {
+				t.Errorf("last argument = %s, want the output path", got)
+			}
+		})
+	}
+}
+
+func TestSlideshowArgsRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		slides int
+		opts   Options
+		want   string
+	}{
+		{"no slides", 0, Options{SlideDuration: 4 * time.Second}, "no slides"},
+		{"transition as long as a slide", 3, Options{SlideDuration: time.Second, Transition: "fade", TransitionDuration: time.Second}, "must be longer than the transition"},
+		{"too short", 1, Options{SlideDuration: 2 * time.Second}, "Reels must be between"},
+		{"too long", 30, Options{SlideDuration: 4 * time.Second}, "lasts 2m0s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := slideshowArgs(slides(tt.slides), "reel.mp4", tt.opts); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("slideshowArgs error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDuration(t *testing.T) {
+	opts := Options{SlideDuration: 4 * time.Second, Transition: "slideleft", TransitionDuration: 500 * time.Millisecond}
+
+	for n, want := range map[int]time.Duration{0: 0, 1: 4 * time.Second, 2: 7500 * time.Millisecond, 10: 35500 * time.Millisecond} {
+		if got := opts.Duration(n); got != want {
+			t.Errorf("Duration(%d) = %s, want %s", n, got, want)
+		}
+	}
+
+	opts.Transition = TransitionNone
+	if got := opts.Duration(10); got != 40*time.Second {
+		t.Errorf("Duration(10) without transitions = %s, want 40s", got)
+	}
+}
@@ -36,7 +36,7 @@ echo ""
 
 # Build the application
 echo "🔨 Building application..."
-go build -o bin/publisher cmd/publisher/main.go
+go build -o bin/publisher ./cmd/publisher
 echo "✅ Build successful"
 echo ""
 